@@ -0,0 +1,418 @@
+// Package archive packs a spec directory into a portable tar.gz and unpacks
+// it into another workspace. Every archive starts with a manifest.json that
+// lists each file with its size and SHA-256, so an import can verify the
+// contents before anything lands in .spec-workflow/specs/.
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+// Format identifies the archive layout written by Export.
+const Format = "oraculo-spec/1"
+
+const (
+	manifestName = "manifest.json"
+	// filesPrefix is the tar directory that holds the spec's files.
+	filesPrefix = "spec/"
+)
+
+// ErrSpecExists is returned by Import when the target spec directory is
+// already present.
+var ErrSpecExists = errors.New("spec already exists")
+
+// Manifest describes the contents of an archive.
+type Manifest struct {
+	Format        string         `json:"format"`
+	Spec          string         `json:"spec"`
+	CreatedAt     string         `json:"created_at"`
+	SchemaVersion int            `json:"schema_version,omitempty"`
+	Files         []ManifestFile `json:"files"`
+}
+
+// ManifestFile is one file entry, with its path relative to the spec
+// directory in slash form.
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ImportOptions controls Import.
+type ImportOptions struct {
+	// Rename imports the spec under this name instead of the one recorded
+	// in the manifest.
+	Rename string
+}
+
+// ImportResult describes a completed import.
+type ImportResult struct {
+	Spec     string    `json:"spec"`
+	From     string    `json:"from"`
+	Dir      string    `json:"dir"`
+	Manifest *Manifest `json:"-"`
+}
+
+// Export writes specDir as a gzip-compressed tar to w and returns the
+// manifest it wrote. spec.db is checkpointed first so its WAL is folded into
+// the main file; the -wal/-shm sidecars and rebuild leftovers are skipped.
+func Export(specDir string, w io.Writer) (*Manifest, error) {
+	m := &Manifest{
+		Format:    Format,
+		Spec:      filepath.Base(specDir),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+		version, err := checkpoint(specDir)
+		if err != nil {
+			return nil, err
+		}
+		m.SchemaVersion = version
+	}
+
+	files, err := collect(specDir)
+	if err != nil {
+		return nil, err
+	}
+	m.Files = files
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("archive: encode manifest: %w", err)
+	}
+	if err := writeEntry(tw, manifestName, int64(len(data)), strings.NewReader(string(data))); err != nil {
+		return nil, err
+	}
+
+	for _, f := range m.Files {
+		if err := copyFile(tw, specDir, f); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("archive: close tar: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("archive: close gzip: %w", err)
+	}
+	return m, nil
+}
+
+// Import unpacks the archive at archivePath into specsRoot. The archive is
+// extracted into a hidden temporary directory and checked against its
+// manifest — every file listed, no file unlisted, sizes and hashes matching —
+// before the directory is renamed into place.
+func Import(archivePath, specsRoot string, opts ImportOptions) (*ImportResult, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("archive: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("archive: %s is not a gzip archive: %w", filepath.Base(archivePath), err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	m, err := readManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	name := m.Spec
+	if opts.Rename != "" {
+		name = opts.Rename
+	}
+	if err := ValidateSpecName(name); err != nil {
+		return nil, err
+	}
+	target := filepath.Join(specsRoot, name)
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("archive: %w: %s", ErrSpecExists, name)
+	}
+
+	if err := os.MkdirAll(specsRoot, 0755); err != nil {
+		return nil, fmt.Errorf("archive: %w", err)
+	}
+	tmp, err := os.MkdirTemp(specsRoot, ".import-")
+	if err != nil {
+		return nil, fmt.Errorf("archive: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			os.RemoveAll(tmp)
+		}
+	}()
+
+	if err := extract(tr, tmp, m); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return nil, fmt.Errorf("archive: move into place: %w", err)
+	}
+	committed = true
+
+	return &ImportResult{Spec: name, From: m.Spec, Dir: target, Manifest: m}, nil
+}
+
+// ValidateSpecName rejects names that are not a single, visible directory
+// name under .spec-workflow/specs/.
+func ValidateSpecName(name string) error {
+	switch {
+	case name == "", name == ".", name == "..":
+		return fmt.Errorf("archive: invalid spec name %q", name)
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("archive: invalid spec name %q: must not contain a path separator", name)
+	case strings.HasPrefix(name, "."):
+		return fmt.Errorf("archive: invalid spec name %q: must not start with a dot", name)
+	}
+	return nil
+}
+
+// checkpoint folds the spec.db WAL into the main file and returns the
+// schema version.
+func checkpoint(specDir string) (int, error) {
+	s, err := store.OpenNoMigrate(specDir)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+	if err := s.Checkpoint(); err != nil {
+		return 0, err
+	}
+	return s.SchemaVersion()
+}
+
+// skipFile reports whether a spec-root file is SQLite or rebuild state that
+// must not travel with the archive.
+func skipFile(rel string) bool {
+	if strings.Contains(rel, "/") {
+		return false
+	}
+	if rel == specdir.SpecDB {
+		return false
+	}
+	return strings.HasPrefix(rel, specdir.SpecDB)
+}
+
+// collect walks specDir and hashes every regular file in path order.
+func collect(specDir string) ([]ManifestFile, error) {
+	var files []ManifestFile
+	err := filepath.WalkDir(specDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(specDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if skipFile(rel) {
+			return nil
+		}
+		size, sum, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, ManifestFile{Path: rel, Size: size, SHA256: sum})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("archive: scan %s: %w", filepath.Base(specDir), err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func hashFile(p string) (int64, string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyFile streams one file into the tar and fails if it no longer matches
+// the hash recorded in the manifest.
+func copyFile(tw *tar.Writer, specDir string, mf ManifestFile) error {
+	f, err := os.Open(filepath.Join(specDir, filepath.FromSlash(mf.Path)))
+	if err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if err := writeEntry(tw, filesPrefix+mf.Path, mf.Size, io.TeeReader(f, h)); err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != mf.SHA256 {
+		return fmt.Errorf("archive: %s changed during export", mf.Path)
+	}
+	return nil
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	hdr := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("archive: write %s: %w", name, err)
+	}
+	// CopyN fails if the file shrank; a file that grew is caught by the hash.
+	if _, err := io.CopyN(tw, r, size); err != nil {
+		return fmt.Errorf("archive: write %s: %w", name, err)
+	}
+	return nil
+}
+
+// readManifest reads and validates the leading manifest.json entry.
+func readManifest(tr *tar.Reader) (*Manifest, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("archive: read manifest: %w", err)
+	}
+	if hdr.Name != manifestName {
+		return nil, fmt.Errorf("archive: first entry is %q, want %s", hdr.Name, manifestName)
+	}
+	var m Manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return nil, fmt.Errorf("archive: decode manifest: %w", err)
+	}
+	if m.Format != Format {
+		return nil, fmt.Errorf("archive: unsupported format %q", m.Format)
+	}
+	if err := ValidateSpecName(m.Spec); err != nil {
+		return nil, err
+	}
+	for _, f := range m.Files {
+		if _, err := safeRel(f.Path); err != nil {
+			return nil, err
+		}
+	}
+	return &m, nil
+}
+
+// safeRel validates a manifest or tar path and returns it in OS form.
+func safeRel(rel string) (string, error) {
+	clean := path.Clean(rel)
+	if rel == "" || clean != rel || path.IsAbs(rel) || clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(rel, `\`) {
+		return "", fmt.Errorf("archive: unsafe path %q", rel)
+	}
+	return filepath.FromSlash(clean), nil
+}
+
+// extract writes the file entries into dir, checking each against the
+// manifest.
+func extract(tr *tar.Reader, dir string, m *Manifest) error {
+	want := make(map[string]ManifestFile, len(m.Files))
+	for _, f := range m.Files {
+		want[f.Path] = f
+	}
+	seen := make(map[string]bool, len(m.Files))
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("archive: read: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return fmt.Errorf("archive: %s: unsupported entry type", hdr.Name)
+		}
+		rel, ok := strings.CutPrefix(hdr.Name, filesPrefix)
+		if !ok {
+			return fmt.Errorf("archive: unexpected entry %s", hdr.Name)
+		}
+		mf, ok := want[rel]
+		if !ok {
+			return fmt.Errorf("archive: %s is not listed in the manifest", rel)
+		}
+		if seen[rel] {
+			return fmt.Errorf("archive: %s appears twice", rel)
+		}
+		seen[rel] = true
+
+		osRel, err := safeRel(rel)
+		if err != nil {
+			return err
+		}
+		if err := writeVerified(filepath.Join(dir, osRel), tr, mf); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range m.Files {
+		if !seen[f.Path] {
+			return fmt.Errorf("archive: %s is listed in the manifest but missing", f.Path)
+		}
+	}
+	return nil
+}
+
+func writeVerified(dst string, r io.Reader, mf ManifestFile) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, h), r)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("archive: extract %s: %w", mf.Path, err)
+	}
+	if n != mf.Size {
+		return fmt.Errorf("archive: %s: size %d does not match manifest (%d)", mf.Path, n, mf.Size)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != mf.SHA256 {
+		return fmt.Errorf("archive: %s: hash mismatch", mf.Path)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// makeSpec builds specs/<name> with a few files and a spec.db holding one
+// meta row.
+func makeSpec(t *testing.T, root, name string) string {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	writeFile(t, filepath.Join(dir, "requirements.md"), "# Requirements")
+	writeFile(t, filepath.Join(dir, "tasks.md"), "# Tasks")
+	writeFile(t, filepath.Join(dir, "execution", "waves", "wave-01", "_latest.json"), `{"run_id":"run-001"}`)
+
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetMeta("stage", "execution"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func exportToFile(t *testing.T, specDir string) string {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "spec.tar.gz")
+	f, err := os.Create(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := Export(specDir, f); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	return out
+}
+
+// writeArchive builds an archive by hand from a manifest and named entries.
+func writeArchive(t *testing.T, m Manifest, entries map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	data, _ := json.Marshal(m)
+	add := func(name, body string) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(body))
+	}
+	add(manifestName, string(data))
+	for name, body := range entries {
+		add(name, body)
+	}
+	tw.Close()
+	gz.Close()
+
+	out := filepath.Join(t.TempDir(), "hand.tar.gz")
+	if err := os.WriteFile(out, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	specDir := makeSpec(t, src, "alpha")
+	// Leftovers that must not be exported.
+	writeFile(t, filepath.Join(specDir, "spec.db.bak"), "old")
+
+	archivePath := exportToFile(t, specDir)
+
+	dst := t.TempDir()
+	res, err := Import(archivePath, dst, ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if res.Spec != "alpha" || res.From != "alpha" {
+		t.Errorf("result = %+v", res)
+	}
+
+	for _, f := range res.Manifest.Files {
+		if strings.HasPrefix(f.Path, "spec.db-") || f.Path == "spec.db.bak" {
+			t.Errorf("exported sidecar %s", f.Path)
+		}
+	}
+	got, err := os.ReadFile(filepath.Join(dst, "alpha", "execution", "waves", "wave-01", "_latest.json"))
+	if err != nil || string(got) != `{"run_id":"run-001"}` {
+		t.Errorf("_latest.json = %q, %v", got, err)
+	}
+
+	s, err := store.Open(filepath.Join(dst, "alpha"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if v, _ := s.GetMeta("stage"); v != "execution" {
+		t.Errorf("imported meta stage = %q, want execution", v)
+	}
+
+	entries, _ := os.ReadDir(dst)
+	if len(entries) != 1 {
+		t.Errorf("specs root has %d entries, want only the imported spec", len(entries))
+	}
+}
+
+func TestImportCollisionAndRename(t *testing.T) {
+	src := t.TempDir()
+	archivePath := exportToFile(t, makeSpec(t, src, "alpha"))
+
+	dst := t.TempDir()
+	writeFile(t, filepath.Join(dst, "alpha", "tasks.md"), "existing")
+
+	_, err := Import(archivePath, dst, ImportOptions{})
+	if !errors.Is(err, ErrSpecExists) {
+		t.Fatalf("Import over existing spec: err = %v, want ErrSpecExists", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dst, "alpha", "tasks.md")); string(got) != "existing" {
+		t.Errorf("existing spec was modified: %q", got)
+	}
+
+	res, err := Import(archivePath, dst, ImportOptions{Rename: "beta"})
+	if err != nil {
+		t.Fatalf("Import --rename: %v", err)
+	}
+	if res.Spec != "beta" || res.From != "alpha" {
+		t.Errorf("result = %+v", res)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "beta", "tasks.md")); err != nil {
+		t.Errorf("renamed spec missing tasks.md: %v", err)
+	}
+
+	if _, err := Import(archivePath, dst, ImportOptions{Rename: "../escape"}); err == nil {
+		t.Error("Import accepted a rename with a path separator")
+	}
+}
+
+func TestImportRejectsTamperedArchive(t *testing.T) {
+	good := "hello"
+	m := Manifest{
+		Format: Format,
+		Spec:   "alpha",
+		Files: []ManifestFile{{
+			Path:   "tasks.md",
+			Size:   int64(len(good)),
+			SHA256: store.ContentHash([]byte(good)),
+		}},
+	}
+
+	cases := []struct {
+		name    string
+		m       Manifest
+		entries map[string]string
+		want    string
+	}{
+		{"hash mismatch", m, map[string]string{"spec/tasks.md": "hellO"}, "hash mismatch"},
+		{"missing file", m, map[string]string{}, "missing"},
+		{"unlisted file", m, map[string]string{"spec/tasks.md": good, "spec/extra.md": "x"}, "not listed"},
+		{"traversal", Manifest{Format: Format, Spec: "alpha", Files: []ManifestFile{{Path: "../evil", Size: 1}}},
+			map[string]string{"spec/../evil": "x"}, "unsafe path"},
+		{"bad format", Manifest{Format: "other", Spec: "alpha"}, nil, "unsupported format"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := t.TempDir()
+			_, err := Import(writeArchive(t, tc.m, tc.entries), dst, ImportOptions{})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("err = %v, want containing %q", err, tc.want)
+			}
+			entries, _ := os.ReadDir(dst)
+			if len(entries) != 0 {
+				t.Errorf("failed import left %d entries behind", len(entries))
+			}
+		})
+	}
+}
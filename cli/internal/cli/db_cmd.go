@@ -0,0 +1,363 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/reconcile"
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	"github.com/lucas-stellet/oraculo/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+func newDBCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "spec.db maintenance commands",
+		Long:  "Inspect, migrate, check and rebuild the per-spec SQLite database (spec.db).",
+	}
+
+	cmd.AddCommand(newDBStatusCmd())
+	cmd.AddCommand(newDBMigrateCmd())
+	cmd.AddCommand(newDBRebuildCmd())
+	cmd.AddCommand(newDBFsckCmd())
+
+	return cmd
+}
+
+// migrationView is the JSON shape for a migration in db command output.
+type migrationView struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+func newDBStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status <spec-name>",
+		Short: "Show spec.db schema version and pending migrations",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			cwd := getCwd()
+			specName := args[0]
+
+			specDir, err := specdir.Resolve(cwd, specName)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			dbPath := filepath.Join(specDir, specdir.SpecDB)
+			latest := store.LatestSchemaVersion()
+			version := 0
+			exists := specdir.FileExists(dbPath)
+			if exists {
+				s, err := store.OpenNoMigrate(specDir)
+				if err != nil {
+					tools.Fail(err.Error(), raw)
+				}
+				version, err = s.SchemaVersion()
+				s.Close()
+				if err != nil {
+					tools.Fail(err.Error(), raw)
+				}
+			}
+
+			var migrations []migrationView
+			var pending []string
+			for _, m := range store.Migrations() {
+				applied := m.Version <= version
+				migrations = append(migrations, migrationView{Version: m.Version, Name: m.Name, Applied: applied})
+				if !applied {
+					pending = append(pending, fmt.Sprintf("%03d_%s", m.Version, m.Name))
+				}
+			}
+
+			dbRel, _ := filepath.Rel(cwd, dbPath)
+			result := map[string]any{
+				"ok":         true,
+				"spec":       specName,
+				"db_path":    dbRel,
+				"exists":     exists,
+				"version":    version,
+				"latest":     latest,
+				"too_new":    version > latest,
+				"pending":    pending,
+				"migrations": migrations,
+			}
+			tools.Output(result, fmt.Sprintf("%d/%d", version, latest), raw)
+		},
+	}
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
+func newDBMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate <spec-name>",
+		Short: "Apply pending spec.db migrations",
+		Long:  "Applies embedded schema migrations in order, each in its own transaction. Use --dry-run to list what would run.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			cwd := getCwd()
+			specName := args[0]
+
+			specDir, err := specdir.Resolve(cwd, specName)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			// Opening creates the database, which a dry run must not do.
+			if dryRun && !specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+				tools.Fail(fmt.Sprintf("no spec.db in %s", specName), raw)
+			}
+
+			s, err := store.OpenNoMigrate(specDir)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			defer s.Close()
+
+			from, err := s.SchemaVersion()
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			pending, err := s.PendingMigrations()
+			if err != nil {
+				if errors.Is(err, store.ErrSchemaTooNew) {
+					tools.Fail(fmt.Sprintf("refusing to migrate: %s", err), raw)
+				}
+				tools.Fail(err.Error(), raw)
+			}
+
+			toApply := pending
+			if !dryRun {
+				toApply, err = s.ApplyMigrations()
+				if err != nil {
+					tools.Fail(err.Error(), raw)
+				}
+			}
+
+			names := make([]string, 0, len(toApply))
+			for _, m := range toApply {
+				names = append(names, fmt.Sprintf("%03d_%s", m.Version, m.Name))
+			}
+
+			to := from
+			if len(toApply) > 0 {
+				to = toApply[len(toApply)-1].Version
+			}
+
+			key := "applied"
+			if dryRun {
+				key = "pending"
+			}
+			result := map[string]any{
+				"ok":      true,
+				"spec":    specName,
+				"dry_run": dryRun,
+				"from":    from,
+				"to":      to,
+				key:       names,
+			}
+			tools.Output(result, fmt.Sprintf("%d->%d", from, to), raw)
+		},
+	}
+	cmd.Flags().Bool("dry-run", false, "List pending migrations without applying them")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
+func newDBRebuildCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rebuild <spec-name>",
+		Short: "Rebuild spec.db from the spec directory",
+		Long: "Re-harvests run dirs, artifacts, implementation logs, tasks.md and wave state into a fresh spec.db. " +
+			"The previous database is kept as spec.db.bak. With --shadow the new database is left at spec.db.rebuild for inspection.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			shadow, _ := cmd.Flags().GetBool("shadow")
+			cwd := getCwd()
+			specName := args[0]
+
+			specDir, err := specdir.Resolve(cwd, specName)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			report, err := reconcile.Rebuild(specDir, reconcile.RebuildOptions{Shadow: shadow})
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			dbRel, _ := filepath.Rel(cwd, report.DBPath)
+			result := map[string]any{
+				"ok":                  true,
+				"spec":                specName,
+				"db_path":             dbRel,
+				"shadow":              report.Shadow,
+				"harvested":           report.Harvest,
+				"counts":              report.Counts,
+				"preserved_meta":      report.PreservedMeta,
+				"preserved_summary":   report.PreservedSummary,
+				"preserved_events":    report.PreservedEvents,
+				"preserved_versions":  report.PreservedVersions,
+				"preserved_runs":      report.PreservedRuns,
+				"preserved_run_times": report.PreservedRunTimes,
+				"preserved_waves":     report.PreservedWaves,
+			}
+			if report.BackupPath != "" {
+				backupRel, _ := filepath.Rel(cwd, report.BackupPath)
+				result["backup_path"] = backupRel
+			}
+			h := report.Harvest
+			rawValue := fmt.Sprintf("%d runs, %d artifacts, %d impl logs, %d tasks, %d waves",
+				h.Runs, h.Artifacts, h.ImplLogs, h.Tasks, h.Waves)
+			tools.Output(result, rawValue, raw)
+		},
+	}
+	cmd.Flags().Bool("shadow", false, "Build spec.db.rebuild without replacing spec.db")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
+// fsckExitDrift is the exit status of `db fsck` when drift remains.
+const fsckExitDrift = 2
+
+func newDBFsckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fsck <spec-name>",
+		Short: "Check spec.db against the spec directory",
+		Long: `Compares run dirs, subagent brief/report/status.json, artifacts (including
+_latest.json), tasks.md and implementation logs with the rows in spec.db and
+lists missing, extra and content-mismatched records.
+
+Exit status is 0 when clean, 2 when drift remains and 1 on error.
+--repair re-harvests only the drifted entries and deletes extra rows.`,
+		Args: cobra.ExactArgs(1),
+		Run:  runDBFsck,
+	}
+	cmd.Flags().Bool("repair", false, "Re-harvest drifted entries and delete extra rows")
+	cmd.Flags().Bool("raw", false, "Output raw JSON")
+	return cmd
+}
+
+func runDBFsck(cmd *cobra.Command, args []string) {
+	raw, _ := cmd.Flags().GetBool("raw")
+	repair, _ := cmd.Flags().GetBool("repair")
+	cwd := getCwd()
+	specName := args[0]
+
+	specDir, err := specdir.Resolve(cwd, specName)
+	if err != nil {
+		failText(err.Error(), raw)
+	}
+	if !specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+		failText("spec.db not found (run 'oraculo db rebuild "+specName+"')", raw)
+	}
+
+	s, err := store.OpenNoMigrate(specDir)
+	if err != nil {
+		failText(err.Error(), raw)
+	}
+	defer s.Close()
+
+	pending, err := s.PendingMigrations()
+	if err != nil {
+		failText(err.Error(), raw)
+	}
+	if len(pending) > 0 {
+		if !repair {
+			failText(fmt.Sprintf("spec.db has %d pending migrations (run 'oraculo db migrate %s')", len(pending), specName), raw)
+		}
+		if err := s.Migrate(); err != nil {
+			failText(err.Error(), raw)
+		}
+	}
+
+	report, err := reconcile.Fsck(s, specDir)
+	if err != nil {
+		failText(err.Error(), raw)
+	}
+	if repair && !report.Clean() {
+		if err := reconcile.Repair(s, specDir, report); err != nil {
+			failText(err.Error(), raw)
+		}
+	}
+	drifted := report.Drifted()
+
+	if raw {
+		result := map[string]any{
+			"ok":       len(drifted) == 0,
+			"spec":     specName,
+			"repair":   repair,
+			"checked":  report.Checked,
+			"findings": report.Findings,
+			"drift":    len(drifted),
+		}
+		if len(report.Errors) > 0 {
+			result["errors"] = report.Errors
+		}
+		tools.Output(result, "", false)
+	} else {
+		printFsckReport(specName, report)
+	}
+
+	if len(drifted) > 0 {
+		os.Exit(fsckExitDrift)
+	}
+}
+
+func printFsckReport(specName string, report *reconcile.FsckReport) {
+	var checked []string
+	for _, entity := range []string{
+		reconcile.EntityRun, reconcile.EntitySubagent, reconcile.EntityArtifact,
+		reconcile.EntityTask, reconcile.EntityImplLog,
+	} {
+		checked = append(checked, fmt.Sprintf("%d %s", report.Checked[entity], entity))
+	}
+	fmt.Printf("fsck %s: checked %s\n", specName, strings.Join(checked, ", "))
+
+	for _, f := range report.Findings {
+		mark := " "
+		if f.Repaired {
+			mark = "✓"
+		}
+		line := fmt.Sprintf("  %s %-8s  %-8s  %s", mark, f.Kind, f.Entity, f.Key)
+		if f.Detail != "" {
+			line += "  (" + f.Detail + ")"
+		}
+		fmt.Println(line)
+	}
+	for _, e := range report.Errors {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", e)
+	}
+
+	drifted := len(report.Drifted())
+	switch {
+	case report.Clean():
+		fmt.Println("clean")
+	case drifted == 0:
+		fmt.Printf("%d findings, all repaired\n", len(report.Findings))
+	default:
+		fmt.Printf("%d findings, %d unrepaired\n", len(report.Findings), drifted)
+	}
+}
+
+// failText reports an error for commands whose default output is text:
+// with --raw the JSON error shape is used, otherwise a plain message.
+func failText(message string, raw bool) {
+	if raw {
+		tools.Fail(message, false)
+	}
+	fmt.Fprintf(os.Stderr, "Error: %s\n", message)
+	os.Exit(1)
+}
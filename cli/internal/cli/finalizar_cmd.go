@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
+	"github.com/lucas-stellet/oraculo/internal/reconcile"
 	"github.com/lucas-stellet/oraculo/internal/specdir"
 	"github.com/lucas-stellet/oraculo/internal/store"
 	"github.com/lucas-stellet/oraculo/internal/summary"
@@ -53,13 +53,13 @@ func runFinalizar(cmd *cobra.Command, args []string) {
 		finalizarFail(fmt.Sprintf("cannot parse tasks.md: %s", err), raw)
 	}
 
-	// 3. Validate all non-deferred tasks are done.
+	// 3. Validate all non-deferred tasks are done (or skipped).
 	var incomplete []string
 	for _, t := range doc.Tasks {
 		if t.IsDeferred {
 			continue
 		}
-		if t.Status != "done" {
+		if t.Status != "done" && t.Status != "skipped" {
 			incomplete = append(incomplete, fmt.Sprintf("  - Task %s: %s (%s)", t.ID, t.Title, t.Status))
 		}
 	}
@@ -83,21 +83,11 @@ func runFinalizar(cmd *cobra.Command, args []string) {
 	defer s.Close()
 
 	// 6. Harvest all remaining filesystem artifacts.
-	harvested := harvestAllArtifacts(s, sd)
+	harvested := reconcile.HarvestFiles(s, sd).Total()
 
 	// 7. Sync all tasks to DB.
 	for _, t := range doc.Tasks {
-		wavePtr := &t.Wave
-		_ = s.SyncTask(store.TaskRecord{
-			TaskID:     t.ID,
-			Title:      t.Title,
-			Status:     t.Status,
-			Wave:       wavePtr,
-			DependsOn:  strings.Join(t.DependsOn, ","),
-			Files:      t.Files,
-			TDD:        t.TDD != "",
-			IsDeferred: t.IsDeferred,
-		})
+		_ = s.SyncTask(t.Record())
 	}
 
 	// 8. Scan waves.
@@ -122,10 +112,18 @@ func runFinalizar(cmd *cobra.Command, args []string) {
 	}
 
 	// 11. Update spec_meta.
+	prevStatus, _ := s.GetMeta("status")
+	prevStage, _ := s.GetMeta("stage")
 	nowStr := time.Now().UTC().Format(time.RFC3339)
 	_ = s.SetMeta("status", "completed")
 	_ = s.SetMeta("stage", "complete")
 	_ = s.SetMeta("completed_at", nowStr)
+	_ = s.RecordEvent(store.Event{
+		Command: "finalizar",
+		Args:    map[string]any{"export": export, "force": force},
+		Before:  map[string]any{"status": prevStatus, "stage": prevStage},
+		After:   map[string]any{"status": "completed", "stage": "complete", "completed_at": nowStr},
+	})
 
 	// 12. Index in global .oraculo-index.db.
 	var docsIndexed int
@@ -172,149 +170,6 @@ func runFinalizar(cmd *cobra.Command, args []string) {
 	outputJSON(result, rawValue, raw)
 }
 
-// harvestAllArtifacts walks phase directories and harvests files into the DB.
-func harvestAllArtifacts(s *store.SpecStore, sd string) int {
-	var count int
-
-	phases := []struct {
-		name string
-		dir  string
-	}{
-		{specdir.PhaseDiscover, specdir.PhaseDiscover},
-		{specdir.PhaseDesign, specdir.PhaseDesign},
-		{specdir.PhasePlanning, specdir.PhasePlanning},
-		{specdir.PhaseExecution, specdir.PhaseExecution},
-		{specdir.PhaseQA, specdir.PhaseQA},
-		{specdir.PhasePostMortem, specdir.PhasePostMortem},
-	}
-
-	for _, p := range phases {
-		phaseDir := filepath.Join(sd, p.dir)
-		if !specdir.DirExists(phaseDir) {
-			continue
-		}
-		count += harvestPhaseDir(s, sd, p.name, phaseDir)
-	}
-
-	// Harvest implementation logs.
-	implDir := filepath.Join(sd, specdir.ImplLogsDir)
-	if specdir.DirExists(implDir) {
-		entries, err := os.ReadDir(implDir)
-		if err == nil {
-			for _, e := range entries {
-				if e.IsDir() {
-					continue
-				}
-				taskID := extractTaskID(e.Name())
-				if taskID != "" {
-					absPath := filepath.Join(implDir, e.Name())
-					if s.HarvestImplLog(taskID, absPath) == nil {
-						count++
-					}
-				}
-			}
-		}
-	}
-
-	return count
-}
-
-// harvestPhaseDir walks a phase directory and harvests markdown/json files and run dirs.
-func harvestPhaseDir(s *store.SpecStore, sd, phase, phaseDir string) int {
-	var count int
-
-	_ = filepath.Walk(phaseDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-
-		// Harvest run directories via HarvestRunDir.
-		if info.IsDir() && runDirReFC.MatchString(info.Name()) {
-			// Determine wave number if this is inside a wave dir.
-			var waveNum *int
-			wn := extractWaveNum(path)
-			if wn > 0 {
-				waveNum = &wn
-			}
-			command := inferCommandFromPath(path)
-			if s.HarvestRunDir(path, command, waveNum) == nil {
-				count++
-			}
-			return filepath.SkipDir
-		}
-
-		// Harvest individual markdown and json files as artifacts.
-		if !info.IsDir() && (filepath.Ext(path) == ".md" || filepath.Ext(path) == ".json") {
-			relPath, _ := filepath.Rel(sd, path)
-			if s.HarvestArtifact(phase, relPath, path) == nil {
-				count++
-			}
-		}
-
-		return nil
-	})
-
-	return count
-}
-
-var (
-	taskIDRe   = regexp.MustCompile(`^task-(.+)\.md$`)
-	waveNumRe  = regexp.MustCompile(`wave-(\d+)`)
-	runDirReFC = regexp.MustCompile(`^run-\d+$`)
-)
-
-// extractTaskID extracts a task ID from a filename like "task-3.md".
-func extractTaskID(filename string) string {
-	m := taskIDRe.FindStringSubmatch(filename)
-	if len(m) == 2 {
-		return m[1]
-	}
-	return ""
-}
-
-// extractWaveNum extracts a wave number from a path containing "wave-NN".
-func extractWaveNum(path string) int {
-	m := waveNumRe.FindStringSubmatch(path)
-	if len(m) == 2 {
-		var n int
-		fmt.Sscanf(m[1], "%d", &n)
-		return n
-	}
-	return 0
-}
-
-// inferCommandFromPath guesses the command name from the run directory path.
-func inferCommandFromPath(path string) string {
-	parts := strings.Split(filepath.ToSlash(path), "/")
-	for i, p := range parts {
-		if p == "checkpoint" {
-			return "checkpoint"
-		}
-		if p == "execution" && i+1 < len(parts) && parts[i+1] == "waves" {
-			return "exec"
-		}
-		if p == "_comms" && i+1 < len(parts) {
-			return parts[i+1]
-		}
-	}
-	// Fallback: infer from phase.
-	for _, p := range parts {
-		switch p {
-		case "discover":
-			return "discover"
-		case "design":
-			return "design-research"
-		case "planning":
-			return "tasks-plan"
-		case "qa":
-			return "qa"
-		case "post-mortem":
-			return "post-mortem"
-		}
-	}
-	return "unknown"
-}
-
 // truncate returns the first n characters of s, appending "..." if truncated.
 func truncate(s string, n int) string {
 	if len(s) <= n {
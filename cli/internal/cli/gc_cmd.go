@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lucas-stellet/oraculo/internal/config"
+	"github.com/lucas-stellet/oraculo/internal/reconcile"
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	"github.com/lucas-stellet/oraculo/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+func newGCCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gc <spec-name>",
+		Short: "Compress or delete harvested run dirs",
+		Long: `Removes run dirs (run-NNN) that are already harvested into spec.db and no
+longer needed on disk. A run is kept when spec.db is missing it or out of
+date, when a _latest.json in an enclosing directory points at it, when it is
+among the newest keep_last runs of its directory, or when it changed within
+min_age_days.
+
+The [retention] section of oraculo.toml sets the defaults; flags override
+them. "compress" replaces the dir with run-NNN.tar.gz, "delete" removes it.
+Run and subagent rows stay in spec.db either way.`,
+		Args: cobra.ExactArgs(1),
+		Run:  runGC,
+	}
+	cmd.Flags().Bool("dry-run", false, "List what would be collected without changing anything")
+	cmd.Flags().String("action", "", "compress or delete (default from [retention].action)")
+	cmd.Flags().Int("keep-last", -1, "Newest runs to keep per directory (default from [retention].keep_last)")
+	cmd.Flags().Int("min-age-days", -1, "Minimum age in days (default from [retention].min_age_days)")
+	cmd.Flags().Bool("raw", false, "Output raw JSON")
+	return cmd
+}
+
+func runGC(cmd *cobra.Command, args []string) {
+	raw, _ := cmd.Flags().GetBool("raw")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	action, _ := cmd.Flags().GetString("action")
+	keepLast, _ := cmd.Flags().GetInt("keep-last")
+	minAgeDays, _ := cmd.Flags().GetInt("min-age-days")
+	cwd := getCwd()
+
+	cfg, err := config.Load(cwd)
+	if err != nil {
+		failText(err.Error(), raw)
+	}
+	switch action {
+	case "":
+		action = cfg.Retention.Action
+	case reconcile.GCCompress, reconcile.GCDelete:
+	default:
+		failText("--action must be compress or delete", raw)
+	}
+	if keepLast < 0 {
+		keepLast = cfg.Retention.KeepLast
+	}
+	if minAgeDays < 0 {
+		minAgeDays = cfg.Retention.MinAgeDays
+	}
+
+	specDir, err := specdir.Resolve(cwd, args[0])
+	if err != nil {
+		failText(err.Error(), raw)
+	}
+	if !specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+		failText("spec.db not found (run 'oraculo db rebuild "+args[0]+"')", raw)
+	}
+	s, err := store.Open(specDir)
+	if err != nil {
+		failText(err.Error(), raw)
+	}
+	defer s.Close()
+
+	report := reconcile.GC(s, specDir, reconcile.GCOptions{
+		Action:   action,
+		KeepLast: keepLast,
+		MinAge:   time.Duration(minAgeDays) * 24 * time.Hour,
+		DryRun:   dryRun,
+	})
+
+	if !dryRun && len(report.Collected) > 0 {
+		var paths []string
+		for _, c := range report.Collected {
+			paths = append(paths, c.Path)
+		}
+		_ = s.RecordEvent(store.Event{
+			Command: "gc",
+			Args:    map[string]any{"action": report.Action, "keep_last": keepLast, "min_age_days": minAgeDays},
+			After:   map[string]any{"collected": paths, "freed_bytes": report.FreedBytes},
+		})
+	}
+
+	if raw {
+		result := map[string]any{
+			"ok":          len(report.Errors) == 0,
+			"spec":        report.Spec,
+			"action":      report.Action,
+			"dry_run":     report.DryRun,
+			"collected":   report.Collected,
+			"kept":        report.Kept,
+			"freed_bytes": report.FreedBytes,
+		}
+		if len(report.Errors) > 0 {
+			result["errors"] = report.Errors
+		}
+		tools.Output(result, "", false)
+	} else {
+		printGCReport(report)
+	}
+	if len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+func printGCReport(r reconcile.GCReport) {
+	verb := map[string]string{reconcile.GCCompress: "compressed", reconcile.GCDelete: "deleted"}[r.Action]
+	if r.DryRun {
+		verb = "would " + r.Action
+	}
+	for _, c := range r.Collected {
+		line := fmt.Sprintf("  %-14s  %s  (%s)", verb, c.Path, formatBytes(c.Bytes))
+		if c.Archive != "" && !r.DryRun {
+			line += " -> " + filepath.Base(c.Archive)
+		}
+		fmt.Println(line)
+	}
+	for _, k := range r.Kept {
+		fmt.Printf("  %-14s  %s  (%s)\n", "kept", k.Path, k.Reason)
+	}
+	for _, e := range r.Errors {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", e)
+	}
+	summary := fmt.Sprintf("gc %s: %d collected, %d kept, %s freed", r.Spec, len(r.Collected), len(r.Kept), formatBytes(r.FreedBytes))
+	if r.DryRun {
+		summary += " (dry run)"
+	}
+	fmt.Println(summary)
+}
+
+// formatBytes renders a byte count with a binary unit.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/lucas-stellet/oraculo/internal/reconcile"
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	"github.com/lucas-stellet/oraculo/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+func newIndexCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index [spec-name]",
+		Short: "Update the global search index from spec documents",
+		Long: `Index requirements, design, tasks, phase reports and implementation logs
+into the global search index (.spec-workflow/.oraculo-index.db).
+
+Indexing is incremental: documents are keyed by path and content hash, so
+unchanged files are skipped and documents whose file was removed are deleted.
+Pass a spec name or --all to index every spec.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			all, _ := cmd.Flags().GetBool("all")
+			cwd := getCwd()
+
+			if all == (len(args) == 1) {
+				tools.Fail("pass either a spec name or --all", raw)
+			}
+
+			ix, err := store.OpenIndex(cwd)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			defer ix.Close()
+
+			var reports []reconcile.IndexReport
+			if all {
+				reports, err = reconcile.IndexWorkspace(ix, cwd)
+				if err != nil {
+					tools.Fail(err.Error(), raw)
+				}
+			} else {
+				specDir, err := specdir.Resolve(cwd, args[0])
+				if err != nil {
+					tools.Fail(err.Error(), raw)
+				}
+				reports = append(reports, reconcile.IndexSpec(ix, specDir))
+			}
+
+			var indexed, unchanged, deleted, errCount int
+			for _, r := range reports {
+				indexed += r.Indexed
+				unchanged += r.Unchanged
+				deleted += r.Deleted
+				errCount += len(r.Errors)
+			}
+			result := map[string]any{
+				"ok":        errCount == 0,
+				"specs":     reports,
+				"indexed":   indexed,
+				"unchanged": unchanged,
+				"deleted":   deleted,
+			}
+			rawValue := fmt.Sprintf("%d indexed, %d unchanged, %d deleted", indexed, unchanged, deleted)
+			tools.Output(result, rawValue, raw)
+		},
+	}
+	cmd.Flags().Bool("all", false, "Index every spec in the workspace")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
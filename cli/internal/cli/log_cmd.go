@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	"github.com/lucas-stellet/oraculo/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+func newLogCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "log <spec-name>",
+		Short: "Show the event timeline for a spec",
+		Long: `Show the append-only event journal recorded in spec.db by dispatch-init,
+task-mark, wave-update, audit-iteration advance, finalizar and tasks mark.
+
+--since and --until accept RFC3339 timestamps, dates (2006-01-02) or a
+duration back from now (e.g. 36h).`,
+		Args: cobra.ExactArgs(1),
+		Run:  runLog,
+	}
+
+	cmd.Flags().Int("wave", 0, "Only events for this wave")
+	cmd.Flags().String("task", "", "Only events for this task ID")
+	cmd.Flags().String("command", "", "Only events from this command (e.g. task-mark)")
+	cmd.Flags().String("since", "", "Only events at or after this time")
+	cmd.Flags().String("until", "", "Only events at or before this time")
+	cmd.Flags().Int("limit", 0, "Show only the most recent N events")
+	cmd.Flags().Bool("raw", false, "Output raw JSON")
+
+	return cmd
+}
+
+func runLog(cmd *cobra.Command, args []string) {
+	raw, _ := cmd.Flags().GetBool("raw")
+	cwd := getCwd()
+	specName := args[0]
+
+	specDir, err := specdir.Resolve(cwd, specName)
+	if err != nil {
+		failText(err.Error(), raw)
+	}
+
+	var filter store.EventFilter
+	if cmd.Flags().Changed("wave") {
+		w, _ := cmd.Flags().GetInt("wave")
+		filter.Wave = &w
+	}
+	filter.TaskID, _ = cmd.Flags().GetString("task")
+	filter.Command, _ = cmd.Flags().GetString("command")
+	filter.Limit, _ = cmd.Flags().GetInt("limit")
+
+	now := time.Now()
+	since, _ := cmd.Flags().GetString("since")
+	if filter.Since, err = parseLogTime(since, now, false); err != nil {
+		failText(fmt.Sprintf("--since: %s", err), raw)
+	}
+	until, _ := cmd.Flags().GetString("until")
+	if filter.Until, err = parseLogTime(until, now, true); err != nil {
+		failText(fmt.Sprintf("--until: %s", err), raw)
+	}
+
+	var events []store.Event
+	if specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+		s, err := store.Open(specDir)
+		if err != nil {
+			failText(err.Error(), raw)
+		}
+		defer s.Close()
+		events, err = s.ListEvents(filter)
+		if err != nil {
+			failText(err.Error(), raw)
+		}
+	}
+
+	if raw {
+		if events == nil {
+			events = []store.Event{}
+		}
+		result := map[string]any{
+			"ok":     true,
+			"spec":   specName,
+			"count":  len(events),
+			"events": events,
+		}
+		tools.Output(result, "", false)
+		return
+	}
+
+	if len(events) == 0 {
+		fmt.Printf("No events recorded for %q\n", specName)
+		return
+	}
+	for _, e := range events {
+		fmt.Println(formatEventLine(e))
+	}
+}
+
+// formatEventLine renders one event as a single timeline line.
+func formatEventLine(e store.Event) string {
+	parts := []string{e.Timestamp}
+
+	who := e.Actor
+	if who == "" {
+		who = "-"
+	}
+	if e.SessionID != "" {
+		who += "@" + shortID(e.SessionID)
+	}
+	parts = append(parts, who, e.Command)
+
+	var scope []string
+	if e.Wave != nil {
+		scope = append(scope, fmt.Sprintf("wave=%d", *e.Wave))
+	}
+	if e.TaskID != "" {
+		scope = append(scope, "task="+e.TaskID)
+	}
+	if len(scope) > 0 {
+		parts = append(parts, strings.Join(scope, " "))
+	}
+
+	if change := describeChange(e.Before, e.After); change != "" {
+		parts = append(parts, change)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// describeChange summarizes before/after state. Keys present in both with
+// different values are shown as "key: old → new"; otherwise the after state
+// is shown as compact JSON.
+func describeChange(before, after any) string {
+	b, bok := before.(map[string]any)
+	a, aok := after.(map[string]any)
+	if bok && aok {
+		var diffs []string
+		for _, k := range sortedMapKeys(a) {
+			if old, ok := b[k]; ok && fmt.Sprint(old) != fmt.Sprint(a[k]) {
+				diffs = append(diffs, fmt.Sprintf("%s: %v → %v", k, old, a[k]))
+			}
+		}
+		if len(diffs) > 0 {
+			return strings.Join(diffs, ", ")
+		}
+	}
+	if after == nil {
+		return ""
+	}
+	data, err := json.Marshal(after)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// parseLogTime converts a --since/--until value to an RFC3339 UTC timestamp.
+// A bare date as an upper bound covers the whole day.
+func parseLogTime(value string, now time.Time, endOfDay bool) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		if endOfDay {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d).UTC().Format(time.RFC3339), nil
+	}
+	return "", fmt.Errorf("cannot parse %q as RFC3339, date or duration", value)
+}
+
+func sortedMapKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -35,6 +35,12 @@ func NewRootCmd(version, commit, date string) *cobra.Command {
 	cmd.AddCommand(newSummaryCmd())
 	cmd.AddCommand(newSearchCmd())
 	cmd.AddCommand(newFinalizarCmd())
+	cmd.AddCommand(newDBCmd())
+	cmd.AddCommand(newLogCmd())
+	cmd.AddCommand(newIndexCmd())
+	cmd.AddCommand(newGCCmd())
+	cmd.AddCommand(newStatsCmd())
+	cmd.AddCommand(newTraceCmd())
 
 	return cmd
 }
@@ -3,6 +3,8 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/lucas-stellet/oraculo/internal/store"
 	"github.com/lucas-stellet/oraculo/internal/tools"
@@ -13,14 +15,30 @@ func newSearchCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "search <query>",
 		Short: "Full-text search across indexed specs",
-		Long: `Search across all finalized spec documents using FTS5 full-text search.
-Requires specs to have been indexed via "oraculo finalizar".`,
+		Long: `Search across indexed spec documents using FTS5 full-text search.
+Specs are indexed by "oraculo index" and by "oraculo finalizar".
+
+Query syntax:
+  "exact phrase"     match words next to each other
+  auth*              prefix match
+  a AND b, a OR b    boolean operators (upper case), with ( ) grouping
+  a NOT b            documents matching a but not b
+Any other punctuation is matched literally.
+
+--since and --until filter on the time a document was last indexed and accept
+RFC3339 timestamps, dates (2006-01-02) or a duration back from now (e.g. 72h).`,
 		Args: cobra.ExactArgs(1),
 		Run:  runSearch,
 	}
 
 	cmd.Flags().String("spec", "", "Filter results to a specific spec")
+	cmd.Flags().String("doc-type", "", "Filter by document type (requirements, design, tasks, report, impl_log, completion)")
+	cmd.Flags().String("phase", "", "Filter by phase (e.g. design, execution, qa)")
+	cmd.Flags().String("stage", "", "Filter by the spec's current stage")
+	cmd.Flags().String("since", "", "Only documents indexed at or after this time")
+	cmd.Flags().String("until", "", "Only documents indexed at or before this time")
 	cmd.Flags().Int("limit", 5, "Maximum number of results")
+	cmd.Flags().Int("offset", 0, "Skip this many results (for paging)")
 	cmd.Flags().Bool("raw", false, "Output raw JSON")
 
 	return cmd
@@ -28,8 +46,31 @@ Requires specs to have been indexed via "oraculo finalizar".`,
 
 func runSearch(cmd *cobra.Command, args []string) {
 	raw, _ := cmd.Flags().GetBool("raw")
-	specFilter, _ := cmd.Flags().GetString("spec")
+
+	var filter store.SearchFilter
+	filter.Spec, _ = cmd.Flags().GetString("spec")
+	filter.DocType, _ = cmd.Flags().GetString("doc-type")
+	filter.Phase, _ = cmd.Flags().GetString("phase")
+	filter.Stage, _ = cmd.Flags().GetString("stage")
 	limit, _ := cmd.Flags().GetInt("limit")
+	if limit <= 0 {
+		limit = 5
+	}
+	offset, _ := cmd.Flags().GetInt("offset")
+	if offset < 0 {
+		failText("--offset must not be negative", raw)
+	}
+
+	var err error
+	now := time.Now()
+	since, _ := cmd.Flags().GetString("since")
+	if filter.Since, err = parseLogTime(since, now, false); err != nil {
+		failText(fmt.Sprintf("--since: %s", err), raw)
+	}
+	until, _ := cmd.Flags().GetString("until")
+	if filter.Until, err = parseLogTime(until, now, true); err != nil {
+		failText(fmt.Sprintf("--until: %s", err), raw)
+	}
 
 	cwd := getCwd()
 	query := args[0]
@@ -37,47 +78,98 @@ func runSearch(cmd *cobra.Command, args []string) {
 	ix, err := store.OpenIndex(cwd)
 	if err != nil {
 		if raw {
-			tools.Fail("index not found: run 'oraculo finalizar <spec>' to index specs first", raw)
+			tools.Fail("index not found: run 'oraculo index --all' to index specs first", false)
 		}
-		fmt.Fprintln(os.Stderr, "No search index found. Run 'oraculo finalizar <spec>' to index specs first.")
+		fmt.Fprintln(os.Stderr, "No search index found. Run 'oraculo index --all' to index specs first.")
 		os.Exit(1)
 	}
 	defer ix.Close()
 
-	results, err := ix.Search(query, specFilter, limit)
+	// Ask for one extra row to learn whether another page exists.
+	filter.Limit = limit + 1
+	filter.Offset = offset
+	results, err := ix.SearchFiltered(query, filter)
 	if err != nil {
-		tools.Fail(fmt.Sprintf("search failed: %s", err), raw)
+		failText(fmt.Sprintf("search failed: %s", err), raw)
+	}
+	hasMore := len(results) > limit
+	if hasMore {
+		results = results[:limit]
 	}
 
 	if raw {
+		if results == nil {
+			results = []store.SearchResult{}
+		}
+		for i := range results {
+			results[i].TitleHighlight = renderHighlight(results[i].TitleHighlight, "**", "**")
+			results[i].Highlight = renderHighlight(results[i].Highlight, "**", "**")
+		}
 		result := map[string]any{
-			"ok":      true,
-			"query":   query,
-			"count":   len(results),
-			"results": results,
+			"ok":       true,
+			"query":    query,
+			"count":    len(results),
+			"offset":   offset,
+			"has_more": hasMore,
+			"results":  results,
 		}
-		tools.Output(result, "", true)
+		tools.Output(result, "", false)
 		return
 	}
 
 	if len(results) == 0 {
-		fmt.Printf("No results found for %q\n", query)
+		if offset > 0 {
+			fmt.Printf("No more results for %q\n", query)
+		} else {
+			fmt.Printf("No results found for %q\n", query)
+		}
 		return
 	}
 
-	fmt.Printf("Search: %q\nFound %d results:\n\n", query, len(results))
+	hlOpen, hlClose := "**", "**"
+	if colorEnabled() {
+		hlOpen, hlClose = "\x1b[1;33m", "\x1b[0m"
+	}
+
+	fmt.Printf("Search: %q\nShowing results %d-%d:\n\n", query, offset+1, offset+len(results))
 	for i, r := range results {
-		snippet := r.Snippet
-		if len(snippet) > 200 {
-			snippet = snippet[:200] + "..."
+		label := r.Spec
+		if r.Stage != "" {
+			label += " (" + r.Stage + ")"
 		}
-		fmt.Printf("  %d. [%s] %s\n", i+1, r.DocType, r.Spec)
-		if r.Title != "" {
-			fmt.Printf("     %s\n", r.Title)
+		fmt.Printf("  %d. [%s] %s\n", offset+i+1, r.DocType, label)
+		if title := renderHighlight(r.TitleHighlight, hlOpen, hlClose); title != "" {
+			fmt.Printf("     %s\n", title)
 		}
-		if snippet != "" {
-			fmt.Printf("     %s\n", snippet)
+		if r.Path != "" {
+			fmt.Printf("     %s\n", r.Path)
+		}
+		excerpt := renderHighlight(r.Highlight, hlOpen, hlClose)
+		if excerpt == "" {
+			excerpt = r.Snippet
+		}
+		if excerpt != "" {
+			fmt.Printf("     %s\n", excerpt)
 		}
 		fmt.Printf("     Score: %.2f\n\n", r.Rank)
 	}
+	if hasMore {
+		fmt.Printf("More results: --offset %d\n", offset+len(results))
+	}
+}
+
+// renderHighlight replaces the store's highlight markers with open/close and
+// folds the excerpt onto a single line.
+func renderHighlight(s, open, close string) string {
+	s = strings.NewReplacer(store.HighlightOpen, open, store.HighlightClose, close).Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// colorEnabled reports whether stdout is a terminal and NO_COLOR is unset.
+func colorEnabled() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
@@ -1,8 +1,18 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/archive"
 	"github.com/lucas-stellet/oraculo/internal/spec"
 	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	"github.com/lucas-stellet/oraculo/internal/textdiff"
 	"github.com/lucas-stellet/oraculo/internal/tools"
 	"github.com/spf13/cobra"
 )
@@ -11,7 +21,7 @@ func newSpecCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "spec",
 		Short: "Spec lifecycle inspection commands",
-		Long:  "Inspect spec artifacts, lifecycle stage, prerequisites, and approvals.",
+		Long:  "Inspect spec artifacts, lifecycle stage, prerequisites, approvals and artifact history; export and import specs.",
 	}
 
 	cmd.AddCommand(newSpecArtifactsCmd())
@@ -19,6 +29,10 @@ func newSpecCmd() *cobra.Command {
 	cmd.AddCommand(newSpecPrereqsCmd())
 	cmd.AddCommand(newSpecApprovalCmd())
 	cmd.AddCommand(newSpecListCmd())
+	cmd.AddCommand(newSpecExportCmd())
+	cmd.AddCommand(newSpecImportCmd())
+	cmd.AddCommand(newSpecHistoryCmd())
+	cmd.AddCommand(newSpecDiffCmd())
 
 	return cmd
 }
@@ -179,3 +193,302 @@ func newSpecListCmd() *cobra.Command {
 	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
 	return cmd
 }
+
+func newSpecExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export <spec-name>",
+		Short: "Export a spec directory as a portable tar.gz archive",
+		Long: `Pack the spec directory, including spec.db, into a tar.gz archive with a
+manifest of content hashes. spec.db is checkpointed first so the archive does
+not depend on its WAL sidecars.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			cwd := getCwd()
+			specName := args[0]
+
+			specDir, err := specdir.Resolve(cwd, specName)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			out, _ := cmd.Flags().GetString("output")
+			if out == "" {
+				out = specName + ".tar.gz"
+			}
+			if !filepath.IsAbs(out) {
+				out = filepath.Join(cwd, out)
+			}
+			if rel, err := filepath.Rel(specDir, out); err == nil && filepath.IsLocal(rel) {
+				tools.Fail("output file must be outside the spec directory", raw)
+			}
+
+			f, err := os.Create(out)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			m, err := archive.Export(specDir, f)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(out)
+				tools.Fail(err.Error(), raw)
+			}
+
+			var size int64
+			for _, mf := range m.Files {
+				size += mf.Size
+			}
+			result := map[string]any{
+				"ok":             true,
+				"spec":           specName,
+				"output":         out,
+				"files":          len(m.Files),
+				"bytes":          size,
+				"schema_version": m.SchemaVersion,
+			}
+			tools.Output(result, out, raw)
+		},
+	}
+	cmd.Flags().StringP("output", "o", "", "Archive path (default <spec-name>.tar.gz)")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
+func newSpecImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <archive>",
+		Short: "Import a spec archive created by spec export",
+		Long: `Unpack an archive created by "oraculo spec export" into .spec-workflow/specs/.
+Every file is checked against the manifest hashes before the spec is moved
+into place. Importing over an existing spec is refused unless --rename gives
+it a new name. The imported spec is registered in the global search index.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			cwd := getCwd()
+			rename, _ := cmd.Flags().GetString("rename")
+
+			specsRoot := filepath.Join(cwd, ".spec-workflow", "specs")
+			res, err := archive.Import(args[0], specsRoot, archive.ImportOptions{Rename: rename})
+			if err != nil {
+				if errors.Is(err, archive.ErrSpecExists) {
+					err = fmt.Errorf("%w (use --rename to import under another name)", err)
+				}
+				tools.Fail(err.Error(), raw)
+			}
+
+			stage, docsIndexed := registerImportedSpec(cwd, res)
+			result := map[string]any{
+				"ok":           true,
+				"spec":         res.Spec,
+				"from":         res.From,
+				"dir":          specdir.SpecDir(res.Spec),
+				"files":        len(res.Manifest.Files),
+				"stage":        stage,
+				"docs_indexed": docsIndexed,
+			}
+			tools.Output(result, res.Spec, raw)
+		},
+	}
+	cmd.Flags().String("rename", "", "Import the spec under a different name")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
+// registerImportedSpec migrates the imported spec.db, journals the import and
+// registers the spec (and its completion summary, if any) in the global
+// index. Failures are ignored: the files are already in place and the index
+// can be rebuilt. It returns the stage recorded in the index and the number
+// of documents indexed.
+func registerImportedSpec(cwd string, res *archive.ImportResult) (string, int) {
+	stage := spec.ClassifyStage(res.Dir)
+	var cr *store.CompletionRecord
+	if s := store.TryOpen(res.Dir); s != nil {
+		defer s.Close()
+		if v, err := s.GetMeta("stage"); err == nil && v != "" {
+			stage = v
+		}
+		cr, _ = s.GetCompletionSummary()
+		_ = s.RecordEvent(store.Event{
+			Command: "spec import",
+			Args:    map[string]any{"from": res.From, "created_at": res.Manifest.CreatedAt},
+			After:   map[string]any{"spec": res.Spec, "files": len(res.Manifest.Files)},
+		})
+	}
+
+	ix, err := store.OpenIndex(cwd)
+	if err != nil {
+		return stage, 0
+	}
+	defer ix.Close()
+	_ = ix.IndexSpec(res.Spec, stage, filepath.Join(res.Dir, specdir.SpecDB))
+
+	var docsIndexed int
+	if cr != nil {
+		fullContent := cr.Frontmatter + "\n\n" + cr.Body
+		if ix.IndexDocument(res.Spec, "completion", "complete", "Completion Summary: "+res.Spec, truncate(cr.Body, 200), fullContent) == nil {
+			docsIndexed++
+		}
+	}
+	return stage, docsIndexed
+}
+
+func newSpecHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history <spec-name> <rel-path>",
+		Short: "List the recorded versions of an artifact",
+		Long: `List every content spec.db has recorded for an artifact (e.g. design.md or
+execution/CHECKPOINT-REPORT.md), oldest first. A version is recorded each
+time oraculo index, a dispatch handoff or a harvest (finalizar, db rebuild,
+db fsck --repair) sees new content.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			cwd := getCwd()
+			specName := args[0]
+			relPath := cleanRelPath(args[1])
+
+			specDir, err := specdir.Resolve(cwd, specName)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			s := openExistingStore(specDir, raw)
+			defer s.Close()
+
+			versions, err := s.ListArtifactVersions(relPath)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			if len(versions) == 0 {
+				tools.Fail(fmt.Sprintf("no recorded versions of %s", relPath), raw)
+			}
+
+			latest := versions[len(versions)-1]
+			result := map[string]any{
+				"ok":       true,
+				"spec":     specName,
+				"path":     relPath,
+				"versions": versions,
+				"latest":   latest.Version,
+			}
+			// Flag edits on disk that no harvest has recorded yet.
+			if content, err := os.ReadFile(filepath.Join(specDir, filepath.FromSlash(relPath))); err == nil {
+				result["disk_differs"] = store.ContentHash(content) != latest.ContentHash
+			} else {
+				result["on_disk"] = false
+			}
+
+			lines := make([]string, len(versions))
+			for i, v := range versions {
+				lines[i] = fmt.Sprintf("%d\t%s\t%s\t%d", v.Version, shortID(v.ContentHash), v.CreatedAt, v.Size)
+			}
+			tools.Output(result, strings.Join(lines, "\n"), raw)
+		},
+	}
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
+func newSpecDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <spec-name> <rel-path>",
+		Short: "Show a unified diff between two versions of an artifact",
+		Long: `Print a unified diff between two recorded versions of an artifact.
+--to defaults to the latest version and --from to the version before --to;
+for version 1, which has none, the diff is against empty content.
+Version numbers are listed by "oraculo spec history". --raw prints just the
+diff, as the other spec commands print their plain value.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			cwd := getCwd()
+			specName := args[0]
+			relPath := cleanRelPath(args[1])
+
+			specDir, err := specdir.Resolve(cwd, specName)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			s := openExistingStore(specDir, raw)
+			defer s.Close()
+
+			versions, err := s.ListArtifactVersions(relPath)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			if len(versions) == 0 {
+				tools.Fail(fmt.Sprintf("no recorded versions of %s", relPath), raw)
+			}
+
+			to := versions[len(versions)-1].Version
+			if cmd.Flags().Changed("to") {
+				to, _ = cmd.Flags().GetInt("to")
+			}
+			from := to - 1
+			if cmd.Flags().Changed("from") {
+				from, _ = cmd.Flags().GetInt("from")
+			}
+
+			// Version 1 has no earlier version: it is diffed against empty
+			// content unless --from names one.
+			fromLabel, fromContent := "/dev/null", ""
+			if from != 0 || cmd.Flags().Changed("from") {
+				fromV, err := s.GetArtifactVersion(relPath, from)
+				if err == nil && fromV == nil {
+					err = fmt.Errorf("%s has no version %d", relPath, from)
+				}
+				if err != nil {
+					tools.Fail(err.Error(), raw)
+				}
+				fromLabel, fromContent = fmt.Sprintf("%s@%d", relPath, from), fromV.Content
+			}
+			toV, err := s.GetArtifactVersion(relPath, to)
+			if err == nil && toV == nil {
+				err = fmt.Errorf("%s has no version %d", relPath, to)
+			}
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			diff := textdiff.Unified(
+				fromLabel, fmt.Sprintf("%s@%d", relPath, to),
+				fromContent, toV.Content, textdiff.DefaultContext,
+			)
+			result := map[string]any{
+				"ok":        true,
+				"spec":      specName,
+				"path":      relPath,
+				"from":      from,
+				"to":        to,
+				"identical": diff == "",
+				"diff":      diff,
+			}
+			tools.Output(result, diff, raw)
+		},
+	}
+	cmd.Flags().Int("from", 0, "Base version (default: the version before --to)")
+	cmd.Flags().Int("to", 0, "Target version (default: latest)")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
+// cleanRelPath normalizes a user-supplied artifact path to the slash form
+// stored in spec.db.
+func cleanRelPath(p string) string {
+	return path.Clean(filepath.ToSlash(p))
+}
+
+// openExistingStore opens spec.db for reading commands, failing instead of
+// creating an empty database when the spec has none.
+func openExistingStore(specDir string, raw bool) *store.SpecStore {
+	if !specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+		tools.Fail(fmt.Sprintf("no spec.db in %s", filepath.Base(specDir)), raw)
+	}
+	s, err := store.Open(specDir)
+	if err != nil {
+		tools.Fail(err.Error(), raw)
+	}
+	return s
+}
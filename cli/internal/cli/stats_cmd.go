@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/config"
+	"github.com/lucas-stellet/oraculo/internal/spec"
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/stats"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	"github.com/lucas-stellet/oraculo/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+func newStatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats [spec-name]",
+		Short: "Show delivery metrics across specs",
+		Long: `Reports delivery metrics from each spec's spec.db:
+
+  - cycle time per phase (first run created to last handoff)
+  - exec and checkpoint runs per wave
+  - checkpoint pass/block ratio
+  - inline audit retries
+  - tasks per wave against [planning].max_wave_size
+
+Without a spec name every spec with a spec.db is reported, followed by an
+aggregate over all of them. --summary prints only the aggregate.`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  runStats,
+	}
+	cmd.Flags().Bool("summary", false, "Only print the aggregate over all specs")
+	cmd.Flags().Bool("raw", false, "Output raw JSON")
+	return cmd
+}
+
+func runStats(cmd *cobra.Command, args []string) {
+	raw, _ := cmd.Flags().GetBool("raw")
+	summaryOnly, _ := cmd.Flags().GetBool("summary")
+	cwd := getCwd()
+
+	cfg, err := config.Load(cwd)
+	if err != nil {
+		failText(err.Error(), raw)
+	}
+
+	var names []string
+	if len(args) == 1 {
+		specDir, err := specdir.Resolve(cwd, args[0])
+		if err != nil {
+			failText(err.Error(), raw)
+		}
+		if !specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+			failText("spec.db not found (run 'oraculo db rebuild "+args[0]+"')", raw)
+		}
+		names = []string{args[0]}
+	} else if names, err = spec.List(cwd); err != nil {
+		failText(err.Error(), raw)
+	}
+
+	specs := []stats.SpecStats{}
+	var warnings []string
+	for _, name := range names {
+		specDir := specdir.SpecDirAbs(cwd, name)
+		if !specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+			continue
+		}
+		st, err := specStats(specDir, name, cfg.Planning.MaxWaveSize)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", name, err))
+			continue
+		}
+		specs = append(specs, st)
+	}
+	agg := stats.Summarize(specs)
+
+	if raw {
+		result := map[string]any{
+			"ok":        len(warnings) == 0,
+			"aggregate": agg,
+		}
+		if !summaryOnly {
+			result["specs"] = specs
+		}
+		if len(warnings) > 0 {
+			result["errors"] = warnings
+		}
+		tools.Output(result, "", false)
+		return
+	}
+
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	if len(specs) == 0 {
+		fmt.Println("No specs with a spec.db found")
+		return
+	}
+	if !summaryOnly {
+		for i, st := range specs {
+			if i > 0 {
+				fmt.Println()
+			}
+			printSpecStats(st)
+		}
+	}
+	if summaryOnly || len(args) == 0 {
+		if !summaryOnly {
+			fmt.Println()
+		}
+		printAggregateStats(agg, cfg.Planning.MaxWaveSize)
+	}
+}
+
+func specStats(specDir, name string, maxWaveSize int) (stats.SpecStats, error) {
+	s, err := store.Open(specDir)
+	if err != nil {
+		return stats.SpecStats{}, err
+	}
+	defer s.Close()
+	return stats.ForSpec(s, name, maxWaveSize)
+}
+
+func printSpecStats(st stats.SpecStats) {
+	header := st.Spec
+	if st.Stage != "" {
+		header += "  (" + st.Stage + ")"
+	}
+	fmt.Println(header)
+
+	if len(st.Phases) > 0 {
+		fmt.Printf("  %-12s  %5s  %9s\n", "PHASE", "RUNS", "CYCLE")
+		for _, p := range st.Phases {
+			fmt.Printf("  %-12s  %5d  %9s\n", p.Phase, p.Runs, formatHours(p.Hours))
+		}
+	}
+	if len(st.Waves) > 0 {
+		fmt.Printf("  %-4s  %-10s  %7s  %4s  %10s\n", "WAVE", "STATUS", "TASKS", "EXEC", "CHECKPOINT")
+		for _, w := range st.Waves {
+			tasks := fmt.Sprintf("%d", w.Tasks)
+			if st.MaxWaveSize > 0 {
+				tasks = fmt.Sprintf("%d/%d", w.Tasks, st.MaxWaveSize)
+			}
+			if w.OverLimit {
+				tasks += "!"
+			}
+			status := w.Status
+			if status == "" {
+				status = "-"
+			}
+			fmt.Printf("  %-4d  %-10s  %7s  %4d  %10d\n", w.Wave, status, tasks, w.ExecRuns, w.CheckRuns)
+		}
+	}
+	fmt.Printf("  checkpoints: %s\n", formatCheckpoints(st.Checkpoints))
+	fmt.Printf("  audits: %s\n", formatAudits(st.Audits))
+}
+
+func printAggregateStats(agg stats.Aggregate, maxWaveSize int) {
+	fmt.Printf("All specs (%d)\n", agg.Specs)
+	if len(agg.Phases) > 0 {
+		fmt.Printf("  %-12s  %5s  %5s  %9s  %9s\n", "PHASE", "SPECS", "RUNS", "MEAN", "MEDIAN")
+		for _, p := range agg.Phases {
+			fmt.Printf("  %-12s  %5d  %5d  %9s  %9s\n", p.Phase, p.Specs, p.Runs, formatHours(p.MeanHours), formatHours(p.MedianHours))
+		}
+	}
+	waves := []string{
+		fmt.Sprintf("%d", agg.Waves),
+		fmt.Sprintf("%.1f exec runs/wave", agg.ExecRunsPerWave),
+		fmt.Sprintf("%.1f tasks/wave", agg.TasksPerWave),
+	}
+	if maxWaveSize > 0 {
+		waves = append(waves, fmt.Sprintf("%d over max_wave_size %d", agg.WavesOverLimit, maxWaveSize))
+	}
+	fmt.Printf("  waves: %s\n", strings.Join(waves, ", "))
+	fmt.Printf("  checkpoints: %s\n", formatCheckpoints(agg.Checkpoints))
+	fmt.Printf("  audits: %s\n", formatAudits(agg.Audits))
+}
+
+func formatCheckpoints(c stats.CheckpointStats) string {
+	s := fmt.Sprintf("%d pass, %d blocked", c.Pass, c.Blocked)
+	if c.Pending > 0 {
+		s += fmt.Sprintf(", %d pending", c.Pending)
+	}
+	if c.Pass+c.Blocked > 0 {
+		s += fmt.Sprintf(" (%.0f%% pass)", c.PassRatio*100)
+	}
+	return s
+}
+
+func formatAudits(a stats.AuditStats) string {
+	if a.Retries == 0 {
+		return "no retries"
+	}
+	return fmt.Sprintf("%d retries over %d runs, max iteration %d", a.Retries, a.RunsRetried, a.MaxIteration)
+}
+
+// formatHours renders a duration in hours, switching to days past 48h.
+func formatHours(h float64) string {
+	if h >= 48 {
+		return fmt.Sprintf("%.1fd", h/24)
+	}
+	return fmt.Sprintf("%.1fh", h)
+}
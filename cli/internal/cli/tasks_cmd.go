@@ -6,9 +6,13 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/lucas-stellet/oraculo/internal/config"
+	"github.com/lucas-stellet/oraculo/internal/spec"
 	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
 	"github.com/lucas-stellet/oraculo/internal/tasks"
 	"github.com/lucas-stellet/oraculo/internal/tools"
+	"github.com/lucas-stellet/oraculo/internal/wave"
 	"github.com/spf13/cobra"
 )
 
@@ -20,12 +24,22 @@ func newTasksCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(newTasksStateCmd())
+	cmd.AddCommand(newTasksListCmd())
 	cmd.AddCommand(newTasksNextCmd())
 	cmd.AddCommand(newTasksMarkCmd())
+	cmd.AddCommand(newTasksMarkManyCmd())
 	cmd.AddCommand(newTasksCountCmd())
 	cmd.AddCommand(newTasksFilesCmd())
+	cmd.AddCommand(newTasksConflictsCmd())
 	cmd.AddCommand(newTasksValidateCmd())
 	cmd.AddCommand(newTasksComplexityCmd())
+	cmd.AddCommand(newTasksAddCmd())
+	cmd.AddCommand(newTasksMoveCmd())
+	cmd.AddCommand(newTasksSplitCmd())
+	cmd.AddCommand(newTasksRemoveCmd())
+	cmd.AddCommand(newTasksGraphCmd())
+	cmd.AddCommand(newTasksPlanWavesCmd())
+	cmd.AddCommand(newTasksGitCmd())
 
 	return cmd
 }
@@ -45,9 +59,29 @@ func newTasksStateCmd() *cobra.Command {
 				tools.Fail(err.Error(), raw)
 			}
 
-			doc, err := tasks.ParseFile(specdir.TasksPath(specDir))
+			data, err := os.ReadFile(specdir.TasksPath(specDir))
 			if err != nil {
-				tools.Fail(err.Error(), raw)
+				tools.Fail("cannot read tasks.md: "+err.Error(), raw)
+			}
+			doc := tasks.Parse(string(data))
+
+			if field, _ := cmd.Flags().GetString("field"); field != "" {
+				values := []map[string]string{}
+				var lines []string
+				for _, t := range doc.Tasks {
+					if v, ok := t.Field(field); ok {
+						values = append(values, map[string]string{"task_id": t.ID, "value": v})
+						lines = append(lines, t.ID+"\t"+v)
+					}
+				}
+				result := map[string]any{
+					"ok":     true,
+					"spec":   specName,
+					"field":  field,
+					"values": values,
+				}
+				tools.Output(result, strings.Join(lines, "\n"), raw)
+				return
 			}
 
 			result := map[string]any{
@@ -56,10 +90,59 @@ func newTasksStateCmd() *cobra.Command {
 				"tasks":    doc.Tasks,
 				"counts":   doc.Count(),
 				"warnings": doc.Warnings,
+				"hash":     store.ContentHash(data),
 			}
 			tools.Output(result, "", raw)
 		},
 	}
+	cmd.Flags().String("field", "", "Only show this field (built-in or metadata key) for each task that has it")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
+func newTasksListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list <spec-name>",
+		Short: "List tasks matching metadata filters",
+		Long: `Lists the tasks whose fields match every --where key=value filter. Keys are
+built-in fields (id, title, status, wave, depends_on, files, tdd, reason,
+requirements, deferred) or any other "Key: value" metadata line under a task, matched
+case-insensitively. depends_on, requirements and files match when the value is
+one of their entries, so --where depends_on=1 finds every task depending on 1.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			where, _ := cmd.Flags().GetStringArray("where")
+			specName := args[0]
+
+			conds, err := tasks.ParseConditions(where)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			specDir, err := specdir.Resolve(getCwd(), specName)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			doc, err := tasks.ParseFile(specdir.TasksPath(specDir))
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			matched := tasks.Filter(doc, conds)
+			ids := make([]string, 0, len(matched))
+			for _, t := range matched {
+				ids = append(ids, t.ID)
+			}
+			result := map[string]any{
+				"ok":    true,
+				"spec":  specName,
+				"where": conds,
+				"tasks": matched,
+			}
+			tools.Output(result, strings.Join(ids, "\n"), raw)
+		},
+	}
+	cmd.Flags().StringArray("where", nil, "Filter as key=value (repeatable; all must match)")
 	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
 	return cmd
 }
@@ -68,8 +151,11 @@ func newTasksNextCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "next <spec-name>",
 		Short: "Resolve the next executable wave",
-		Long:  "Determines which tasks are executable next, including deferred tasks with resolved dependencies.",
-		Args:  cobra.ExactArgs(1),
+		Long: `Determines which tasks are executable next, including deferred tasks with
+resolved dependencies. Executable tasks whose Files overlap are reported as
+file_conflict warnings. The last completed wave's state is read as
+wave-status reads it; a blocked wave holds the next one.`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			raw, _ := cmd.Flags().GetBool("raw")
 			cwd := getCwd()
@@ -85,7 +171,7 @@ func newTasksNextCmd() *cobra.Command {
 				tools.Fail(err.Error(), raw)
 			}
 
-			next := tasks.ResolveNextWave(doc, specDir)
+			next := wave.NextWave(doc, specDir)
 			result := map[string]any{
 				"ok":             true,
 				"spec":           specName,
@@ -107,18 +193,32 @@ func newTasksMarkCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "mark <spec-name> <task-id> <status>",
 		Short: "Update a task's checkbox status",
-		Long:  "Surgically updates a single task checkbox. Status: done, in_progress, pending.",
-		Args:  cobra.ExactArgs(3),
+		Long: `Surgically updates a single task checkbox. Status: done, in_progress,
+pending, blocked ([!]) or skipped ([~]). blocked and skipped need --reason,
+recorded as a "Reason:" line under the task; other statuses remove it.
+
+The update holds a lock on the spec directory and replaces tasks.md
+atomically. --expect-hash (the "hash" printed by tasks state, or a prefix of
+at least 8 characters) makes the mark fail if tasks.md changed since it was
+read. The new hash is returned.`,
+		Args: cobra.ExactArgs(3),
 		Run: func(cmd *cobra.Command, args []string) {
 			raw, _ := cmd.Flags().GetBool("raw")
 			requireImplLog, _ := cmd.Flags().GetBool("require-impl-log")
+			reason, _ := cmd.Flags().GetString("reason")
+			expectHash, _ := cmd.Flags().GetString("expect-hash")
 			cwd := getCwd()
 			specName := args[0]
 			taskID := args[1]
 			newStatus := args[2]
 
-			if newStatus != "done" && newStatus != "in_progress" && newStatus != "pending" {
-				tools.Fail("status must be one of: done, in_progress, pending", raw)
+			switch newStatus {
+			case "done", "in_progress", "pending", "blocked", "skipped":
+			default:
+				tools.Fail("status must be one of: done, in_progress, pending, blocked, skipped", raw)
+			}
+			if tasks.NeedsReason(newStatus) && strings.TrimSpace(reason) == "" {
+				tools.Fail("--reason is required for status "+newStatus, raw)
 			}
 
 			specDir, err := specdir.Resolve(cwd, specName)
@@ -127,7 +227,14 @@ func newTasksMarkCmd() *cobra.Command {
 			}
 
 			filePath := specdir.TasksPath(specDir)
-			if err := tasks.MarkTaskInFile(filePath, taskID, newStatus, requireImplLog, specDir); err != nil {
+			hash, err := tasks.Mark(filePath, specDir, tasks.MarkRequest{
+				TaskID:         taskID,
+				Status:         newStatus,
+				Reason:         reason,
+				RequireImplLog: requireImplLog,
+				ExpectHash:     expectHash,
+			})
+			if err != nil {
 				tools.Fail(err.Error(), raw)
 			}
 
@@ -136,11 +243,100 @@ func newTasksMarkCmd() *cobra.Command {
 				"spec":    specName,
 				"task_id": taskID,
 				"status":  newStatus,
+				"hash":    hash,
+			}
+			if tasks.NeedsReason(newStatus) {
+				result["reason"] = strings.TrimSpace(reason)
 			}
 			tools.Output(result, "ok", raw)
 		},
 	}
 	cmd.Flags().Bool("require-impl-log", false, "Refuse to mark done unless implementation log exists")
+	cmd.Flags().String("reason", "", "Why the task is blocked or skipped (required for those statuses)")
+	cmd.Flags().String("expect-hash", "", "Fail unless tasks.md still has this content hash")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
+func newTasksMarkManyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mark-many <spec-name> --status <status> (--ids 3,4,5 | --wave N)",
+		Short: "Update the checkbox status of several tasks at once",
+		Long: `Applies one status to every task named by --ids and/or to every task of
+--wave (skipped tasks of the wave are left alone unless named in --ids).
+
+All transitions are validated before anything is written: every task must
+exist, blocked and skipped need --reason, and with --require-impl-log every
+task marked done needs an implementation log. If any check fails, nothing
+changes and every failure is reported. Otherwise tasks.md is rewritten once,
+under the spec directory lock, and spec.db is updated in one transaction.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			idsFlag, _ := cmd.Flags().GetString("ids")
+			wave, _ := cmd.Flags().GetInt("wave")
+			status, _ := cmd.Flags().GetString("status")
+			reason, _ := cmd.Flags().GetString("reason")
+			requireImplLog, _ := cmd.Flags().GetBool("require-impl-log")
+			expectHash, _ := cmd.Flags().GetString("expect-hash")
+			cwd := getCwd()
+			specName := args[0]
+
+			if status == "" {
+				tools.Fail("--status is required", raw)
+			}
+			var ids []string
+			for _, id := range strings.Split(idsFlag, ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					ids = append(ids, id)
+				}
+			}
+			if len(ids) == 0 && wave <= 0 {
+				tools.Fail("--ids or --wave is required", raw)
+			}
+
+			specDir, err := specdir.Resolve(cwd, specName)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			res, err := tasks.MarkMany(specdir.TasksPath(specDir), specDir, tasks.BatchRequest{
+				TaskIDs:        ids,
+				Wave:           wave,
+				Status:         status,
+				Reason:         reason,
+				RequireImplLog: requireImplLog,
+				ExpectHash:     expectHash,
+			})
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			changed := 0
+			for _, tr := range res.Transitions {
+				if tr.Changed {
+					changed++
+				}
+			}
+			result := map[string]any{
+				"ok":          true,
+				"spec":        specName,
+				"status":      status,
+				"changed":     changed,
+				"transitions": res.Transitions,
+				"hash":        res.Hash,
+			}
+			if tasks.NeedsReason(status) {
+				result["reason"] = strings.TrimSpace(reason)
+			}
+			tools.Output(result, "ok", raw)
+		},
+	}
+	cmd.Flags().String("ids", "", "Comma-separated task IDs")
+	cmd.Flags().Int("wave", 0, "Select every task of this wave")
+	cmd.Flags().String("status", "", "New status: done, in_progress, pending, blocked or skipped")
+	cmd.Flags().String("reason", "", "Why the tasks are blocked or skipped (required for those statuses)")
+	cmd.Flags().Bool("require-impl-log", false, "Refuse to mark done unless every task has an implementation log")
+	cmd.Flags().String("expect-hash", "", "Fail unless tasks.md still has this content hash")
 	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
 	return cmd
 }
@@ -234,11 +430,64 @@ func newTasksFilesCmd() *cobra.Command {
 	return cmd
 }
 
+func newTasksConflictsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "conflicts <spec-name>",
+		Short: "Report overlapping files between tasks of a wave",
+		Long: `Reports pairs of tasks in the same wave whose Files overlap: the same path
+(exact) or a directory and a path under it (directory). Such tasks should not
+run as parallel subagents. Deferred tasks are not checked.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			waveNum, _ := cmd.Flags().GetInt("wave")
+			cwd := getCwd()
+			specName := args[0]
+
+			specDir, err := specdir.Resolve(cwd, specName)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			doc, err := tasks.ParseFile(specdir.TasksPath(specDir))
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			conflicts := tasks.FindConflicts(doc, waveNum)
+			result := map[string]any{
+				"ok":        true,
+				"spec":      specName,
+				"conflicts": conflicts,
+			}
+			if waveNum > 0 {
+				result["wave"] = waveNum
+			}
+			lines := make([]string, 0, len(conflicts))
+			for _, c := range conflicts {
+				lines = append(lines, fmt.Sprintf("wave %d: %s", c.Wave, c))
+			}
+			rawVal := "none"
+			if len(lines) > 0 {
+				rawVal = strings.Join(lines, "\n")
+			}
+			tools.Output(result, rawVal, raw)
+		},
+	}
+	cmd.Flags().Int("wave", 0, "Only check this wave")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
 func newTasksValidateCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "validate <spec-name>",
 		Short: "Validate tasks.md against dashboard rules",
-		Args:  cobra.ExactArgs(1),
+		Long: `Checks tasks.md line syntax against the dashboard rules, then the task graph:
+dependency cycles, Depends On references to missing tasks, dependencies on a
+later wave, frontmatter task_ids vs. body tasks, and Wave Plan entries vs.
+per-task Wave metadata. Every error names its line.`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			raw, _ := cmd.Flags().GetBool("raw")
 			cwd := getCwd()
@@ -276,7 +525,17 @@ func newTasksComplexityCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "complexity <spec-name> [task-id]",
 		Short: "Score task complexity for model routing",
-		Args:  cobra.RangeArgs(1, 2),
+		Long: `Scores tasks by the [complexity] weights in oraculo.toml (files, dependencies,
+TDD, deferred status, title keywords and file types) and maps the score to a
+model hint through sonnet_min and opus_min.
+
+With calibration (calibrate = true or --calibrate), every spec.db in the
+workspace is read: factors whose tasks landed in waves with a blocked
+checkpoint or inline audit retries markedly more (or less) often than average
+weigh one point more (or less). Databases are read as they are, never
+migrated; a spec.db at an older schema is skipped with a warning. Factors
+weighted 0 stay disabled.`,
+		Args: cobra.RangeArgs(1, 2),
 		Run: func(cmd *cobra.Command, args []string) {
 			raw, _ := cmd.Flags().GetBool("raw")
 			cwd := getCwd()
@@ -292,13 +551,24 @@ func newTasksComplexityCmd() *cobra.Command {
 				tools.Fail(err.Error(), raw)
 			}
 
+			cfg, err := config.Load(cwd)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			ccfg := cfg.Complexity
+			var cal *tasks.Calibration
+			var warnings []string
+			if calibrate, _ := cmd.Flags().GetBool("calibrate"); calibrate || ccfg.Calibrate {
+				cal, warnings = calibrateComplexity(cwd, ccfg)
+			}
+
 			if len(args) == 2 {
 				taskID := args[1]
 				task := doc.TaskByID(taskID)
 				if task == nil {
 					tools.Fail(fmt.Sprintf("task %s not found", taskID), raw)
 				}
-				cr := tasks.ScoreComplexity(*task)
+				cr := tasks.ScoreComplexityWith(*task, ccfg, cal)
 				result := map[string]any{
 					"ok":         true,
 					"spec":       specName,
@@ -307,25 +577,63 @@ func newTasksComplexityCmd() *cobra.Command {
 					"model_hint": cr.ModelHint,
 					"factors":    cr.Factors,
 				}
+				addCalibration(result, cal, warnings)
 				tools.Output(result, cr.ModelHint, raw)
 			} else {
 				var scores []tasks.ComplexityResult
 				for _, t := range doc.Tasks {
-					scores = append(scores, tasks.ScoreComplexity(t))
+					scores = append(scores, tasks.ScoreComplexityWith(t, ccfg, cal))
 				}
 				result := map[string]any{
 					"ok":     true,
 					"spec":   specName,
 					"scores": scores,
 				}
+				addCalibration(result, cal, warnings)
 				tools.Output(result, "", raw)
 			}
 		},
 	}
+	cmd.Flags().Bool("calibrate", false, "Calibrate weights from past checkpoint failures and audit retries")
 	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
 	return cmd
 }
 
+// calibrateComplexity gathers task history from every spec.db in the
+// workspace without migrating any of them. Specs whose history cannot be
+// read, or whose spec.db predates the current schema, are reported and
+// skipped.
+func calibrateComplexity(cwd string, cfg config.ComplexityConfig) (*tasks.Calibration, []string) {
+	names, err := spec.List(cwd)
+	if err != nil {
+		return tasks.Calibrate(nil, cfg), []string{err.Error()}
+	}
+	var history []tasks.HistoryTask
+	var warnings []string
+	for _, name := range names {
+		specDir := specdir.SpecDirAbs(cwd, name)
+		if !specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+			continue
+		}
+		h, err := tasks.ReadHistory(specDir)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("calibration: %s: %s", name, err))
+			continue
+		}
+		history = append(history, h...)
+	}
+	return tasks.Calibrate(history, cfg), warnings
+}
+
+func addCalibration(result map[string]any, cal *tasks.Calibration, warnings []string) {
+	if cal != nil {
+		result["calibration"] = cal
+	}
+	if len(warnings) > 0 {
+		result["warnings"] = warnings
+	}
+}
+
 func readFileContent(path string) (string, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
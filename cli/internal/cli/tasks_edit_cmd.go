@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	"github.com/lucas-stellet/oraculo/internal/tasks"
+	"github.com/lucas-stellet/oraculo/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+// editTasks loads tasks.md of a spec, applies edit and saves the result
+// under the tasks.md lock, syncing spec.db and recording ev, which edit may
+// fill in. It exits on any error.
+func editTasks(specName string, raw bool, edit func(*tasks.Editor) error, ev *store.Event) {
+	specDir, err := specdir.Resolve(getCwd(), specName)
+	if err != nil {
+		tools.Fail(err.Error(), raw)
+	}
+	if err := tasks.EditFile(specdir.TasksPath(specDir), specDir, edit, ev); err != nil {
+		tools.Fail(err.Error(), raw)
+	}
+}
+
+// loadTasksEditor loads tasks.md of a spec and applies edit without saving.
+// It exits on any error.
+func loadTasksEditor(specName string, raw bool, edit func(*tasks.Editor) error) (specDir, filePath string, ed *tasks.Editor) {
+	specDir, err := specdir.Resolve(getCwd(), specName)
+	if err != nil {
+		tools.Fail(err.Error(), raw)
+	}
+	filePath = specdir.TasksPath(specDir)
+	if ed, err = tasks.LoadEditor(filePath); err != nil {
+		tools.Fail(err.Error(), raw)
+	}
+	if err := edit(ed); err != nil {
+		tools.Fail(err.Error(), raw)
+	}
+	return specDir, filePath, ed
+}
+
+func newTasksAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add <spec-name> <title...>",
+		Short: "Add a pending task to tasks.md",
+		Long: `Adds a pending task after the last task of its wave. Without --id the task
+takes the next integer ID. Metadata lines follow the style of the existing
+tasks; the frontmatter task_ids and the Wave Plan are updated to match.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			wave, _ := cmd.Flags().GetInt("wave")
+			id, _ := cmd.Flags().GetString("id")
+			deps, _ := cmd.Flags().GetStringSlice("depends-on")
+			files, _ := cmd.Flags().GetStringSlice("files")
+			tdd, _ := cmd.Flags().GetString("tdd")
+			specName := args[0]
+
+			nt := tasks.NewTask{
+				ID:        id,
+				Title:     strings.Join(args[1:], " "),
+				Wave:      wave,
+				DependsOn: deps,
+				Files:     files,
+				TDD:       tdd,
+			}
+			ev := store.Event{Command: "tasks add", Wave: &wave, Args: nt}
+			editTasks(specName, raw, func(ed *tasks.Editor) error {
+				var err error
+				id, err = ed.AddTask(nt)
+				ev.TaskID = id
+				return err
+			}, &ev)
+
+			result := map[string]any{
+				"ok":      true,
+				"spec":    specName,
+				"task_id": id,
+				"wave":    wave,
+			}
+			tools.Output(result, id, raw)
+		},
+	}
+	cmd.Flags().Int("wave", 0, "Wave to add the task to (required)")
+	cmd.Flags().String("id", "", "Task ID (default: next integer ID)")
+	cmd.Flags().StringSlice("depends-on", nil, "Comma-separated IDs of tasks this task depends on")
+	cmd.Flags().StringSlice("files", nil, "Comma-separated files the task touches")
+	cmd.Flags().String("tdd", "", "TDD value for the task (e.g. yes, no)")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	_ = cmd.MarkFlagRequired("wave")
+	return cmd
+}
+
+func newTasksMoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "move <spec-name> <task-id>",
+		Short: "Move a task to another wave",
+		Long: `Sets the task's Wave and moves its block after the last task of the target
+wave. The Wave Plan is updated to match.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			wave, _ := cmd.Flags().GetInt("wave")
+			specName, taskID := args[0], args[1]
+
+			ev := store.Event{Command: "tasks move", Wave: &wave, TaskID: taskID}
+			editTasks(specName, raw, func(ed *tasks.Editor) error {
+				doc := ed.Document()
+				if t := doc.TaskByID(taskID); t != nil {
+					ev.Before = map[string]any{"wave": t.Wave}
+				}
+				ev.After = map[string]any{"wave": wave}
+				return ed.MoveTask(taskID, wave)
+			}, &ev)
+
+			result := map[string]any{
+				"ok":      true,
+				"spec":    specName,
+				"task_id": taskID,
+				"wave":    wave,
+			}
+			tools.Output(result, "ok", raw)
+		},
+	}
+	cmd.Flags().Int("wave", 0, "Target wave (required)")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	_ = cmd.MarkFlagRequired("wave")
+	return cmd
+}
+
+func newTasksSplitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "split <spec-name> <task-id>",
+		Short: "Split a task into subtasks",
+		Long: `Replaces a task with subtasks <id>.1 .. <id>.N that keep its checkbox and
+metadata. Give one --title per subtask, or --into N to number the original
+title. Tasks that depended on the original now depend on every subtask.
+IDs nest up to three levels (1, 1.1, 1.1.1), so an N.M.K task cannot be split.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			into, _ := cmd.Flags().GetInt("into")
+			titles, _ := cmd.Flags().GetStringArray("title")
+			specName, taskID := args[0], args[1]
+
+			if len(titles) > 0 && into > 0 && into != len(titles) {
+				tools.Fail("--into does not match the number of --title flags", raw)
+			}
+			if len(titles) == 0 && into < 2 {
+				tools.Fail("give --into N (N >= 2) or one --title per subtask", raw)
+			}
+
+			var ids []string
+			ev := store.Event{Command: "tasks split", TaskID: taskID}
+			editTasks(specName, raw, func(ed *tasks.Editor) error {
+				if len(titles) == 0 {
+					doc := ed.Document()
+					t := doc.TaskByID(taskID)
+					if t == nil {
+						return fmt.Errorf("task %s not found", taskID)
+					}
+					for i := 1; i <= into; i++ {
+						titles = append(titles, fmt.Sprintf("%s (%d/%d)", t.Title, i, into))
+					}
+				}
+				var err error
+				ids, err = ed.SplitTask(taskID, titles)
+				ev.Args = map[string]any{"titles": titles}
+				ev.After = map[string]any{"task_ids": ids}
+				return err
+			}, &ev)
+
+			result := map[string]any{
+				"ok":       true,
+				"spec":     specName,
+				"task_id":  taskID,
+				"task_ids": ids,
+			}
+			tools.Output(result, strings.Join(ids, ","), raw)
+		},
+	}
+	cmd.Flags().Int("into", 0, "Number of subtasks, titled after the original")
+	cmd.Flags().StringArray("title", nil, "Subtask title (repeat once per subtask)")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
+func newTasksRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove <spec-name> <task-id>",
+		Short: "Remove a task from tasks.md",
+		Long: `Removes a task block, its frontmatter task_ids entry and its Wave Plan
+entry. Refuses when other tasks depend on it unless --force is given, in which
+case those references are dropped.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			force, _ := cmd.Flags().GetBool("force")
+			specName, taskID := args[0], args[1]
+
+			ev := store.Event{Command: "tasks remove", TaskID: taskID, Args: map[string]any{"force": force}}
+			editTasks(specName, raw, func(ed *tasks.Editor) error {
+				doc := ed.Document()
+				if t := doc.TaskByID(taskID); t != nil {
+					ev.Before = t.Record()
+				}
+				return ed.RemoveTask(taskID, force)
+			}, &ev)
+
+			result := map[string]any{
+				"ok":      true,
+				"spec":    specName,
+				"task_id": taskID,
+			}
+			tools.Output(result, "ok", raw)
+		},
+	}
+	cmd.Flags().Bool("force", false, "Remove even if other tasks depend on it")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
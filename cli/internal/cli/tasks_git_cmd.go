@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/git"
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/tasks"
+	"github.com/lucas-stellet/oraculo/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+func newTasksGitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "git <spec-name> <task-id>",
+		Short: "Show a task's commits and check them against its Files",
+		Long: `Lists every commit whose message names the task ("task <id>", as in the
+"<type>(<spec>): task <id> - <title>" convention), newest first, with diff
+stats. Commits scoped to the spec are preferred; when there are none, any
+commit naming the task is used and scope is "any".
+
+The files the commits changed are compared with the task's Files: entries
+(paths, directories or glob patterns). Changed files no entry covers are
+listed as undeclared, entries no commit touched as untouched. Files under
+.spec-workflow/ are ignored.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			cwd := getCwd()
+			specName, taskID := args[0], args[1]
+
+			specDir, err := specdir.Resolve(cwd, specName)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			doc, err := tasks.ParseFile(specdir.TasksPath(specDir))
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			task := doc.TaskByID(taskID)
+			if task == nil {
+				tools.Fail(fmt.Sprintf("task %s not found", taskID), raw)
+			}
+			repoRoot := git.RepoRoot(cwd)
+			if repoRoot == "" {
+				tools.Fail("not a git repository", raw)
+			}
+
+			scope, commits := git.TaskCommits(repoRoot, specName, []string{taskID})
+			if commits == nil {
+				commits = []git.Commit{}
+			}
+
+			var changed []string
+			seen := make(map[string]bool)
+			hashes := make([]string, 0, len(commits))
+			for _, c := range commits {
+				hashes = append(hashes, c.Short)
+				for _, f := range c.Files {
+					p := projectPath(cwd, repoRoot, f.Path)
+					if !seen[p] {
+						seen[p] = true
+						changed = append(changed, p)
+					}
+				}
+			}
+			report := tasks.CompareScope(task.FileList(), changed)
+
+			result := map[string]any{
+				"ok":         true,
+				"spec":       specName,
+				"task_id":    taskID,
+				"scope":      scope,
+				"commits":    commits,
+				"declared":   report.Declared,
+				"changed":    report.Changed,
+				"undeclared": report.Undeclared,
+				"untouched":  report.Untouched,
+				"in_scope":   len(commits) > 0 && report.InScope(),
+			}
+			if len(report.Ignored) > 0 {
+				result["ignored"] = report.Ignored
+			}
+			tools.Output(result, strings.Join(hashes, "\n"), raw)
+		},
+	}
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
+// projectPath turns a path relative to the repo root into one relative to
+// the project root cwd, the base Files: entries are written against.
+func projectPath(cwd, repoRoot, p string) string {
+	rel, err := filepath.Rel(cwd, filepath.Join(repoRoot, p))
+	if err != nil {
+		return p
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/tasks"
+	"github.com/lucas-stellet/oraculo/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+func newTasksGraphCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph <spec-name>",
+		Short: "Render the task plan as a graph",
+		Long: `Renders tasks grouped by wave, with an edge from each dependency to the task
+that depends on it. Tasks are colored by status; deferred tasks are grouped
+separately and drawn dashed.
+
+Formats: mermaid (default), dot (Graphviz) and json. --fenced wraps Mermaid
+output in a code fence, ready to paste into STATUS-SUMMARY.md.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			format, _ := cmd.Flags().GetString("format")
+			fenced, _ := cmd.Flags().GetBool("fenced")
+
+			specDir, err := specdir.Resolve(getCwd(), args[0])
+			if err != nil {
+				failText(err.Error(), false)
+			}
+			doc, err := tasks.ParseFile(specdir.TasksPath(specDir))
+			if err != nil {
+				failText(err.Error(), false)
+			}
+			view := tasks.BuildView(doc)
+
+			switch format {
+			case "mermaid":
+				out := tasks.RenderMermaid(view)
+				if fenced {
+					out = "```mermaid\n" + out + "```\n"
+				}
+				fmt.Print(out)
+			case "dot":
+				fmt.Print(tasks.RenderDot(view))
+			case "json":
+				tools.Output(map[string]any{
+					"ok":     true,
+					"spec":   args[0],
+					"groups": view.Groups,
+					"nodes":  view.Nodes,
+					"edges":  view.Edges,
+				}, "", false)
+			default:
+				failText("--format must be mermaid, dot or json", false)
+			}
+		},
+	}
+	cmd.Flags().String("format", "mermaid", "Output format: mermaid, dot or json")
+	cmd.Flags().Bool("fenced", false, "Wrap Mermaid output in a ```mermaid code fence")
+	return cmd
+}
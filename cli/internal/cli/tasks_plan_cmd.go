@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/config"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	"github.com/lucas-stellet/oraculo/internal/tasks"
+	"github.com/lucas-stellet/oraculo/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+func newTasksPlanWavesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan-waves <spec-name>",
+		Short: "Compute a wave layout from task dependencies",
+		Long: `Sorts the non-deferred tasks topologically and packs them into waves: a
+task runs after its dependencies, never beside a task whose Files overlap its
+own, and no wave exceeds [planning].max_wave_size. Tasks on the longest
+dependency chain are placed first; that chain is reported as the critical
+path.
+
+Tasks already in progress or done keep their wave. --write rewrites the
+tasks' Wave lines and the Wave Plan section of tasks.md.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			write, _ := cmd.Flags().GetBool("write")
+			maxWaveSize, _ := cmd.Flags().GetInt("max-wave-size")
+			cwd := getCwd()
+			specName := args[0]
+
+			if maxWaveSize < 0 {
+				cfg, err := config.Load(cwd)
+				if err != nil {
+					tools.Fail(err.Error(), raw)
+				}
+				maxWaveSize = cfg.Planning.MaxWaveSize
+			}
+
+			var plan tasks.WavePlanResult
+			edit := func(ed *tasks.Editor) error {
+				var err error
+				if plan, err = tasks.PlanWaves(ed.Document(), maxWaveSize); err != nil {
+					return err
+				}
+				if !write {
+					return nil
+				}
+				return ed.SetWaves(plan.Assignments())
+			}
+			if write {
+				ev := store.Event{Command: "tasks plan-waves", Args: map[string]any{"max_wave_size": maxWaveSize}}
+				editTasks(specName, raw, func(ed *tasks.Editor) error {
+					err := edit(ed)
+					ev.After = plan
+					return err
+				}, &ev)
+			} else {
+				loadTasksEditor(specName, raw, edit)
+			}
+
+			var lines []string
+			for _, w := range plan.Waves {
+				lines = append(lines, fmt.Sprintf("Wave %d: %s", w.Wave, strings.Join(w.TaskIDs, ", ")))
+			}
+			result := map[string]any{
+				"ok":            true,
+				"spec":          specName,
+				"max_wave_size": plan.MaxWaveSize,
+				"waves":         plan.Waves,
+				"changes":       plan.Changes,
+				"critical_path": plan.CriticalPath,
+				"written":       write,
+			}
+			if len(plan.Pinned) > 0 {
+				result["pinned"] = plan.Pinned
+			}
+			tools.Output(result, strings.Join(lines, "\n"), raw)
+		},
+	}
+	cmd.Flags().Int("max-wave-size", -1, "Maximum tasks per wave, 0 for no limit (default from [planning].max_wave_size)")
+	cmd.Flags().Bool("write", false, "Write the layout back into tasks.md")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/tasks"
+	"github.com/lucas-stellet/oraculo/internal/tools"
+	"github.com/lucas-stellet/oraculo/internal/trace"
+	"github.com/spf13/cobra"
+)
+
+func newTraceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trace <spec-name>",
+		Short: "Show requirement coverage by tasks, impl logs and QA",
+		Long: `Prints a coverage matrix linking each requirement and acceptance criterion
+in requirements.md to the tasks that reference it (Requirements: REQ-001),
+their implementation logs and the QA-TEST-PLAN.md scenarios that name it.
+
+Requirements are "### REQ-001 - Title" headings; acceptance criteria are the
+bullets under "Acceptance criteria", numbered REQ-001.1, REQ-001.2, ... unless
+a bullet starts with its own ID. Items no task covers are flagged UNCOVERED,
+and references to IDs requirements.md does not define are listed.
+
+With --strict the command exits 2 when anything is uncovered.`,
+		Args: cobra.ExactArgs(1),
+		Run:  runTrace,
+	}
+	cmd.Flags().Bool("strict", false, "Exit 2 when a requirement or criterion is uncovered")
+	cmd.Flags().Bool("raw", false, "Output raw JSON")
+	return cmd
+}
+
+func runTrace(cmd *cobra.Command, args []string) {
+	raw, _ := cmd.Flags().GetBool("raw")
+	strict, _ := cmd.Flags().GetBool("strict")
+	specName := args[0]
+
+	specDir, err := specdir.Resolve(getCwd(), specName)
+	if err != nil {
+		failText(err.Error(), raw)
+	}
+	reqs, err := trace.ParseRequirementsFile(filepath.Join(specDir, specdir.RequirementsMD))
+	if err != nil {
+		failText(err.Error(), raw)
+	}
+	var doc tasks.Document
+	if path := specdir.TasksPath(specDir); specdir.FileExists(path) {
+		if doc, err = tasks.ParseFile(path); err != nil {
+			failText(err.Error(), raw)
+		}
+	}
+	var scenarios []trace.Scenario
+	if path := filepath.Join(specDir, specdir.QATestPlan); specdir.FileExists(path) {
+		if scenarios, err = trace.ParseScenariosFile(path); err != nil {
+			failText(err.Error(), raw)
+		}
+	}
+
+	m := trace.Build(reqs, doc, scenarios, func(taskID string) bool {
+		return specdir.FileExists(specdir.ImplLogPath(specDir, taskID))
+	})
+
+	if raw {
+		result := map[string]any{
+			"ok":           true,
+			"spec":         specName,
+			"requirements": m.Requirements,
+			"unknown":      m.Unknown,
+			"summary":      m.Summary,
+		}
+		tools.Output(result, "", false)
+	} else {
+		printTrace(specName, m)
+	}
+	if strict && len(m.Summary.Uncovered) > 0 {
+		os.Exit(2)
+	}
+}
+
+func printTrace(specName string, m trace.Matrix) {
+	s := m.Summary
+	fmt.Printf("%s: %d/%d requirements covered by tasks, %d with impl logs, %d with QA scenarios",
+		specName, s.Covered, s.Requirements, s.WithImplLogs, s.WithQA)
+	if s.Criteria > 0 {
+		fmt.Printf("; %d/%d criteria covered", s.CriteriaCovered, s.Criteria)
+	}
+	fmt.Println()
+	if len(m.Requirements) == 0 {
+		fmt.Println("No requirements found in requirements.md")
+	}
+
+	type row struct{ id, tasks, logs, qa, gaps string }
+	rows := []row{{"ID", "TASKS", "IMPL LOGS", "QA", "GAPS"}}
+	add := func(id string, c trace.Coverage) {
+		rows = append(rows, row{id, joinOrDash(c.Tasks), joinOrDash(c.ImplLogs), joinOrDash(c.Scenarios), traceGaps(c)})
+	}
+	for _, r := range m.Requirements {
+		add(r.ID, r.Coverage)
+		for _, c := range r.Criteria {
+			add("  "+c.ID, c.Coverage)
+		}
+	}
+	if len(rows) > 1 {
+		var w [4]int
+		for _, r := range rows {
+			for i, cell := range []string{r.id, r.tasks, r.logs, r.qa} {
+				w[i] = max(w[i], len(cell))
+			}
+		}
+		fmt.Println()
+		for _, r := range rows {
+			line := fmt.Sprintf("%-*s  %-*s  %-*s  %-*s  %s", w[0], r.id, w[1], r.tasks, w[2], r.logs, w[3], r.qa, r.gaps)
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+	}
+
+	if len(m.Unknown) > 0 {
+		fmt.Println()
+		fmt.Println("Unknown references:")
+		for _, u := range m.Unknown {
+			fmt.Printf("  %s %s -> %s\n", u.Source, u.ID, u.Ref)
+		}
+	}
+}
+
+// traceGaps renders the gaps of an item; an item without tasks is simply
+// UNCOVERED.
+func traceGaps(c trace.Coverage) string {
+	if c.Uncovered() {
+		return "UNCOVERED"
+	}
+	var gaps []string
+	for _, g := range c.Gaps {
+		switch g {
+		case trace.GapImplLogs:
+			gaps = append(gaps, "no impl log")
+		case trace.GapQA:
+			gaps = append(gaps, "no QA scenario")
+		}
+	}
+	return strings.Join(gaps, ", ")
+}
+
+func joinOrDash(ids []string) string {
+	if len(ids) == 0 {
+		return "-"
+	}
+	return strings.Join(ids, ", ")
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 
+	"github.com/lucas-stellet/oraculo/internal/config"
 	"github.com/lucas-stellet/oraculo/internal/specdir"
 	"github.com/lucas-stellet/oraculo/internal/tools"
 	"github.com/lucas-stellet/oraculo/internal/wave"
@@ -12,14 +13,16 @@ import (
 func newWaveCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "wave",
-		Short: "Wave state inspection commands",
-		Long:  "Inspect wave execution state, checkpoint status, and summaries.",
+		Short: "Wave state commands",
+		Long:  "Inspect and move wave state, checkpoint status, and summaries.",
 	}
 
 	cmd.AddCommand(newWaveStateCmd())
 	cmd.AddCommand(newWaveSummaryCmd())
 	cmd.AddCommand(newWaveCheckpointCmd())
 	cmd.AddCommand(newWaveResumeCmd())
+	cmd.AddCommand(newWaveTransitionCmd())
+	cmd.AddCommand(newWaveRollbackCmd())
 
 	return cmd
 }
@@ -94,8 +97,14 @@ func newWaveCheckpointCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "checkpoint <spec-name> <wave-num>",
 		Short: "Resolve checkpoint status for a wave",
-		Long:  "Uses _latest.json-first resolution to avoid stale _wave-summary.json bugs.",
-		Args:  cobra.ExactArgs(2),
+		Long: `Uses _latest.json-first resolution to avoid stale _wave-summary.json bugs.
+
+The run's subagent status.json files are judged by the [checkpoint] gate in
+oraculo.toml: the release-gate-decider decides (policy "decider", the
+default), every subagent must pass ("all-pass"), or a quorum must pass
+("quorum"); required_reviewers and max_open_findings apply on top. Each
+rule is reported under rules with whether it passed.`,
+		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
 			raw, _ := cmd.Flags().GetBool("raw")
 			cwd := getCwd()
@@ -107,7 +116,12 @@ func newWaveCheckpointCmd() *cobra.Command {
 				tools.Fail(err.Error(), raw)
 			}
 
-			cp := wave.ResolveCheckpoint(specDir, waveNum)
+			cfg, err := config.Load(cwd)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			cp := wave.ResolveCheckpointGate(specDir, waveNum, cfg.Checkpoint)
 			result := map[string]any{
 				"ok":         true,
 				"spec":       specName,
@@ -117,6 +131,8 @@ func newWaveCheckpointCmd() *cobra.Command {
 				"source":     cp.Source,
 				"stale_flag": cp.StaleFlag,
 				"details":    cp.Details,
+				"policy":     cp.Policy,
+				"rules":      cp.Rules,
 			}
 			tools.Output(result, cp.Status, raw)
 		},
@@ -155,6 +171,52 @@ func newWaveResumeCmd() *cobra.Command {
 	return cmd
 }
 
+func newWaveTransitionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "transition <spec-name> <wave-num> <state>",
+		Short: "Move a wave to a new state",
+		Long: `Moves a wave through its state machine:
+
+  pending → executing → awaiting-checkpoint → passed | blocked
+  passed | blocked → reopened → executing
+
+Any other move is rejected. The state is stored in spec.db and journaled;
+from then on it is what wave state, wave resume and tools wave-status
+report for the wave instead of the state inferred from its run
+directories. Dispatches and tools wave-update advance the state on their
+own; use this command to reopen a wave or to correct it.`,
+		Args: cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			cwd := getCwd()
+			specName := args[0]
+			waveNum := parseWaveNum(args[1], raw)
+			state := args[2]
+
+			specDir, err := specdir.Resolve(cwd, specName)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			from, err := wave.Transition(specDir, waveNum, state, "wave transition")
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+			result := map[string]any{
+				"ok":    true,
+				"spec":  specName,
+				"wave":  waveNum,
+				"from":  from,
+				"state": state,
+				"next":  wave.NextStates(state),
+			}
+			tools.Output(result, state, raw)
+		},
+	}
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
 func parseWaveNum(s string, raw bool) int {
 	var n int
 	_, err := fmt.Sscanf(s, "%d", &n)
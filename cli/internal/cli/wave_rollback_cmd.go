@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/git"
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/tasks"
+	"github.com/lucas-stellet/oraculo/internal/tools"
+	"github.com/lucas-stellet/oraculo/internal/wave"
+	"github.com/spf13/cobra"
+)
+
+func newWaveRollbackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback <spec-name> <wave-num>",
+		Short: "Undo a wave so it can run again",
+		Long: `Rolls back a passed or blocked wave:
+
+  - its tasks, except skipped ones, go back to pending in tasks.md
+  - its execution/ and checkpoint/ run dirs move to _archived/ inside
+    those subdirs, and spec.db records the new paths
+  - _latest.json and _wave-summary.json are removed
+  - the wave is reopened
+
+Archived runs keep their numbers; the next dispatch continues after them.
+
+--commits only lists the wave's task commits ("task <id>" in the
+message, scoped to the spec when any are), newest first, and rolls
+nothing back. --revert reverts them with git revert before the rollback,
+before anything else changes; if a revert fails, the whole sequence is
+aborted and nothing is rolled back. Commits already reverted and revert
+commits are left out. When no commit is scoped to the spec, the commits of
+any spec naming the same task IDs are listed (scope "any"); --revert
+refuses those unless --any-scope is given.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			listCommits, _ := cmd.Flags().GetBool("commits")
+			revert, _ := cmd.Flags().GetBool("revert")
+			anyScope, _ := cmd.Flags().GetBool("any-scope")
+			cwd := getCwd()
+			specName := args[0]
+			waveNum := parseWaveNum(args[1], raw)
+
+			specDir, err := specdir.Resolve(cwd, specName)
+			if err != nil {
+				tools.Fail(err.Error(), raw)
+			}
+
+			result := map[string]any{
+				"ok":   true,
+				"spec": specName,
+				"wave": waveNum,
+			}
+			if listCommits || revert {
+				if _, err := wave.CheckRollback(specDir, waveNum); err != nil {
+					tools.Fail(err.Error(), raw)
+				}
+				ids, err := waveTaskIDs(specDir, waveNum)
+				if err != nil {
+					tools.Fail(err.Error(), raw)
+				}
+				repoRoot := git.RepoRoot(cwd)
+				if repoRoot == "" {
+					tools.Fail("not a git repository", raw)
+				}
+				scope, commits := git.TaskCommits(repoRoot, specName, ids)
+				commits = git.Unreverted(repoRoot, commits)
+				result["scope"] = scope
+				result["commits"] = commits
+				if !revert {
+					shorts := make([]string, len(commits))
+					for i, c := range commits {
+						shorts[i] = c.Short
+					}
+					tools.Output(result, strings.Join(shorts, "\n"), raw)
+					return
+				}
+				if scope != "spec" && len(commits) > 0 && !anyScope {
+					tools.Fail(fmt.Sprintf("no commits are scoped to %s; the %d naming its tasks may belong to another spec (list them with --commits, which changes nothing, then pass --any-scope to revert them)",
+						specName, len(commits)), raw)
+				}
+				hashes := make([]string, 0, len(commits))
+				for _, c := range commits {
+					hashes = append(hashes, c.Hash)
+				}
+				if err := git.Revert(repoRoot, hashes); err != nil {
+					tools.Fail(err.Error(), raw)
+				}
+				result["reverted"] = len(hashes)
+			}
+
+			res, err := wave.Rollback(specDir, waveNum, "wave rollback")
+			if err != nil {
+				msg := err.Error()
+				if revert {
+					msg += " (the wave's commits were already reverted)"
+				}
+				tools.Fail(msg, raw)
+			}
+			result["from"] = res.From
+			result["state"] = wave.StateReopened
+			result["tasks"] = res.Tasks
+			result["archived"] = res.Archived
+			result["cleared"] = res.Cleared
+			tools.Output(result, wave.StateReopened, raw)
+		},
+	}
+	cmd.Flags().Bool("commits", false, "List the wave's task commits without rolling back")
+	cmd.Flags().Bool("revert", false, "Revert the wave's task commits first")
+	cmd.Flags().Bool("any-scope", false, "Let --revert revert commits not scoped to the spec")
+	cmd.Flags().Bool("raw", false, "Output raw value without JSON wrapping")
+	return cmd
+}
+
+// waveTaskIDs returns the IDs of the tasks of a wave a rollback resets,
+// which leaves skipped tasks alone.
+func waveTaskIDs(specDir string, waveNum int) ([]string, error) {
+	doc, err := tasks.ParseFile(specdir.TasksPath(specDir))
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, t := range doc.Tasks {
+		if t.Wave == waveNum && t.Status != "skipped" {
+			ids = append(ids, t.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("wave %d has no tasks", waveNum)
+	}
+	return ids, nil
+}
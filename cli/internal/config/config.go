@@ -26,6 +26,9 @@ type Config struct {
 	Safety           SafetyConfig           `toml:"safety"`
 	Verification     VerificationConfig     `toml:"verification"`
 	Hooks            HooksConfig            `toml:"hooks"`
+	Retention        RetentionConfig        `toml:"retention"`
+	Complexity       ComplexityConfig       `toml:"complexity"`
+	Checkpoint       CheckpointConfig       `toml:"checkpoint"`
 }
 
 type ModelsConfig struct {
@@ -106,6 +109,52 @@ type HooksConfig struct {
 	GuardPaths               bool   `toml:"guard_paths"`
 	GuardWaveLayout          bool   `toml:"guard_wave_layout"`
 	GuardStopHandoff         bool   `toml:"guard_stop_handoff"`
+	IndexOnSessionStart      bool   `toml:"index_on_session_start"`
+}
+
+// RetentionConfig controls which harvested run dirs `oraculo gc` removes.
+type RetentionConfig struct {
+	Action     string `toml:"action"`
+	KeepLast   int    `toml:"keep_last"`
+	MinAgeDays int    `toml:"min_age_days"`
+}
+
+// ComplexityConfig controls `oraculo tasks complexity` scoring and model
+// routing: scores from SonnetMin route to sonnet, from OpusMin to opus.
+type ComplexityConfig struct {
+	SonnetMin             int               `toml:"sonnet_min"`
+	OpusMin               int               `toml:"opus_min"`
+	Keywords              []string          `toml:"keywords"`
+	FileTypes             []string          `toml:"file_types"`
+	Calibrate             bool              `toml:"calibrate"`
+	CalibrationMinSamples int               `toml:"calibration_min_samples"`
+	Weights               ComplexityWeights `toml:"weights"`
+}
+
+// ComplexityWeights are the points each complexity factor adds.
+type ComplexityWeights struct {
+	FilesFew      int `toml:"files_few"`
+	FilesModerate int `toml:"files_moderate"`
+	FilesMany     int `toml:"files_many"`
+	DepsSingle    int `toml:"deps_single"`
+	DepsModerate  int `toml:"deps_moderate"`
+	DepsMany      int `toml:"deps_many"`
+	TDD           int `toml:"tdd"`
+	Deferred      int `toml:"deferred"`
+	Keyword       int `toml:"keyword"`
+	FileType      int `toml:"file_type"`
+}
+
+// CheckpointConfig is the gate checkpoint readers apply to the subagent
+// status.json files of a checkpoint run. Policy is "decider", "all-pass"
+// or "quorum"; required reviewers and open finding limits apply on top of
+// any policy. Load rejects any other policy and a quorum below 1, so a
+// typo never loosens the gate.
+type CheckpointConfig struct {
+	Policy            string         `toml:"policy"`
+	Quorum            int            `toml:"quorum"`
+	RequiredReviewers []string       `toml:"required_reviewers"`
+	MaxOpenFindings   map[string]int `toml:"max_open_findings"`
 }
 
 // Defaults returns a Config populated with all default values.
@@ -180,6 +229,38 @@ func Defaults() Config {
 			GuardPaths:             true,
 			GuardWaveLayout:        true,
 			GuardStopHandoff:       true,
+			IndexOnSessionStart:    false,
+		},
+		Retention: RetentionConfig{
+			Action:     "compress",
+			KeepLast:   1,
+			MinAgeDays: 7,
+		},
+		Complexity: ComplexityConfig{
+			SonnetMin:             4,
+			OpusMin:               7,
+			Keywords:              []string{},
+			FileTypes:             []string{},
+			Calibrate:             false,
+			CalibrationMinSamples: 5,
+			Weights: ComplexityWeights{
+				FilesFew:      1,
+				FilesModerate: 2,
+				FilesMany:     3,
+				DepsSingle:    1,
+				DepsModerate:  2,
+				DepsMany:      3,
+				TDD:           2,
+				Deferred:      1,
+				Keyword:       2,
+				FileType:      1,
+			},
+		},
+		Checkpoint: CheckpointConfig{
+			Policy:            "decider",
+			Quorum:            2,
+			RequiredReviewers: []string{},
+			MaxOpenFindings:   map[string]int{},
 		},
 	}
 }
@@ -218,6 +299,10 @@ func Load(workspaceRoot string) (Config, error) {
 
 	cfg.Hooks.EnforcementMode = normalizeEnforcementMode(cfg.Hooks.EnforcementMode)
 	cfg.Statusline.ShowTokenCost = normalizeShowTokenCost(cfg.Statusline.ShowTokenCost)
+	cfg.Retention.Action = normalizeRetentionAction(cfg.Retention.Action)
+	if err := cfg.Checkpoint.normalize(); err != nil {
+		return cfg, fmt.Errorf("config %s: %w", configPath, err)
+	}
 
 	return cfg, nil
 }
@@ -240,6 +325,10 @@ func LoadFromPath(configPath string) (Config, error) {
 
 	cfg.Hooks.EnforcementMode = normalizeEnforcementMode(cfg.Hooks.EnforcementMode)
 	cfg.Statusline.ShowTokenCost = normalizeShowTokenCost(cfg.Statusline.ShowTokenCost)
+	cfg.Retention.Action = normalizeRetentionAction(cfg.Retention.Action)
+	if err := cfg.Checkpoint.normalize(); err != nil {
+		return cfg, fmt.Errorf("config %s: %w", configPath, err)
+	}
 
 	return cfg, nil
 }
@@ -331,6 +420,31 @@ func normalizeShowTokenCost(mode string) string {
 	}
 }
 
+func normalizeRetentionAction(action string) string {
+	action = strings.ToLower(strings.TrimSpace(action))
+	if action == "delete" {
+		return "delete"
+	}
+	return "compress"
+}
+
+// normalize lower-cases the policy, an empty one meaning "decider", and
+// rejects unknown policies and a quorum below 1.
+func (c *CheckpointConfig) normalize() error {
+	c.Policy = strings.ToLower(strings.TrimSpace(c.Policy))
+	switch c.Policy {
+	case "":
+		c.Policy = "decider"
+	case "decider", "all-pass", "quorum":
+	default:
+		return fmt.Errorf("checkpoint.policy %q is not one of decider, all-pass, quorum", c.Policy)
+	}
+	if c.Quorum < 1 {
+		return fmt.Errorf("checkpoint.quorum must be at least 1, got %d", c.Quorum)
+	}
+	return nil
+}
+
 func normalizeEnforcementMode(mode string) string {
 	mode = strings.ToLower(strings.TrimSpace(mode))
 	if mode == "block" {
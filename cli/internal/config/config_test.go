@@ -220,6 +220,61 @@ web_research = "custom"
 	}
 }
 
+func TestCheckpointConfig(t *testing.T) {
+	tmp := t.TempDir()
+	configDir := filepath.Join(tmp, ".spec-workflow")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(content string) Config {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(configDir, "oraculo.toml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(tmp)
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		return cfg
+	}
+
+	cfg := write(`[checkpoint]
+policy = "All-Pass"
+required_reviewers = ["traceability-judge"]
+
+[checkpoint.max_open_findings]
+critical = 0
+high = 2
+`)
+	if cfg.Checkpoint.Policy != "all-pass" {
+		t.Errorf("Checkpoint.Policy = %q, want all-pass", cfg.Checkpoint.Policy)
+	}
+	if cfg.Checkpoint.Quorum != 2 {
+		t.Errorf("Checkpoint.Quorum = %d, want default 2", cfg.Checkpoint.Quorum)
+	}
+	if len(cfg.Checkpoint.RequiredReviewers) != 1 || cfg.Checkpoint.MaxOpenFindings["high"] != 2 {
+		t.Errorf("Checkpoint = %+v", cfg.Checkpoint)
+	}
+
+	if cfg = write("[checkpoint]\npolicy = \"\"\n"); cfg.Checkpoint.Policy != "decider" {
+		t.Errorf("empty policy should mean decider, got %q", cfg.Checkpoint.Policy)
+	}
+
+	// A typo must not loosen the gate.
+	for _, content := range []string{
+		"[checkpoint]\npolicy = \"allpass\"\n",
+		"[checkpoint]\npolicy = \"all_pass\"\n",
+		"[checkpoint]\npolicy = \"quorum\"\nquorum = 0\n",
+	} {
+		if err := os.WriteFile(filepath.Join(configDir, "oraculo.toml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(tmp); err == nil {
+			t.Errorf("Load(%q): expected error", content)
+		}
+	}
+}
+
 func TestGetValue(t *testing.T) {
 	cfg := Defaults()
 
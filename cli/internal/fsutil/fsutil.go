@@ -0,0 +1,108 @@
+// Package fsutil provides the file primitives that keep concurrent writers
+// (parallel subagents, agent teammates) from losing updates: advisory
+// directory locks and atomic temp-file-and-rename writes.
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// LockTimeout is how long Lock waits for a lock held by another process.
+var LockTimeout = 10 * time.Second
+
+// ErrLockTimeout is returned when a lock is still held after LockTimeout.
+var ErrLockTimeout = errors.New("timed out waiting for lock")
+
+// pollInterval is how often a waiting Lock retries.
+const pollInterval = 20 * time.Millisecond
+
+// DirLock is an exclusive advisory lock on a directory.
+type DirLock struct {
+	f *os.File
+}
+
+// Lock takes an exclusive advisory (flock) lock on dir, waiting up to
+// LockTimeout. Locking the directory rather than a file in it keeps the
+// lock valid across WriteAtomic renames and leaves no lock files behind.
+// Only writers that also call Lock are excluded.
+func Lock(dir string) (*DirLock, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("lock %s: %w", dir, err)
+	}
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return &DirLock{f: f}, nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			f.Close()
+			return nil, fmt.Errorf("lock %s: %w", dir, err)
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("lock %s: %w", dir, ErrLockTimeout)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Unlock releases the lock.
+func (l *DirLock) Unlock() error {
+	defer l.f.Close()
+	return syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+}
+
+// WithLock runs fn while holding the lock on the directory containing path.
+func WithLock(path string, fn func() error) error {
+	l, err := Lock(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer l.Unlock()
+	return fn()
+}
+
+// WriteAtomic writes data to a temporary file next to path and renames it
+// over path, so readers see either the old or the new content, never a
+// partial write.
+func WriteAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	cleanup := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.md")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAtomic(path, []byte("new"), 0644); err != nil {
+		t.Fatalf("WriteAtomic: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("content = %q, want new", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v, want 0644", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dir has %d entries, want only tasks.md (temp file left behind)", len(entries))
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	old := LockTimeout
+	LockTimeout = 50 * time.Millisecond
+	defer func() { LockTimeout = old }()
+
+	dir := t.TempDir()
+	held, err := Lock(dir)
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if _, err := Lock(dir); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("second Lock err = %v, want ErrLockTimeout", err)
+	}
+	held.Unlock()
+
+	l, err := Lock(dir)
+	if err != nil {
+		t.Fatalf("Lock after Unlock: %v", err)
+	}
+	l.Unlock()
+}
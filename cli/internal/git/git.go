@@ -2,6 +2,7 @@
 package git
 
 import (
+	"fmt"
 	"os/exec"
 	"strings"
 )
@@ -56,3 +57,20 @@ func DiffNameOnly(repoRoot, baseRef string) []string {
 	}
 	return strings.Split(out, "\n")
 }
+
+// Revert reverts the given commits in order, one revert commit each. When a
+// revert fails (a conflict, local changes in the way) the sequence is
+// aborted, leaving the branch and work tree as they were.
+func Revert(repoRoot string, hashes []string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	cmd := exec.Command("git", append([]string{"revert", "--no-edit"}, hashes...)...)
+	cmd.Dir = repoRoot
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		Run([]string{"revert", "--abort"}, repoRoot)
+		return fmt.Errorf("git revert: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package git
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Commit is one commit with its per-file diff stats.
+type Commit struct {
+	Hash      string     `json:"hash"`
+	Short     string     `json:"short"`
+	Author    string     `json:"author"`
+	Date      string     `json:"date"`
+	Subject   string     `json:"subject"`
+	Files     []FileStat `json:"files"`
+	Additions int        `json:"additions"`
+	Deletions int        `json:"deletions"`
+}
+
+// FileStat is the diff stat of one file in a commit. Binary files have no
+// line counts.
+type FileStat struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary,omitempty"`
+}
+
+// TaskPattern returns an extended regexp matching "task <id>" in a commit
+// message without matching longer IDs: "task 1" does not match "task 1.2"
+// or "task 12".
+func TaskPattern(taskID string) string {
+	return "task " + regexp.QuoteMeta(taskID) + `\.?([^0-9.]|$)`
+}
+
+// LogGrep returns the commits whose message matches the extended regexp
+// pattern (case-insensitive), newest first, with diff stats. It returns nil
+// when git fails or nothing matches.
+func LogGrep(repoRoot, pattern string) []Commit {
+	out := Run([]string{"log", "-E", "-i", "--grep=" + pattern, "--no-renames",
+		"--format=%x1e%H%x1f%h%x1f%an%x1f%aI%x1f%s", "--numstat"}, repoRoot)
+	if out == "" {
+		return nil
+	}
+	var commits []Commit
+	for _, rec := range strings.Split(out, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(rec), "\n")
+		head := strings.Split(lines[0], "\x1f")
+		if len(head) != 5 {
+			continue
+		}
+		c := Commit{Hash: head[0], Short: head[1], Author: head[2], Date: head[3], Subject: head[4], Files: []FileStat{}}
+		for _, l := range lines[1:] {
+			parts := strings.SplitN(l, "\t", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			fs := FileStat{Path: parts[2]}
+			if parts[0] == "-" {
+				fs.Binary = true
+			} else {
+				fs.Additions, _ = strconv.Atoi(parts[0])
+				fs.Deletions, _ = strconv.Atoi(parts[1])
+			}
+			c.Additions += fs.Additions
+			c.Deletions += fs.Deletions
+			c.Files = append(c.Files, fs)
+		}
+		commits = append(commits, c)
+	}
+	return commits
+}
+
+// TaskCommits returns the commits naming any of the task IDs, newest
+// first. Commits scoped to the spec ("(<spec-name>)" in the message, as in
+// "feat(<spec-name>): task 3 ...") are preferred and scope is "spec"; when
+// there are none, every commit naming the tasks is returned, whichever spec
+// it belongs to, and scope is "any".
+func TaskCommits(repoRoot, specName string, ids []string) (string, []Commit) {
+	patterns := make([]string, len(ids))
+	for i, id := range ids {
+		patterns[i] = TaskPattern(id)
+	}
+	alt := "(" + strings.Join(patterns, "|") + ")"
+
+	if commits := LogGrep(repoRoot, `\(`+regexp.QuoteMeta(specName)+`\).*`+alt); len(commits) > 0 {
+		return "spec", commits
+	}
+	return "any", LogGrep(repoRoot, alt)
+}
+
+// Unreverted returns the commits that are neither revert commits nor
+// already reverted by one.
+func Unreverted(repoRoot string, commits []Commit) []Commit {
+	reverted := RevertedHashes(repoRoot)
+	kept := []Commit{}
+	for _, c := range commits {
+		if reverted[c.Hash] || strings.HasPrefix(c.Subject, `Revert "`) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+var revertsRe = regexp.MustCompile(`This reverts commit ([0-9a-f]{7,40})`)
+
+// RevertedHashes returns the full hashes of the commits a "git revert"
+// commit in the history has already reverted.
+func RevertedHashes(repoRoot string) map[string]bool {
+	reverted := make(map[string]bool)
+	out := Run([]string{"log", "--grep=This reverts commit", "--format=%B"}, repoRoot)
+	for _, m := range revertsRe.FindAllStringSubmatch(out, -1) {
+		if full := Run([]string{"rev-parse", "--verify", "--quiet", m[1] + "^{commit}"}, repoRoot); full != "" {
+			reverted[full] = true
+		}
+	}
+	return reverted
+}
@@ -0,0 +1,80 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// initRepo creates a repository with one commit per message, oldest
+// first, each adding its own file.
+func initRepo(t *testing.T, messages ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, v := range []string{"GIT_AUTHOR", "GIT_COMMITTER"} {
+		t.Setenv(v+"_NAME", "test")
+		t.Setenv(v+"_EMAIL", "test@example.com")
+	}
+	dir := t.TempDir()
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	gitRun("init", "-q")
+	for i, msg := range messages {
+		name := filepath.Join(dir, fmt.Sprintf("file-%d.txt", i))
+		if err := os.WriteFile(name, []byte(msg), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		gitRun("add", "-A")
+		gitRun("commit", "-q", "-m", msg)
+	}
+	return dir
+}
+
+func subjects(commits []Commit) []string {
+	out := make([]string, len(commits))
+	for i, c := range commits {
+		out[i] = c.Subject
+	}
+	return out
+}
+
+func TestTaskCommits(t *testing.T) {
+	// Two specs number their tasks from 1.
+	repo := initRepo(t,
+		"feat(alpha): task 1 add parser",
+		"feat(beta): task 1 add schema",
+		"feat(alpha): task 2 add lexer",
+		"feat(beta): task 12 add index",
+	)
+
+	scope, commits := TaskCommits(repo, "alpha", []string{"1", "2"})
+	got := subjects(commits)
+	if scope != "spec" || len(got) != 2 || got[0] != "feat(alpha): task 2 add lexer" || got[1] != "feat(alpha): task 1 add parser" {
+		t.Errorf("alpha: scope %q, commits %v; want alpha's tasks 2 and 1 only", scope, got)
+	}
+
+	// A spec with no scoped commits gets every spec's commits naming the IDs.
+	scope, commits = TaskCommits(repo, "gamma", []string{"1"})
+	got = subjects(commits)
+	if scope != "any" || len(got) != 2 || got[0] != "feat(beta): task 1 add schema" || got[1] != "feat(alpha): task 1 add parser" {
+		t.Errorf("gamma: scope %q, commits %v; want task 1 of alpha and beta", scope, got)
+	}
+
+	if err := Revert(repo, []string{commits[0].Hash}); err != nil {
+		t.Fatalf("Revert: %v", err)
+	}
+	_, commits = TaskCommits(repo, "beta", []string{"1"})
+	if kept := Unreverted(repo, commits); len(kept) != 0 {
+		t.Errorf("Unreverted = %v, want the reverted commit left out", subjects(kept))
+	}
+}
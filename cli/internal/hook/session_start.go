@@ -7,12 +7,15 @@ import (
 	"strings"
 
 	"github.com/lucas-stellet/oraculo/internal/config"
+	"github.com/lucas-stellet/oraculo/internal/reconcile"
 	"github.com/lucas-stellet/oraculo/internal/render"
+	"github.com/lucas-stellet/oraculo/internal/store"
 	"github.com/lucas-stellet/oraculo/internal/workspace"
 )
 
-// HandleSessionStart syncs the tasks template variant based on TDD config
-// and triggers a re-render of workflows if the config is newer than rendered files.
+// HandleSessionStart syncs the tasks template variant based on TDD config,
+// triggers a re-render of workflows if the config is newer than rendered files
+// and, when enabled, refreshes the global search index.
 func HandleSessionStart() error {
 	ctx := newHookContext()
 
@@ -24,6 +27,44 @@ func HandleSessionStart() error {
 		logHook(fmt.Sprintf("Re-render error: %v", err))
 	}
 
+	if err := refreshSearchIndex(ctx.workspaceRoot, ctx.cfg); err != nil {
+		logHook(fmt.Sprintf("Index error: %v", err))
+	}
+
+	return nil
+}
+
+// refreshSearchIndex incrementally re-indexes every spec when
+// hooks.index_on_session_start is enabled.
+func refreshSearchIndex(workspaceRoot string, cfg config.Config) error {
+	if !cfg.Hooks.IndexOnSessionStart {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(workspaceRoot, ".spec-workflow", "specs")); err != nil {
+		return nil // No specs yet.
+	}
+
+	ix, err := store.OpenIndex(workspaceRoot)
+	if err != nil {
+		return err
+	}
+	defer ix.Close()
+
+	reports, err := reconcile.IndexWorkspace(ix, workspaceRoot)
+	if err != nil {
+		return err
+	}
+	var indexed, deleted int
+	for _, r := range reports {
+		indexed += r.Indexed
+		deleted += r.Deleted
+		for _, e := range r.Errors {
+			logHook(fmt.Sprintf("Index %s: %s", r.Spec, e))
+		}
+	}
+	if indexed > 0 || deleted > 0 {
+		logHook(fmt.Sprintf("Search index updated: %d indexed, %d deleted across %d spec(s).", indexed, deleted, len(reports)))
+	}
 	return nil
 }
 
@@ -0,0 +1,477 @@
+package reconcile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	"github.com/lucas-stellet/oraculo/internal/tasks"
+)
+
+// Finding kinds.
+const (
+	KindMissing  = "missing"  // on disk, not in spec.db
+	KindExtra    = "extra"    // in spec.db, not on disk
+	KindMismatch = "mismatch" // in both, content differs
+)
+
+// Finding entities.
+const (
+	EntityRun      = "run"
+	EntitySubagent = "subagent"
+	EntityArtifact = "artifact"
+	EntityTask     = "task"
+	EntityImplLog  = "impl_log"
+)
+
+// Finding is a single difference between the filesystem and spec.db.
+// Key is the spec-relative path for runs, subagents and artifacts, and the
+// task ID for tasks and impl logs.
+type Finding struct {
+	Kind     string `json:"kind"`
+	Entity   string `json:"entity"`
+	Key      string `json:"key"`
+	Detail   string `json:"detail,omitempty"`
+	Repaired bool   `json:"repaired,omitempty"`
+
+	runID int64 // set for extra runs so repair can delete the exact row
+}
+
+// FsckReport is the result of comparing a spec directory with its spec.db.
+type FsckReport struct {
+	Checked  map[string]int `json:"checked"`
+	Findings []Finding      `json:"findings"`
+	Errors   []string       `json:"errors,omitempty"`
+}
+
+// Clean reports whether no drift was found.
+func (r *FsckReport) Clean() bool {
+	return len(r.Findings) == 0
+}
+
+// Drifted returns the findings that have not been repaired.
+func (r *FsckReport) Drifted() []Finding {
+	var out []Finding
+	for _, f := range r.Findings {
+		if !f.Repaired {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Fsck compares run dirs (with their subagents' brief, report and
+// status.json), artifacts such as _latest.json, tasks.md and impl logs
+// against spec.db. It only reads; see Repair for fixing drift.
+func Fsck(s *store.SpecStore, specDir string) (*FsckReport, error) {
+	r := &FsckReport{Checked: make(map[string]int), Findings: []Finding{}}
+
+	fsRuns := make(map[string]string) // rel path -> abs path
+	fsArtifacts := make(map[string]string)
+	walkPhaseDirs(specDir, func(runDir string) {
+		rel, _ := filepath.Rel(specDir, runDir)
+		fsRuns[rel] = runDir
+	}, func(_, relPath, absPath string) {
+		fsArtifacts[relPath] = absPath
+	})
+
+	if err := checkRuns(s, fsRuns, r); err != nil {
+		return nil, err
+	}
+	if err := checkArtifacts(s, fsArtifacts, r); err != nil {
+		return nil, err
+	}
+	if err := checkTasks(s, specDir, r); err != nil {
+		return nil, err
+	}
+	if err := checkImplLogs(s, specDir, r); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		a, b := r.Findings[i], r.Findings[j]
+		if a.Entity != b.Entity {
+			return a.Entity < b.Entity
+		}
+		return a.Key < b.Key
+	})
+	return r, nil
+}
+
+func checkRuns(s *store.SpecStore, fsRuns map[string]string, r *FsckReport) error {
+	runs, err := s.ListRuns()
+	if err != nil {
+		return err
+	}
+
+	dbRuns := make(map[string]store.Run)
+	for _, run := range runs {
+		key := filepath.Clean(run.CommsPath)
+		if _, dup := dbRuns[key]; dup {
+			r.add(Finding{Kind: KindExtra, Entity: EntityRun, Key: key,
+				Detail: fmt.Sprintf("duplicate row id=%d (%s)", run.ID, run.Command), runID: run.ID})
+			continue
+		}
+		dbRuns[key] = run
+	}
+
+	for _, key := range sortedKeys(fsRuns) {
+		r.Checked[EntityRun]++
+		run, ok := dbRuns[key]
+		if !ok {
+			r.add(Finding{Kind: KindMissing, Entity: EntityRun, Key: key})
+			continue
+		}
+		if err := checkSubagents(s, run, fsRuns[key], r); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range sortedKeys(dbRuns) {
+		if _, ok := fsRuns[key]; !ok {
+			run := dbRuns[key]
+			if run.Collected != "" {
+				continue // removed on purpose by oraculo gc
+			}
+			r.add(Finding{Kind: KindExtra, Entity: EntityRun, Key: key, runID: run.ID})
+		}
+	}
+	return nil
+}
+
+// subagentFields are the files compared for each subagent, paired with the
+// column they are harvested into.
+var subagentFields = []struct {
+	file string
+	get  func(store.Subagent) string
+}{
+	{"brief.md", func(a store.Subagent) string { return a.Brief }},
+	{"report.md", func(a store.Subagent) string { return a.Report }},
+	{"status.json", func(a store.Subagent) string { return a.StatusJSON }},
+}
+
+func checkSubagents(s *store.SpecStore, run store.Run, runDir string, r *FsckReport) error {
+	subs, err := s.ListSubagents(run.ID)
+	if err != nil {
+		return err
+	}
+	dbSubs := make(map[string]store.Subagent, len(subs))
+	for _, a := range subs {
+		dbSubs[a.Name] = a
+	}
+
+	entries, err := os.ReadDir(runDir)
+	if err != nil {
+		r.Errors = append(r.Errors, fmt.Sprintf("read %s: %s", runDir, err))
+		return nil
+	}
+
+	key := filepath.Clean(run.CommsPath)
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if !e.IsDir() || store.IsSpecialDir(e.Name()) {
+			continue
+		}
+		name := e.Name()
+		seen[name] = true
+		r.Checked[EntitySubagent]++
+
+		sub, ok := dbSubs[name]
+		if !ok {
+			r.add(Finding{Kind: KindMissing, Entity: EntitySubagent, Key: filepath.Join(key, name)})
+			continue
+		}
+		var differs []string
+		for _, f := range subagentFields {
+			disk, _ := os.ReadFile(filepath.Join(runDir, name, f.file))
+			if store.ContentHash(disk) != store.ContentHash([]byte(f.get(sub))) {
+				differs = append(differs, f.file)
+			}
+		}
+		if len(differs) > 0 {
+			r.add(Finding{Kind: KindMismatch, Entity: EntitySubagent, Key: filepath.Join(key, name),
+				Detail: strings.Join(differs, ", ")})
+		}
+	}
+
+	for _, name := range sortedKeys(dbSubs) {
+		if !seen[name] {
+			r.add(Finding{Kind: KindExtra, Entity: EntitySubagent, Key: filepath.Join(key, name)})
+		}
+	}
+	return nil
+}
+
+func checkArtifacts(s *store.SpecStore, fsArtifacts map[string]string, r *FsckReport) error {
+	dbHashes, err := s.ArtifactHashes()
+	if err != nil {
+		return err
+	}
+	checkHashes(EntityArtifact, fsArtifacts, dbHashes, r)
+	return nil
+}
+
+func checkImplLogs(s *store.SpecStore, specDir string, r *FsckReport) error {
+	logs, err := listImplLogs(specDir)
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+	}
+	dbHashes, err := s.ImplLogHashes()
+	if err != nil {
+		return err
+	}
+	checkHashes(EntityImplLog, logs, dbHashes, r)
+	return nil
+}
+
+// checkHashes compares files on disk with stored content hashes by key.
+func checkHashes(entity string, files, dbHashes map[string]string, r *FsckReport) {
+	for _, key := range sortedKeys(files) {
+		r.Checked[entity]++
+		want, ok := dbHashes[key]
+		if !ok {
+			r.add(Finding{Kind: KindMissing, Entity: entity, Key: key})
+			continue
+		}
+		data, err := os.ReadFile(files[key])
+		if err != nil {
+			r.Errors = append(r.Errors, fmt.Sprintf("read %s: %s", files[key], err))
+			continue
+		}
+		if got := store.ContentHash(data); got != want {
+			r.add(Finding{Kind: KindMismatch, Entity: entity, Key: key,
+				Detail: fmt.Sprintf("sha256 fs=%s db=%s", shortHash(got), shortHash(want))})
+		}
+	}
+	for _, key := range sortedKeys(dbHashes) {
+		if _, ok := files[key]; !ok {
+			r.add(Finding{Kind: KindExtra, Entity: entity, Key: key})
+		}
+	}
+}
+
+func checkTasks(s *store.SpecStore, specDir string, r *FsckReport) error {
+	fsTasks, err := parseTaskRecords(specDir)
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+		return nil
+	}
+	list, err := s.ListTasks()
+	if err != nil {
+		return err
+	}
+	dbTasks := make(map[string]store.TaskRecord, len(list))
+	for _, t := range list {
+		dbTasks[t.TaskID] = t
+	}
+
+	for _, id := range sortedKeys(fsTasks) {
+		r.Checked[EntityTask]++
+		db, ok := dbTasks[id]
+		if !ok {
+			r.add(Finding{Kind: KindMissing, Entity: EntityTask, Key: id})
+			continue
+		}
+		if diff := diffTask(fsTasks[id], db); diff != "" {
+			r.add(Finding{Kind: KindMismatch, Entity: EntityTask, Key: id, Detail: diff})
+		}
+	}
+	for _, id := range sortedKeys(dbTasks) {
+		if _, ok := fsTasks[id]; !ok {
+			r.add(Finding{Kind: KindExtra, Entity: EntityTask, Key: id})
+		}
+	}
+	return nil
+}
+
+// parseTaskRecords parses tasks.md into records keyed by task ID.
+// A spec without tasks.md has no tasks.
+func parseTaskRecords(specDir string) (map[string]store.TaskRecord, error) {
+	out := make(map[string]store.TaskRecord)
+	path := specdir.TasksPath(specDir)
+	if !specdir.FileExists(path) {
+		return out, nil
+	}
+	doc, err := tasks.ParseFile(path)
+	if err != nil {
+		return out, fmt.Errorf("parse tasks.md: %w", err)
+	}
+	for _, t := range doc.Tasks {
+		out[t.ID] = t.Record()
+	}
+	return out, nil
+}
+
+// diffTask describes the fields where the stored task differs from tasks.md.
+func diffTask(fs, db store.TaskRecord) string {
+	var diffs []string
+	add := func(field string, a, b any) {
+		if a != b {
+			diffs = append(diffs, fmt.Sprintf("%s: fs=%v db=%v", field, a, b))
+		}
+	}
+	add("status", fs.Status, db.Status)
+	add("title", fs.Title, db.Title)
+	add("wave", intOrNil(fs.Wave), intOrNil(db.Wave))
+	add("depends_on", fs.DependsOn, db.DependsOn)
+	add("files", fs.Files, db.Files)
+	add("tdd", fs.TDD, db.TDD)
+	add("deferred", fs.IsDeferred, db.IsDeferred)
+	add("meta", formatMeta(fs.Meta), formatMeta(db.Meta))
+	return strings.Join(diffs, "; ")
+}
+
+// formatMeta renders task metadata as sorted key=value pairs.
+func formatMeta(meta map[string]string) string {
+	pairs := make([]string, 0, len(meta))
+	for k, v := range meta {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// Repair re-harvests the drifted entries listed in r and removes extra rows,
+// marking each finding it fixed as Repaired. Entries without drift are not
+// touched.
+func Repair(s *store.SpecStore, specDir string, r *FsckReport) error {
+	runs, err := s.ListRuns()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]store.Run)
+	for _, run := range runs {
+		if _, ok := existing[filepath.Clean(run.CommsPath)]; !ok {
+			existing[filepath.Clean(run.CommsPath)] = run
+		}
+	}
+
+	var fsTasks map[string]store.TaskRecord
+	var logs map[string]string
+	harvested := make(map[string]error) // run rel path -> harvest result
+
+	// Delete extra runs first so re-harvest attaches to the surviving row.
+	for i := range r.Findings {
+		f := &r.Findings[i]
+		if f.Entity == EntityRun && f.Kind == KindExtra {
+			if err := s.DeleteRun(f.runID); err != nil {
+				r.Errors = append(r.Errors, err.Error())
+				continue
+			}
+			f.Repaired = true
+		}
+	}
+
+	for i := range r.Findings {
+		f := &r.Findings[i]
+		if f.Repaired {
+			continue
+		}
+
+		var err error
+		switch f.Entity {
+		case EntityRun, EntitySubagent:
+			runKey := f.Key
+			if f.Entity == EntitySubagent {
+				runKey = filepath.Dir(f.Key)
+			}
+			done, ok := harvested[runKey]
+			if !ok {
+				done = harvestRun(s, specDir, runKey, existing)
+				harvested[runKey] = done
+			}
+			err = done
+
+		case EntityArtifact:
+			if f.Kind == KindExtra {
+				err = s.DeleteArtifact(f.Key)
+			} else {
+				err = s.HarvestArtifact(phaseOf(f.Key), f.Key, filepath.Join(specDir, f.Key))
+			}
+
+		case EntityTask:
+			if f.Kind == KindExtra {
+				err = s.DeleteTask(f.Key)
+				break
+			}
+			if fsTasks == nil {
+				if fsTasks, err = parseTaskRecords(specDir); err != nil {
+					break
+				}
+			}
+			err = s.SyncTask(fsTasks[f.Key])
+
+		case EntityImplLog:
+			if f.Kind == KindExtra {
+				err = s.DeleteImplLog(f.Key)
+				break
+			}
+			if logs == nil {
+				if logs, err = listImplLogs(specDir); err != nil {
+					break
+				}
+			}
+			err = s.HarvestImplLog(f.Key, logs[f.Key])
+		}
+
+		if err != nil {
+			r.Errors = append(r.Errors, fmt.Sprintf("repair %s %s: %s", f.Entity, f.Key, err))
+			continue
+		}
+		f.Repaired = true
+	}
+	return nil
+}
+
+// harvestRun re-harvests a single run dir, reusing the command and wave of an
+// existing row so the harvest updates it instead of adding a second one.
+func harvestRun(s *store.SpecStore, specDir, relPath string, existing map[string]store.Run) error {
+	absPath := filepath.Join(specDir, relPath)
+	if run, ok := existing[relPath]; ok {
+		return s.HarvestRunDir(absPath, run.Command, run.WaveNumber)
+	}
+	return s.HarvestRunDir(absPath, InferCommandFromPath(absPath), waveNumPtr(absPath))
+}
+
+// phaseOf returns the phase an artifact path belongs to: its phase
+// directory, or the phase of a spec-root document.
+func phaseOf(relPath string) string {
+	for _, doc := range rootDocs {
+		if relPath == doc.name {
+			return doc.phase
+		}
+	}
+	first, _, _ := strings.Cut(filepath.ToSlash(relPath), "/")
+	return first
+}
+
+func (r *FsckReport) add(f Finding) {
+	r.Findings = append(r.Findings, f)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func intOrNil(p *int) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
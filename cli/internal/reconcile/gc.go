@@ -0,0 +1,391 @@
+package reconcile
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+// GC actions.
+const (
+	GCCompress = "compress"
+	GCDelete   = "delete"
+)
+
+// GCOptions controls GC.
+type GCOptions struct {
+	Action   string // GCCompress or GCDelete
+	KeepLast int    // newest runs kept per parent directory, at least 1
+	MinAge   time.Duration
+	DryRun   bool
+	Now      time.Time // zero means time.Now()
+}
+
+// GCRun is one run dir considered by GC. Reason is set for kept runs.
+type GCRun struct {
+	Path    string `json:"path"`
+	Bytes   int64  `json:"bytes"`
+	Archive string `json:"archive,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// GCReport describes a GC pass over a spec.
+type GCReport struct {
+	Spec       string   `json:"spec"`
+	Action     string   `json:"action"`
+	DryRun     bool     `json:"dry_run"`
+	Collected  []GCRun  `json:"collected"`
+	Kept       []GCRun  `json:"kept"`
+	FreedBytes int64    `json:"freed_bytes"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// gcCandidate is a run dir on disk together with what GC needs to know
+// about it.
+type gcCandidate struct {
+	rel    string
+	abs    string
+	number int
+	bytes  int64
+	newest time.Time
+}
+
+// GC compresses or deletes run dirs that are safe to remove: the run is
+// harvested into spec.db and up to date with the files, no _latest.json in
+// an enclosing directory points at it, it is not among the newest
+// opts.KeepLast runs of its directory and nothing in it changed within
+// opts.MinAge. The run and subagent rows stay in spec.db and are marked
+// collected.
+//
+// KeepLast is raised to 1 because dispatch numbers the next run after the
+// highest run dir on disk; removing it would reuse a run number.
+//
+// The delete action also keeps runs holding files that are not harvested
+// (special `_` subdirectories, extra files), since they would be lost.
+func GC(s *store.SpecStore, specDir string, opts GCOptions) GCReport {
+	if opts.Action != GCDelete {
+		opts.Action = GCCompress
+	}
+	if opts.KeepLast < 1 {
+		opts.KeepLast = 1
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	r := GCReport{
+		Spec:      filepath.Base(specDir),
+		Action:    opts.Action,
+		DryRun:    opts.DryRun,
+		Collected: []GCRun{},
+		Kept:      []GCRun{},
+	}
+
+	var cands []gcCandidate
+	var latest []string
+	walkPhaseDirs(specDir, func(runDir string) {
+		rel, _ := filepath.Rel(specDir, runDir)
+		n, _ := strconv.Atoi(strings.TrimPrefix(filepath.Base(runDir), "run-"))
+		c := gcCandidate{rel: rel, abs: runDir, number: n}
+		c.bytes, c.newest = treeStats(runDir)
+		cands = append(cands, c)
+	}, func(_, relPath, absPath string) {
+		if filepath.Base(relPath) == "_latest.json" {
+			latest = append(latest, absPath)
+		}
+	})
+
+	runs, err := s.ListRuns()
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+		return r
+	}
+	dbRuns := make(map[string]store.Run, len(runs))
+	for _, run := range runs {
+		dbRuns[filepath.Clean(run.CommsPath)] = run
+	}
+
+	refs := latestRefs(specDir, latest, &r)
+	newest := newestPerDir(cands, opts.KeepLast)
+
+	sort.Slice(cands, func(i, j int) bool { return cands[i].rel < cands[j].rel })
+	for _, c := range cands {
+		item := GCRun{Path: filepath.ToSlash(c.rel), Bytes: c.bytes}
+		run, ok := dbRuns[c.rel]
+		switch {
+		case !ok:
+			item.Reason = "not harvested"
+		case !runUpToDate(s, run, c.abs):
+			item.Reason = "spec.db is out of date (run 'oraculo db fsck --repair')"
+		case refs[c.rel] != "":
+			item.Reason = "referenced by " + refs[c.rel]
+		case newest[c.rel]:
+			item.Reason = fmt.Sprintf("among the newest %d in its directory", opts.KeepLast)
+		case opts.Now.Sub(c.newest) < opts.MinAge:
+			item.Reason = "modified within the minimum age"
+		case opts.Action == GCDelete:
+			if extra := unharvestedFiles(c.abs); len(extra) > 0 {
+				item.Reason = "has unharvested files: " + strings.Join(extra, ", ")
+			}
+		}
+		if item.Reason != "" {
+			r.Kept = append(r.Kept, item)
+			continue
+		}
+
+		if opts.Action == GCCompress {
+			item.Archive = item.Path + ".tar.gz"
+		}
+		if !opts.DryRun {
+			if err := collectRun(s, run, c.abs, opts.Action); err != nil {
+				r.Errors = append(r.Errors, err.Error())
+				continue
+			}
+		}
+		r.Collected = append(r.Collected, item)
+		r.FreedBytes += c.bytes
+	}
+	return r
+}
+
+// treeStats returns the total size and newest modification time of the
+// files under dir.
+func treeStats(dir string) (int64, time.Time) {
+	var size int64
+	var newest time.Time
+	_ = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, newest
+}
+
+// latestRefs maps each run dir referenced by a _latest.json to that file's
+// spec-relative path. A string value refers to a run when it names the run
+// (run_id, execution, checkpoint) or a path ending in it (run_dir), and the
+// _latest.json sits in a directory enclosing the run.
+func latestRefs(specDir string, latest []string, r *GCReport) map[string]string {
+	refs := make(map[string]string)
+	for _, path := range latest {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			r.Errors = append(r.Errors, err.Error())
+			continue
+		}
+		var doc any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			r.Errors = append(r.Errors, fmt.Sprintf("invalid %s: %s", path, err))
+			continue
+		}
+		rel, _ := filepath.Rel(specDir, path)
+		base, _ := filepath.Rel(specDir, filepath.Dir(path))
+		for _, v := range jsonStrings(doc) {
+			v = filepath.Clean(filepath.FromSlash(v))
+			if !runDirRe.MatchString(filepath.Base(v)) {
+				continue
+			}
+			walkRunsUnder(specDir, base, func(runRel string) {
+				if runRel == v || strings.HasSuffix(string(filepath.Separator)+runRel, string(filepath.Separator)+v) {
+					refs[runRel] = filepath.ToSlash(rel)
+				}
+			})
+		}
+	}
+	return refs
+}
+
+// walkRunsUnder calls fn with the spec-relative path of every run dir below
+// specDir/base.
+func walkRunsUnder(specDir, base string, fn func(runRel string)) {
+	_ = filepath.Walk(filepath.Join(specDir, base), func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if runDirRe.MatchString(info.Name()) {
+			rel, _ := filepath.Rel(specDir, path)
+			fn(rel)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// jsonStrings returns every string value in a decoded JSON document.
+func jsonStrings(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case map[string]any:
+		var out []string
+		for _, x := range t {
+			out = append(out, jsonStrings(x)...)
+		}
+		return out
+	case []any:
+		var out []string
+		for _, x := range t {
+			out = append(out, jsonStrings(x)...)
+		}
+		return out
+	}
+	return nil
+}
+
+// newestPerDir marks the keep highest-numbered runs of each directory.
+func newestPerDir(cands []gcCandidate, keep int) map[string]bool {
+	byDir := make(map[string][]gcCandidate)
+	for _, c := range cands {
+		dir := filepath.Dir(c.rel)
+		byDir[dir] = append(byDir[dir], c)
+	}
+	newest := make(map[string]bool)
+	for _, list := range byDir {
+		sort.Slice(list, func(i, j int) bool { return list[i].number > list[j].number })
+		for i := 0; i < keep && i < len(list); i++ {
+			newest[list[i].rel] = true
+		}
+	}
+	return newest
+}
+
+// runUpToDate reports whether spec.db holds exactly what is in runDir: the
+// same subagents with the same brief, report and status.json, and the same
+// _handoff.md.
+func runUpToDate(s *store.SpecStore, run store.Run, runDir string) bool {
+	var fr FsckReport
+	fr.Checked = make(map[string]int)
+	if err := checkSubagents(s, run, runDir, &fr); err != nil || len(fr.Findings) > 0 || len(fr.Errors) > 0 {
+		return false
+	}
+	disk, err := os.ReadFile(filepath.Join(runDir, "_handoff.md"))
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	handoffs, err := s.ListHandoffs(run.ID)
+	if err != nil || len(handoffs) == 0 {
+		return false
+	}
+	return handoffs[len(handoffs)-1].Content == string(disk)
+}
+
+// harvestedSubagentFiles are the only files of a subagent dir stored in
+// spec.db.
+var harvestedSubagentFiles = map[string]bool{"brief.md": true, "report.md": true, "status.json": true}
+
+// unharvestedFiles lists the entries of runDir whose content spec.db does
+// not hold.
+func unharvestedFiles(runDir string) []string {
+	var extra []string
+	entries, _ := os.ReadDir(runDir)
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case !e.IsDir() && name == "_handoff.md":
+		case !e.IsDir() || store.IsSpecialDir(name):
+			extra = append(extra, name)
+		default:
+			files, _ := os.ReadDir(filepath.Join(runDir, name))
+			for _, f := range files {
+				if f.IsDir() || !harvestedSubagentFiles[f.Name()] {
+					extra = append(extra, name+"/"+f.Name())
+				}
+			}
+		}
+	}
+	return extra
+}
+
+// collectRun removes runDir, first writing runDir.tar.gz for the compress
+// action, and marks the run collected in spec.db.
+func collectRun(s *store.SpecStore, run store.Run, runDir, action string) error {
+	how := store.CollectedDeleted
+	if action == GCCompress {
+		how = store.CollectedCompressed
+		if err := compressDir(runDir, runDir+".tar.gz"); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(runDir); err != nil {
+		return fmt.Errorf("reconcile: gc remove %s: %w", runDir, err)
+	}
+	return s.MarkRunCollected(run.ID, how)
+}
+
+// compressDir writes dir as a gzipped tarball at dest, with entries named
+// relative to dir's parent. The archive is written to a temporary file and
+// renamed into place, and an existing dest is never overwritten.
+func compressDir(dir, dest string) error {
+	if specdir.FileExists(dest) {
+		return fmt.Errorf("reconcile: gc: %s already exists", dest)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".gc-*.tar.gz")
+	if err != nil {
+		return fmt.Errorf("reconcile: gc: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	tw := tar.NewWriter(gz)
+	parent := filepath.Dir(dir)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(parent, path)
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("reconcile: gc compress %s: %w", filepath.Base(dir), err)
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return fmt.Errorf("reconcile: gc: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+// Package reconcile brings spec.db back in line with the spec directory on disk.
+// The filesystem is the source of truth; these helpers re-harvest run dirs,
+// artifacts, implementation logs, tasks and waves into a SpecStore, and keep
+// the global search index current with each spec's documents.
+package reconcile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	"github.com/lucas-stellet/oraculo/internal/tasks"
+	"github.com/lucas-stellet/oraculo/internal/wave"
+)
+
+// HarvestReport counts what was harvested from the filesystem.
+type HarvestReport struct {
+	Runs      int      `json:"runs"`
+	Artifacts int      `json:"artifacts"`
+	ImplLogs  int      `json:"impl_logs"`
+	Tasks     int      `json:"tasks"`
+	Waves     int      `json:"waves"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// Total returns the number of run dirs, artifacts and impl logs harvested.
+func (r HarvestReport) Total() int {
+	return r.Runs + r.Artifacts + r.ImplLogs
+}
+
+// phaseDirs lists the phase directories walked for runs and artifacts.
+var phaseDirs = []string{
+	specdir.PhaseDiscover,
+	specdir.PhaseDesign,
+	specdir.PhasePlanning,
+	specdir.PhaseExecution,
+	specdir.PhaseQA,
+	specdir.PhasePostMortem,
+}
+
+// rootDocs are the dashboard documents at the spec root. They are harvested
+// as artifacts alongside the phase directories so their versions are kept.
+var rootDocs = []struct{ name, phase string }{
+	{specdir.RequirementsMD, "requirements"},
+	{specdir.DesignMD, specdir.PhaseDesign},
+	{specdir.TasksMD, specdir.PhasePlanning},
+}
+
+var (
+	taskIDRe = regexp.MustCompile(`^task-(.+)\.md$`)
+	runDirRe = regexp.MustCompile(`^run-\d+$`)
+)
+
+// HarvestAll harvests files, tasks and waves into s. Individual failures are
+// collected in the report rather than aborting the walk.
+func HarvestAll(s *store.SpecStore, specDir string) HarvestReport {
+	r := HarvestFiles(s, specDir)
+
+	n, err := SyncTasks(s, specDir)
+	r.Tasks = n
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+	}
+
+	n, err = SyncWaves(s, specDir)
+	r.Waves = n
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+	}
+
+	return r
+}
+
+// HarvestFiles walks phase directories and the implementation logs directory,
+// harvesting run dirs, markdown/json artifacts and impl logs.
+func HarvestFiles(s *store.SpecStore, specDir string) HarvestReport {
+	var r HarvestReport
+
+	walkPhaseDirs(specDir, func(runDir string) {
+		if err := s.HarvestRunDir(runDir, InferCommandFromPath(runDir), waveNumPtr(runDir)); err != nil {
+			r.Errors = append(r.Errors, err.Error())
+			return
+		}
+		r.Runs++
+	}, func(phase, relPath, absPath string) {
+		if err := s.HarvestArtifact(phase, relPath, absPath); err != nil {
+			r.Errors = append(r.Errors, err.Error())
+			return
+		}
+		r.Artifacts++
+	})
+
+	logs, err := listImplLogs(specDir)
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+	}
+	for taskID, absPath := range logs {
+		if err := s.HarvestImplLog(taskID, absPath); err != nil {
+			r.Errors = append(r.Errors, err.Error())
+			continue
+		}
+		r.ImplLogs++
+	}
+
+	return r
+}
+
+// HarvestArtifacts records the spec's markdown/json artifacts whose content
+// changed since spec.db last saw them, each as a new version, and leaves
+// runs alone. Unchanged files cost only a hash comparison, so live paths
+// (indexing, dispatch handoffs) can call it. It returns the number of
+// artifacts recorded.
+func HarvestArtifacts(s *store.SpecStore, specDir string) (int, error) {
+	known, err := s.ArtifactHashes()
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	var errs []string
+	walkPhaseDirs(specDir, func(string) {}, func(phase, relPath, absPath string) {
+		content, err := os.ReadFile(absPath)
+		if err != nil {
+			errs = append(errs, err.Error())
+			return
+		}
+		if known[relPath] == store.ContentHash(content) {
+			return
+		}
+		if err := s.HarvestArtifact(phase, relPath, absPath); err != nil {
+			errs = append(errs, err.Error())
+			return
+		}
+		n++
+	})
+	if len(errs) > 0 {
+		return n, fmt.Errorf("harvest artifacts: %s", strings.Join(errs, "; "))
+	}
+	return n, nil
+}
+
+// walkPhaseDirs visits the spec-root documents, then every run-NNN directory
+// and every markdown/json file outside run dirs under the phase directories.
+// Run dirs are not descended into: their contents belong to the run's
+// subagents.
+func walkPhaseDirs(specDir string, visitRun func(runDir string), visitArtifact func(phase, relPath, absPath string)) {
+	for _, doc := range rootDocs {
+		if path := filepath.Join(specDir, doc.name); specdir.FileExists(path) {
+			visitArtifact(doc.phase, doc.name, path)
+		}
+	}
+	for _, phase := range phaseDirs {
+		phaseDir := filepath.Join(specDir, phase)
+		if !specdir.DirExists(phaseDir) {
+			continue
+		}
+		_ = filepath.Walk(phaseDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() && runDirRe.MatchString(info.Name()) {
+				visitRun(path)
+				return filepath.SkipDir
+			}
+			if !info.IsDir() && isArtifactFile(path) {
+				relPath, _ := filepath.Rel(specDir, path)
+				visitArtifact(phase, relPath, path)
+			}
+			return nil
+		})
+	}
+}
+
+// listImplLogs maps task IDs to implementation log paths.
+func listImplLogs(specDir string) (map[string]string, error) {
+	logs := make(map[string]string)
+	implDir := filepath.Join(specDir, specdir.ImplLogsDir)
+	if !specdir.DirExists(implDir) {
+		return logs, nil
+	}
+	entries, err := os.ReadDir(implDir)
+	if err != nil {
+		return logs, fmt.Errorf("read %s: %w", specdir.ImplLogsDir, err)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if taskID := ExtractTaskID(e.Name()); taskID != "" {
+			logs[taskID] = filepath.Join(implDir, e.Name())
+		}
+	}
+	return logs, nil
+}
+
+// SyncTasks parses tasks.md and upserts every task. A missing tasks.md is
+// not an error: specs before the planning phase have none.
+func SyncTasks(s *store.SpecStore, specDir string) (int, error) {
+	tasksPath := specdir.TasksPath(specDir)
+	if !specdir.FileExists(tasksPath) {
+		return 0, nil
+	}
+	doc, err := tasks.ParseFile(tasksPath)
+	if err != nil {
+		return 0, fmt.Errorf("parse tasks.md: %w", err)
+	}
+
+	var n int
+	for _, t := range doc.Tasks {
+		if err := s.SyncTask(t.Record()); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// SyncWaves scans wave directories and upserts their resolved state.
+func SyncWaves(s *store.SpecStore, specDir string) (int, error) {
+	waves, err := wave.ScanWaves(specDir)
+	if err != nil {
+		return 0, fmt.Errorf("scan waves: %w", err)
+	}
+
+	var n int
+	for _, ws := range waves {
+		sum := wave.GenerateSummary(specDir, ws.WaveNum)
+		err := s.UpsertWave(store.WaveRecord{
+			WaveNumber:    ws.WaveNum,
+			Status:        ws.Status,
+			ExecRuns:      ws.ExecRuns,
+			CheckRuns:     ws.CheckRuns,
+			SummaryStatus: sum.Status,
+			SummaryText:   sum.Summary,
+			SummarySource: sum.Source,
+			StaleFlag:     sum.StaleFlag,
+		})
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// ExtractTaskID extracts a task ID from a filename like "task-3.md".
+func ExtractTaskID(filename string) string {
+	m := taskIDRe.FindStringSubmatch(filename)
+	if len(m) == 2 {
+		return m[1]
+	}
+	return ""
+}
+
+// InferCommandFromPath guesses the command name from the run directory path.
+func InferCommandFromPath(path string) string {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	// Checkpoint runs also live under execution/waves, so check them first.
+	for _, p := range parts {
+		if p == "checkpoint" {
+			return "checkpoint"
+		}
+	}
+	for i, p := range parts {
+		if p == "execution" && i+1 < len(parts) && parts[i+1] == "waves" {
+			return "exec"
+		}
+		// discover/_comms and post-mortem/_comms hold run dirs directly,
+		// so the segment after _comms is only a command when it is not a run.
+		if p == "_comms" && i+1 < len(parts) && !runDirRe.MatchString(parts[i+1]) {
+			return parts[i+1]
+		}
+	}
+	// Fallback: infer from phase.
+	for _, p := range parts {
+		switch p {
+		case "discover":
+			return "discover"
+		case "design":
+			return "design-research"
+		case "planning":
+			return "tasks-plan"
+		case "qa":
+			return "qa"
+		case "post-mortem":
+			return "post-mortem"
+		}
+	}
+	return "unknown"
+}
+
+func waveNumPtr(path string) *int {
+	if wn := specdir.WaveNumFromPath(path); wn > 0 {
+		return &wn
+	}
+	return nil
+}
+
+func isArtifactFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".md" || ext == ".json"
+}
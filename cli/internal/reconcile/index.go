@@ -0,0 +1,229 @@
+package reconcile
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/spec"
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+// Document types written to the global index by IndexSpec.
+const (
+	DocRequirements = "requirements"
+	DocDesign       = "design"
+	DocTasks        = "tasks"
+	DocReport       = "report"
+	DocImplLog      = "impl_log"
+)
+
+// snippetLen is the maximum length of an indexed document snippet.
+const snippetLen = 200
+
+// IndexReport describes one incremental index pass over a spec.
+type IndexReport struct {
+	Spec      string   `json:"spec"`
+	Stage     string   `json:"stage"`
+	Indexed   int      `json:"indexed"`
+	Unchanged int      `json:"unchanged"`
+	Deleted   int      `json:"deleted"`
+	Versioned int      `json:"versioned"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// indexSource is a markdown file that belongs in the global index.
+type indexSource struct {
+	relPath string
+	absPath string
+	docType string
+	phase   string
+}
+
+// IndexSpec brings the global index in line with the markdown documents of
+// specDir: requirements, design, tasks, phase reports and implementation
+// logs. Documents are keyed by path and content hash, so unchanged files are
+// skipped and documents whose file is gone are deleted. The spec row is
+// registered with its current stage.
+func IndexSpec(ix *store.IndexStore, specDir string) IndexReport {
+	name := filepath.Base(specDir)
+	r := IndexReport{Spec: name, Stage: indexStage(specDir)}
+
+	if err := ix.IndexSpec(name, r.Stage, filepath.Join(specDir, specdir.SpecDB)); err != nil {
+		r.Errors = append(r.Errors, err.Error())
+	}
+
+	// Indexing is the routine pass over the spec's documents, so it also
+	// records new artifact versions; harvests alone would miss rewrites made
+	// while the spec is in progress. spec.db is only used if present.
+	if specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+		if s := store.TryOpen(specDir); s != nil {
+			n, err := HarvestArtifacts(s, specDir)
+			s.Close()
+			r.Versioned = n
+			if err != nil {
+				r.Errors = append(r.Errors, err.Error())
+			}
+		}
+	}
+
+	indexed, err := ix.DocumentHashes(name)
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+		return r
+	}
+
+	seen := make(map[string]bool)
+	for _, src := range indexSources(specDir) {
+		seen[src.relPath] = true
+		content, err := os.ReadFile(src.absPath)
+		if err != nil {
+			r.Errors = append(r.Errors, err.Error())
+			continue
+		}
+		hash := store.ContentHash(content)
+		if old, ok := indexed[src.relPath]; ok && old == hash {
+			r.Unchanged++
+			continue
+		}
+		doc := store.IndexDoc{
+			Spec:        name,
+			Path:        src.relPath,
+			DocType:     src.docType,
+			Phase:       src.phase,
+			Title:       docTitle(string(content), src.relPath),
+			Snippet:     docSnippet(string(content)),
+			Content:     string(content),
+			ContentHash: hash,
+		}
+		if err := ix.UpsertDocument(doc); err != nil {
+			r.Errors = append(r.Errors, err.Error())
+			continue
+		}
+		r.Indexed++
+	}
+
+	var stale []string
+	for path := range indexed {
+		if !seen[path] {
+			stale = append(stale, path)
+		}
+	}
+	sort.Strings(stale)
+	for _, path := range stale {
+		if err := ix.DeleteDocument(name, path); err != nil {
+			r.Errors = append(r.Errors, err.Error())
+			continue
+		}
+		r.Deleted++
+	}
+	return r
+}
+
+// IndexWorkspace runs IndexSpec for every spec under .spec-workflow/specs.
+func IndexWorkspace(ix *store.IndexStore, cwd string) ([]IndexReport, error) {
+	names, err := spec.List(cwd)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]IndexReport, 0, len(names))
+	for _, name := range names {
+		reports = append(reports, IndexSpec(ix, specdir.SpecDirAbs(cwd, name)))
+	}
+	return reports, nil
+}
+
+// indexStage returns the stage recorded by finalizar, falling back to the
+// stage classified from the files on disk. spec.db is only read if present.
+func indexStage(specDir string) string {
+	if specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+		if s, err := store.OpenNoMigrate(specDir); err == nil {
+			stage, _ := s.GetMeta("stage")
+			s.Close()
+			if stage != "" {
+				return stage
+			}
+		}
+	}
+	return spec.ClassifyStage(specDir)
+}
+
+// indexSources lists the markdown files of specDir in path order. Run dirs
+// are skipped: subagent briefs and reports are summarized by the phase
+// reports and implementation logs.
+func indexSources(specDir string) []indexSource {
+	var srcs []indexSource
+	implPrefix := specdir.ImplLogsDir + "/"
+	walkPhaseDirs(specDir, func(string) {}, func(phase, relPath, absPath string) {
+		if filepath.Ext(absPath) != ".md" {
+			return
+		}
+		rel := filepath.ToSlash(relPath)
+		docType := DocReport
+		switch {
+		case rel == specdir.RequirementsMD:
+			docType = DocRequirements
+		case rel == specdir.DesignMD:
+			docType = DocDesign
+		case rel == specdir.TasksMD:
+			docType = DocTasks
+		case strings.HasPrefix(rel, implPrefix):
+			docType = DocImplLog
+		}
+		srcs = append(srcs, indexSource{rel, absPath, docType, phase})
+	})
+
+	sort.Slice(srcs, func(i, j int) bool { return srcs[i].relPath < srcs[j].relPath })
+	return srcs
+}
+
+// docTitle returns the first markdown heading, or relPath if there is none.
+func docTitle(content, relPath string) string {
+	sc := bufio.NewScanner(strings.NewReader(stripFrontmatter(content)))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "#") {
+			if title := strings.TrimSpace(strings.TrimLeft(line, "#")); title != "" {
+				return title
+			}
+		}
+	}
+	return relPath
+}
+
+// docSnippet returns the first prose of the document, skipping frontmatter
+// and headings, cut to snippetLen bytes.
+func docSnippet(content string) string {
+	var parts []string
+	size := 0
+	sc := bufio.NewScanner(strings.NewReader(stripFrontmatter(content)))
+	for sc.Scan() && size < snippetLen {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts = append(parts, line)
+		size += len(line) + 1
+	}
+	snippet := strings.Join(parts, " ")
+	if len(snippet) > snippetLen {
+		snippet = snippet[:snippetLen] + "..."
+	}
+	return snippet
+}
+
+// stripFrontmatter removes a leading YAML frontmatter block.
+func stripFrontmatter(content string) string {
+	if !strings.HasPrefix(content, "---\n") {
+		return content
+	}
+	end := strings.Index(content[4:], "\n---")
+	if end < 0 {
+		return content
+	}
+	rest := content[4+end+4:]
+	return strings.TrimPrefix(rest, "\n")
+}
@@ -0,0 +1,255 @@
+package reconcile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+const (
+	// rebuildSuffix names the side-by-side database built by Rebuild.
+	rebuildSuffix = ".rebuild"
+	// backupSuffix names the previous spec.db kept after a swap.
+	backupSuffix = ".bak"
+)
+
+// sqliteSidecars are the files SQLite keeps next to a WAL-mode database.
+var sqliteSidecars = []string{"", "-wal", "-shm"}
+
+// RebuildOptions controls Rebuild.
+type RebuildOptions struct {
+	// Shadow builds the new database next to spec.db and leaves it there
+	// instead of swapping it into place.
+	Shadow bool
+}
+
+// RebuildReport describes the result of a rebuild.
+type RebuildReport struct {
+	DBPath            string         `json:"db_path"`
+	BackupPath        string         `json:"backup_path,omitempty"`
+	Shadow            bool           `json:"shadow"`
+	Harvest           HarvestReport  `json:"harvest"`
+	Counts            map[string]int `json:"counts"`
+	PreservedMeta     int            `json:"preserved_meta"`
+	PreservedSummary  bool           `json:"preserved_summary"`
+	PreservedEvents   int            `json:"preserved_events"`
+	PreservedVersions int            `json:"preserved_versions"`
+	PreservedRuns     int            `json:"preserved_runs"`
+	PreservedRunTimes int            `json:"preserved_run_times"`
+	PreservedWaves    int            `json:"preserved_waves"`
+}
+
+// Rebuild reconstructs spec.db from the spec directory. The new database is
+// written to spec.db.rebuild first; unless opts.Shadow is set, the existing
+// spec.db (with its WAL sidecars) is moved to spec.db.bak and the rebuilt file
+// takes its place.
+//
+// spec_meta, the completion summary, the events journal, superseded
+// artifact versions, runs collected by `oraculo gc`, run and handoff
+// timestamps and explicitly set wave states have no filesystem source, so
+// they are copied from the existing database when it can still be read.
+func Rebuild(specDir string, opts RebuildOptions) (*RebuildReport, error) {
+	dbPath := filepath.Join(specDir, specdir.SpecDB)
+	tmpPath := dbPath + rebuildSuffix
+	if err := removeDB(tmpPath); err != nil {
+		return nil, err
+	}
+
+	ns, err := store.OpenAt(specDir, tmpPath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RebuildReport{Shadow: opts.Shadow}
+	report.Harvest = HarvestAll(ns, specDir)
+
+	if specdir.FileExists(dbPath) {
+		carry, err := readCarryOver(specDir)
+		if err != nil {
+			report.Harvest.Errors = append(report.Harvest.Errors, fmt.Sprintf("carry over from old spec.db: %s", err))
+		}
+		if err := carry.apply(ns, report); err != nil {
+			ns.Close()
+			return nil, err
+		}
+	}
+
+	report.Counts, err = ns.TableCounts()
+	if err != nil {
+		ns.Close()
+		return nil, err
+	}
+	// Closing the last connection checkpoints the WAL into the main file.
+	if err := ns.Close(); err != nil {
+		return nil, fmt.Errorf("reconcile: close rebuilt db: %w", err)
+	}
+
+	if opts.Shadow {
+		report.DBPath = tmpPath
+		return report, nil
+	}
+
+	if specdir.FileExists(dbPath) {
+		backup := dbPath + backupSuffix
+		if err := removeDB(backup); err != nil {
+			return nil, err
+		}
+		if err := moveDB(dbPath, backup); err != nil {
+			return nil, err
+		}
+		report.BackupPath = backup
+	}
+	if err := moveDB(tmpPath, dbPath); err != nil {
+		return nil, err
+	}
+	report.DBPath = dbPath
+	return report, nil
+}
+
+// carryOver is the state that has no filesystem source and must be copied
+// from the previous database: spec_meta, the completion summary, the events
+// journal, the artifact version history, collected runs, run timestamps and
+// wave states set by a transition.
+type carryOver struct {
+	meta     map[string]string
+	summary  *store.CompletionRecord
+	events   []store.Event
+	versions []store.ArtifactVersion
+	runs     []store.CollectedRun
+	times    []store.RunTimes
+	waves    []store.WaveRecord
+}
+
+// readCarryOver reads the carry-over state from the current spec.db without
+// migrating it. Whatever was read before an error is still returned.
+func readCarryOver(specDir string) (carryOver, error) {
+	var c carryOver
+	old, err := store.OpenNoMigrate(specDir)
+	if err != nil {
+		return c, err
+	}
+	defer old.Close()
+
+	if c.meta, err = old.ListMeta(); err != nil {
+		return c, err
+	}
+	if c.summary, err = old.GetCompletionSummary(); err != nil {
+		return c, err
+	}
+	// Databases from before the journal, version and retention migrations
+	// lack these tables and columns.
+	if ok, err := old.HasTable("events"); err != nil {
+		return c, err
+	} else if ok {
+		if c.events, err = old.ListEvents(store.EventFilter{}); err != nil {
+			return c, err
+		}
+	}
+	if ok, err := old.HasTable("artifact_versions"); err != nil {
+		return c, err
+	} else if ok {
+		if c.versions, err = old.AllArtifactVersions(); err != nil {
+			return c, err
+		}
+	}
+	if ok, err := old.HasColumn("runs", "collected"); err != nil {
+		return c, err
+	} else if ok {
+		if c.runs, err = old.ListCollectedRuns(); err != nil {
+			return c, err
+		}
+		if c.times, err = old.ListRunTimes(); err != nil {
+			return c, err
+		}
+	}
+	if ok, err := old.HasColumn("waves", "state_set_at"); err != nil || !ok {
+		return c, err
+	}
+	waves, err := old.ListWaves()
+	for _, w := range waves {
+		if w.StateSetAt != "" {
+			c.waves = append(c.waves, w)
+		}
+	}
+	return c, err
+}
+
+// apply writes the carried-over state into ns and records it in report.
+func (c carryOver) apply(ns *store.SpecStore, report *RebuildReport) error {
+	for k, v := range c.meta {
+		if err := ns.SetMeta(k, v); err != nil {
+			return err
+		}
+		report.PreservedMeta++
+	}
+	if c.summary != nil {
+		if err := ns.SaveCompletionSummary(c.summary.Frontmatter, c.summary.Body); err != nil {
+			return err
+		}
+		report.PreservedSummary = true
+	}
+	for _, e := range c.events {
+		if err := ns.RecordEvent(e); err != nil {
+			return err
+		}
+		report.PreservedEvents++
+	}
+	if len(c.versions) > 0 {
+		if err := ns.RestoreArtifactVersions(c.versions); err != nil {
+			return err
+		}
+		report.PreservedVersions = len(c.versions)
+	}
+	for _, run := range c.runs {
+		restored, err := ns.RestoreCollectedRun(run)
+		if err != nil {
+			return err
+		}
+		if restored {
+			report.PreservedRuns++
+		}
+	}
+	for _, t := range c.times {
+		restored, err := ns.RestoreRunTimes(t)
+		if err != nil {
+			return err
+		}
+		if restored {
+			report.PreservedRunTimes++
+		}
+	}
+	for _, w := range c.waves {
+		if err := ns.SetWaveState(w.WaveNumber, w.Status, w.StateSetAt); err != nil {
+			return err
+		}
+		report.PreservedWaves++
+	}
+	return nil
+}
+
+// moveDB renames a database together with any WAL sidecars.
+func moveDB(from, to string) error {
+	for _, suffix := range sqliteSidecars {
+		src := from + suffix
+		if !specdir.FileExists(src) {
+			continue
+		}
+		if err := os.Rename(src, to+suffix); err != nil {
+			return fmt.Errorf("reconcile: move %s: %w", filepath.Base(src), err)
+		}
+	}
+	return nil
+}
+
+// removeDB deletes a database and its WAL sidecars if present.
+func removeDB(path string) error {
+	for _, suffix := range sqliteSidecars {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("reconcile: remove %s: %w", filepath.Base(path+suffix), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,667 @@
+package reconcile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+// --- helpers ---
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const sampleTasks = `---
+spec: sample
+task_ids: [1, 2]
+---
+
+# Tasks: sample
+
+## Wave Plan
+
+- Wave 1: Tasks 1, 2
+
+## Tasks
+
+- [x] 1 First task
+  Wave: 1
+  Depends On: none
+  Files: ` + "`a.go`" + `
+
+- [ ] 2 Second task
+  Wave: 1
+  Depends On: Task 1
+  Files: ` + "`b.go`" + `
+`
+
+// makeSpec builds a spec directory with one discover run, one wave with an
+// exec and checkpoint run, a design artifact, an impl log and tasks.md.
+func makeSpec(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "discover", "_comms", "run-001", "researcher", "brief.md"), "brief")
+	writeFile(t, filepath.Join(dir, "discover", "_comms", "run-001", "researcher", "status.json"), `{"status":"pass","summary":"ok"}`)
+	writeFile(t, filepath.Join(dir, "design", "design.md"), "# Design")
+
+	wave := filepath.Join(dir, "execution", "waves", "wave-01")
+	writeFile(t, filepath.Join(wave, "execution", "run-001", "task-implementer", "status.json"), `{"status":"pass","summary":"done"}`)
+	writeFile(t, filepath.Join(wave, "checkpoint", "run-001", "release-gate-decider", "status.json"), `{"status":"pass","summary":"gate ok"}`)
+
+	writeFile(t, filepath.Join(dir, specdir.ImplLogsDir, "task-1.md"), "# Task 1 log")
+	writeFile(t, specdir.TasksPath(dir), sampleTasks)
+	return dir
+}
+
+// --- tests ---
+
+func TestInferCommandFromPath(t *testing.T) {
+	cases := map[string]string{
+		"/s/discover/_comms/run-001":                    "discover",
+		"/s/post-mortem/_comms/run-002":                 "post-mortem",
+		"/s/design/_comms/design-research/run-001":      "design-research",
+		"/s/planning/_comms/tasks-check/run-003":        "tasks-check",
+		"/s/execution/waves/wave-01/execution/run-001":  "exec",
+		"/s/execution/waves/wave-01/checkpoint/run-001": "checkpoint",
+		"/s/qa/_comms/qa-exec/waves/wave-02/run-001":    "qa-exec",
+		"/s/other/run-001":                              "unknown",
+	}
+	for path, want := range cases {
+		if got := InferCommandFromPath(path); got != want {
+			t.Errorf("InferCommandFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestHarvestAll(t *testing.T) {
+	dir := makeSpec(t)
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	r := HarvestAll(s, dir)
+	if len(r.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", r.Errors)
+	}
+	if r.Runs != 3 || r.ImplLogs != 1 || r.Tasks != 2 || r.Waves != 1 {
+		t.Fatalf("unexpected report: %+v", r)
+	}
+
+	run, err := s.LatestRun("discover")
+	if err != nil || run == nil {
+		t.Fatalf("discover run not harvested: %v", err)
+	}
+
+	w, err := s.GetWave(1)
+	if err != nil || w == nil {
+		t.Fatalf("wave 1 not synced: %v", err)
+	}
+	if w.Status != "passed" || w.ExecRuns != 1 || w.CheckRuns != 1 {
+		t.Fatalf("unexpected wave record: %+v", w)
+	}
+
+	// A second pass must be a no-op on row counts.
+	before, _ := s.TableCounts()
+	HarvestAll(s, dir)
+	after, _ := s.TableCounts()
+	for table, n := range before {
+		if after[table] != n {
+			t.Errorf("%s: %d rows after re-harvest, want %d", table, after[table], n)
+		}
+	}
+}
+
+func TestSyncTasksKeepsRequirements(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, specdir.TasksPath(dir), `## Tasks
+
+- [ ] 1 First task
+  - Wave: 1
+  - _Requirements: REQ-001, REQ-002.1_
+`)
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	if _, err := SyncTasks(s, dir); err != nil {
+		t.Fatalf("SyncTasks: %v", err)
+	}
+	list, err := s.ListTasks()
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListTasks = %+v, %v; want one task", list, err)
+	}
+	if got := list[0].Meta["Requirements"]; got != "REQ-001, REQ-002.1" {
+		t.Errorf("stored requirements = %q, want %q", got, "REQ-001, REQ-002.1")
+	}
+}
+
+func TestRebuildReplacesDBAndKeepsMeta(t *testing.T) {
+	dir := makeSpec(t)
+
+	old, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	old.SetMeta("stage", "execution")
+	old.SaveCompletionSummary("spec: sample", "done")
+	old.RecordEvent(store.Event{Command: "task-mark", TaskID: "1", Actor: "alice"})
+	// Stale row with no filesystem source; rebuild must drop it.
+	old.SyncTask(store.TaskRecord{TaskID: "99", Title: "ghost", Status: "pending"})
+	old.Close()
+
+	report, err := Rebuild(dir, RebuildOptions{})
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if report.PreservedMeta != 1 || !report.PreservedSummary || report.PreservedEvents != 1 {
+		t.Fatalf("carry-over not reported: %+v", report)
+	}
+	if !specdir.FileExists(filepath.Join(dir, "spec.db.bak")) {
+		t.Fatal("expected spec.db.bak backup")
+	}
+	if specdir.FileExists(filepath.Join(dir, "spec.db.rebuild")) {
+		t.Fatal("spec.db.rebuild should have been moved into place")
+	}
+
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open rebuilt: %v", err)
+	}
+	defer s.Close()
+
+	if stage, _ := s.GetMeta("stage"); stage != "execution" {
+		t.Errorf("stage = %q, want execution", stage)
+	}
+	if cs, _ := s.GetCompletionSummary(); cs == nil || cs.Body != "done" {
+		t.Errorf("completion summary not preserved: %+v", cs)
+	}
+	if events, _ := s.ListEvents(store.EventFilter{}); len(events) != 1 || events[0].Actor != "alice" {
+		t.Errorf("events not preserved: %+v", events)
+	}
+	list, _ := s.ListTasks()
+	if len(list) != 2 {
+		t.Fatalf("expected 2 tasks after rebuild, got %d", len(list))
+	}
+	if report.Counts["tasks"] != 2 || report.Counts["runs"] != 3 {
+		t.Errorf("unexpected counts: %v", report.Counts)
+	}
+}
+
+func TestRebuildKeepsArtifactHistory(t *testing.T) {
+	dir := makeSpec(t)
+	design := filepath.Join(dir, "design", "design.md")
+
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	HarvestFiles(s, dir)
+	writeFile(t, design, "# Design v2")
+	HarvestFiles(s, dir)
+	s.Close()
+
+	writeFile(t, design, "# Design v3")
+	report, err := Rebuild(dir, RebuildOptions{})
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if report.PreservedVersions == 0 {
+		t.Fatalf("versions not carried over: %+v", report)
+	}
+
+	s, err = store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open rebuilt: %v", err)
+	}
+	defer s.Close()
+	versions, err := s.ListArtifactVersions("design/design.md")
+	if err != nil || len(versions) != 3 {
+		t.Fatalf("expected 3 versions of design.md, got %+v (%v)", versions, err)
+	}
+	if v1, _ := s.GetArtifactVersion("design/design.md", 1); v1 == nil || v1.Content != "# Design" {
+		t.Errorf("first version lost: %+v", v1)
+	}
+}
+
+func TestRebuildKeepsRunTimes(t *testing.T) {
+	dir := makeSpec(t)
+
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	HarvestFiles(s, dir)
+	times, err := s.ListRunTimes()
+	if err != nil || len(times) == 0 {
+		t.Fatalf("ListRunTimes = %v, %v", times, err)
+	}
+	const past = "2026-01-01T00:00:00Z"
+	for _, rt := range times {
+		rt.CreatedAt, rt.UpdatedAt = past, past
+		for i := range rt.Handoffs {
+			rt.Handoffs[i].CreatedAt = past
+		}
+		if ok, err := s.RestoreRunTimes(rt); err != nil || !ok {
+			t.Fatalf("RestoreRunTimes = %v, %v", ok, err)
+		}
+	}
+	s.Close()
+
+	report, err := Rebuild(dir, RebuildOptions{})
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if report.PreservedRunTimes != len(times) {
+		t.Errorf("preserved run times = %d, want %d", report.PreservedRunTimes, len(times))
+	}
+
+	s, err = store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open rebuilt: %v", err)
+	}
+	defer s.Close()
+	got, _ := s.ListRunTimes()
+	for _, rt := range got {
+		if rt.CreatedAt != past || rt.UpdatedAt != past {
+			t.Errorf("run %s %d: created %s, updated %s", rt.Command, rt.RunNumber, rt.CreatedAt, rt.UpdatedAt)
+		}
+		for _, h := range rt.Handoffs {
+			if h.CreatedAt != past {
+				t.Errorf("run %s %d: handoff created %s", rt.Command, rt.RunNumber, h.CreatedAt)
+			}
+		}
+	}
+}
+
+func TestRebuildShadowLeavesDBInPlace(t *testing.T) {
+	dir := makeSpec(t)
+	old, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	old.Close()
+
+	report, err := Rebuild(dir, RebuildOptions{Shadow: true})
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if report.DBPath != filepath.Join(dir, "spec.db.rebuild") {
+		t.Fatalf("DBPath = %q", report.DBPath)
+	}
+	if report.BackupPath != "" || specdir.FileExists(filepath.Join(dir, "spec.db.bak")) {
+		t.Fatal("shadow rebuild must not back up spec.db")
+	}
+
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+	list, _ := s.ListTasks()
+	if len(list) != 0 {
+		t.Fatalf("original spec.db was modified: %d tasks", len(list))
+	}
+}
+
+func findingSet(r *FsckReport) map[string]bool {
+	set := make(map[string]bool)
+	for _, f := range r.Findings {
+		set[f.Kind+" "+f.Entity+" "+f.Key] = true
+	}
+	return set
+}
+
+func TestFsckCleanAfterHarvest(t *testing.T) {
+	dir := makeSpec(t)
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+	HarvestAll(s, dir)
+
+	r, err := Fsck(s, dir)
+	if err != nil {
+		t.Fatalf("Fsck: %v", err)
+	}
+	if !r.Clean() {
+		t.Fatalf("expected clean report, got %+v", r.Findings)
+	}
+	if r.Checked[EntityRun] != 3 || r.Checked[EntityTask] != 2 || r.Checked[EntityImplLog] != 1 {
+		t.Fatalf("unexpected checked counts: %v", r.Checked)
+	}
+}
+
+func TestFsckDetectsAndRepairsDrift(t *testing.T) {
+	dir := makeSpec(t)
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+	HarvestAll(s, dir)
+
+	// missing: new run and impl log on disk.
+	writeFile(t, filepath.Join(dir, "discover", "_comms", "run-002", "researcher", "status.json"), `{"status":"pass"}`)
+	writeFile(t, filepath.Join(dir, specdir.ImplLogsDir, "task-2.md"), "# Task 2 log")
+	// mismatch: artifact and subagent status.json edited, task checkbox flipped in db.
+	writeFile(t, filepath.Join(dir, "design", "design.md"), "# Design v2")
+	writeFile(t, filepath.Join(dir, "discover", "_comms", "run-001", "researcher", "status.json"), `{"status":"blocked"}`)
+	s.SyncTask(store.TaskRecord{TaskID: "1", Title: "First task", Status: "pending"})
+	// extra: rows with no file behind them.
+	s.SyncTask(store.TaskRecord{TaskID: "9", Title: "ghost", Status: "pending"})
+	os.Remove(filepath.Join(dir, specdir.ImplLogsDir, "task-1.md"))
+
+	r, err := Fsck(s, dir)
+	if err != nil {
+		t.Fatalf("Fsck: %v", err)
+	}
+	got := findingSet(r)
+	for _, want := range []string{
+		"missing run discover/_comms/run-002",
+		"missing impl_log 2",
+		"mismatch artifact design/design.md",
+		"mismatch subagent discover/_comms/run-001/researcher",
+		"mismatch task 1",
+		"extra task 9",
+		"extra impl_log 1",
+		// Impl logs live under execution/, so they are also phase artifacts.
+		"missing artifact execution/_implementation-logs/task-2.md",
+		"extra artifact execution/_implementation-logs/task-1.md",
+	} {
+		if !got[want] {
+			t.Errorf("missing finding %q; got %v", want, got)
+		}
+	}
+	if len(r.Findings) != 9 {
+		t.Errorf("expected 9 findings, got %d: %v", len(r.Findings), got)
+	}
+
+	if err := Repair(s, dir, r); err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+	if n := len(r.Drifted()); n != 0 {
+		t.Fatalf("%d findings left unrepaired: %+v (errors %v)", n, r.Drifted(), r.Errors)
+	}
+
+	again, err := Fsck(s, dir)
+	if err != nil {
+		t.Fatalf("Fsck after repair: %v", err)
+	}
+	if !again.Clean() {
+		t.Fatalf("drift after repair: %+v", again.Findings)
+	}
+}
+
+func TestFsckRepairKeepsDispatchedRunRow(t *testing.T) {
+	dir := makeSpec(t)
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+	HarvestAll(s, dir)
+
+	// dispatch-init registers a run before any subagent reports back,
+	// under a command name the path alone cannot recover.
+	rel := filepath.Join("design", "_comms", "design-draft", "run-001")
+	writeFile(t, filepath.Join(dir, rel, "writer", "brief.md"), "draft")
+	s.CreateRun("design-draft-custom", 1, "design", nil, rel)
+
+	r, _ := Fsck(s, dir)
+	if !findingSet(r)["missing subagent "+filepath.Join(rel, "writer")] {
+		t.Fatalf("expected missing subagent, got %+v", r.Findings)
+	}
+	Repair(s, dir, r)
+
+	runs, _ := s.ListRuns()
+	var matches int
+	for _, run := range runs {
+		if run.CommsPath == rel {
+			matches++
+			if run.Command != "design-draft-custom" {
+				t.Errorf("repair re-keyed run to %q", run.Command)
+			}
+		}
+	}
+	if matches != 1 {
+		t.Fatalf("expected one run row for %s, got %d", rel, matches)
+	}
+}
+
+func TestIndexSpecIncremental(t *testing.T) {
+	dir := makeSpec(t)
+	name := filepath.Base(dir)
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".spec-workflow"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ix, err := store.OpenIndex(root)
+	if err != nil {
+		t.Fatalf("OpenIndex: %v", err)
+	}
+	defer ix.Close()
+
+	// A document written by finalizar has no path and must survive re-indexing.
+	if err := ix.IndexDocument(name, "completion", "complete", "Completion Summary", "", "wrap-up notes"); err != nil {
+		t.Fatal(err)
+	}
+
+	r := IndexSpec(ix, dir)
+	if len(r.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", r.Errors)
+	}
+	// tasks.md, design/design.md and the impl log.
+	if r.Indexed != 3 || r.Unchanged != 0 || r.Deleted != 0 {
+		t.Fatalf("first pass: %+v", r)
+	}
+	if r.Stage != "execution" {
+		t.Errorf("stage = %q, want execution", r.Stage)
+	}
+
+	r = IndexSpec(ix, dir)
+	if r.Indexed != 0 || r.Unchanged != 3 || r.Deleted != 0 {
+		t.Fatalf("second pass should be a no-op: %+v", r)
+	}
+
+	writeFile(t, filepath.Join(dir, specdir.ImplLogsDir, "task-1.md"), "# Task 1 log\n\nAdded the quokka adapter.")
+	if err := os.Remove(filepath.Join(dir, "design", "design.md")); err != nil {
+		t.Fatal(err)
+	}
+	r = IndexSpec(ix, dir)
+	if r.Indexed != 1 || r.Unchanged != 1 || r.Deleted != 1 {
+		t.Fatalf("third pass: %+v", r)
+	}
+
+	results, err := ix.Search("quokka", name, 5)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Search(quokka) = %v, %v", results, err)
+	}
+	if results[0].DocType != DocImplLog || results[0].Title != "Task 1 log" {
+		t.Errorf("unexpected result: %+v", results[0])
+	}
+	if results, _ := ix.Search("wrap-up", name, 5); len(results) != 1 {
+		t.Errorf("completion document was removed: %v", results)
+	}
+}
+
+func TestIndexSpecRecordsVersions(t *testing.T) {
+	dir := makeSpec(t)
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".spec-workflow"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ix, err := store.OpenIndex(root)
+	if err != nil {
+		t.Fatalf("OpenIndex: %v", err)
+	}
+	defer ix.Close()
+
+	// Without spec.db, indexing must not create one.
+	if r := IndexSpec(ix, dir); r.Versioned != 0 {
+		t.Errorf("versioned without spec.db = %d", r.Versioned)
+	}
+	if specdir.FileExists(filepath.Join(dir, specdir.SpecDB)) {
+		t.Fatal("IndexSpec created spec.db")
+	}
+
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	designPath := filepath.Join(dir, "design", "design.md")
+	for i, content := range []string{"# Design", "# Design\n\nv2", "# Design\n\nv2"} {
+		writeFile(t, designPath, content)
+		r := IndexSpec(ix, dir)
+		if len(r.Errors) > 0 {
+			t.Fatalf("pass %d: %v", i, r.Errors)
+		}
+		// The first pass versions every artifact, later ones only changes.
+		switch {
+		case i == 0 && r.Versioned == 0:
+			t.Errorf("pass 0: nothing versioned")
+		case i > 0 && r.Versioned != []int{0, 1, 0}[i]:
+			t.Errorf("pass %d: versioned = %d, want %d", i, r.Versioned, []int{0, 1, 0}[i])
+		}
+	}
+
+	s, err = store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	versions, err := s.ListArtifactVersions("design/design.md")
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("versions = %v, %v; want 2", versions, err)
+	}
+}
+
+func TestDocSnippetSkipsFrontmatter(t *testing.T) {
+	content := "---\nspec: x\n---\n\n# Title\n\nFirst line.\nSecond line.\n"
+	if got := docTitle(content, "a.md"); got != "Title" {
+		t.Errorf("docTitle = %q", got)
+	}
+	if got := docSnippet(content); got != "First line. Second line." {
+		t.Errorf("docSnippet = %q", got)
+	}
+	if got := docTitle("no heading", "a.md"); got != "a.md" {
+		t.Errorf("docTitle fallback = %q", got)
+	}
+}
+
+func TestGCCompressesUnreferencedRuns(t *testing.T) {
+	dir := makeSpec(t)
+	writeFile(t, filepath.Join(dir, "discover", "_comms", "run-002", "researcher", "brief.md"), "again")
+	wave := filepath.Join(dir, "execution", "waves", "wave-01")
+	writeFile(t, filepath.Join(wave, "execution", "run-002", "task-implementer", "brief.md"), "retry")
+	writeFile(t, filepath.Join(wave, "_latest.json"), `{"status":"pass","execution":"run-001","checkpoint":"run-001"}`)
+
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	HarvestAll(s, dir)
+
+	collected := filepath.Join("discover", "_comms", "run-001")
+	dry := GC(s, dir, GCOptions{DryRun: true})
+	if len(dry.Errors) > 0 {
+		t.Fatalf("dry run errors: %v", dry.Errors)
+	}
+	if len(dry.Collected) != 1 || dry.Collected[0].Path != filepath.ToSlash(collected) {
+		t.Fatalf("dry run collected %+v, kept %+v", dry.Collected, dry.Kept)
+	}
+	reasons := make(map[string]string)
+	for _, k := range dry.Kept {
+		reasons[k.Path] = k.Reason
+	}
+	if got := reasons["execution/waves/wave-01/execution/run-001"]; got != "referenced by execution/waves/wave-01/_latest.json" {
+		t.Errorf("referenced exec run kept for %q", got)
+	}
+	if !specdir.DirExists(filepath.Join(dir, collected)) {
+		t.Fatal("dry run removed a run dir")
+	}
+
+	r := GC(s, dir, GCOptions{})
+	if len(r.Errors) > 0 || len(r.Collected) != 1 {
+		t.Fatalf("gc report: %+v", r)
+	}
+	if specdir.DirExists(filepath.Join(dir, collected)) {
+		t.Error("run dir still on disk")
+	}
+	if !specdir.FileExists(filepath.Join(dir, collected+".tar.gz")) {
+		t.Error("archive not written")
+	}
+	if run, _ := s.GetRun("discover", 1); run == nil || run.Collected != store.CollectedCompressed {
+		t.Fatalf("run row after gc = %+v", run)
+	}
+	if fr, _ := Fsck(s, dir); !fr.Clean() {
+		t.Errorf("fsck after gc: %+v", fr.Findings)
+	}
+	s.Close()
+
+	report, err := Rebuild(dir, RebuildOptions{})
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if report.PreservedRuns != 1 {
+		t.Errorf("PreservedRuns = %d, want 1", report.PreservedRuns)
+	}
+	ns, err := store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ns.Close()
+	run, _ := ns.GetRun("discover", 1)
+	if run == nil || run.Collected != store.CollectedCompressed {
+		t.Fatalf("collected run after rebuild = %+v", run)
+	}
+	if subs, _ := ns.ListSubagents(run.ID); len(subs) != 1 || subs[0].Brief != "brief" {
+		t.Errorf("subagents after rebuild = %+v", subs)
+	}
+}
+
+func TestGCDeleteKeepsUnharvestedFiles(t *testing.T) {
+	dir := makeSpec(t)
+	writeFile(t, filepath.Join(dir, "discover", "_comms", "run-001", "_inline-audit", "notes.md"), "notes")
+	writeFile(t, filepath.Join(dir, "discover", "_comms", "run-002", "researcher", "brief.md"), "again")
+
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+	HarvestAll(s, dir)
+
+	r := GC(s, dir, GCOptions{Action: GCDelete, DryRun: true})
+	if len(r.Collected) != 0 {
+		t.Fatalf("collected %+v", r.Collected)
+	}
+	for _, k := range r.Kept {
+		if k.Path == "discover/_comms/run-001" && k.Reason != "has unharvested files: _inline-audit" {
+			t.Errorf("reason = %q", k.Reason)
+		}
+	}
+
+	r = GC(s, dir, GCOptions{DryRun: true, MinAge: time.Hour})
+	if len(r.Collected) != 0 {
+		t.Errorf("min age ignored: collected %+v", r.Collected)
+	}
+}
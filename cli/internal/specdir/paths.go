@@ -41,6 +41,9 @@ const (
 	WaveCheckpointDir = "checkpoint"
 	WaveSummaryJSON   = "_wave-summary.json"
 	LatestJSON        = "_latest.json"
+	// ArchivedDir holds the run dirs set aside by a wave rollback, inside
+	// the wave's execution/ and checkpoint/ subdirs.
+	ArchivedDir = "_archived"
 )
 
 // Subagent handoff structure (inside any run-NNN/).
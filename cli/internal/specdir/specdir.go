@@ -80,8 +80,17 @@ func ReadStatusJSON(path string) (StatusDoc, error) {
 
 // StatusDoc represents a subagent status.json file.
 type StatusDoc struct {
-	Status  string `json:"status"`
-	Summary string `json:"summary"`
+	Status   string    `json:"status"`
+	Summary  string    `json:"summary"`
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// Finding is one issue a reviewing subagent lists in its status.json. It is
+// open unless its status is "resolved".
+type Finding struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary,omitempty"`
+	Status   string `json:"status,omitempty"`
 }
 
 // LatestDoc represents a _latest.json file that points to the latest run.
@@ -138,6 +147,40 @@ func LatestRunDir(dir string) (string, int, error) {
 	return filepath.Join(dir, maxName), maxNum, nil
 }
 
+// SpecDirFromPath returns the spec directory that contains path, i.e. the
+// ancestor directly below .spec-workflow/specs. It returns "" when path is
+// not inside a spec.
+func SpecDirFromPath(path string) string {
+	dir := filepath.Clean(path)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		if filepath.Base(parent) == "specs" && filepath.Base(filepath.Dir(parent)) == ".spec-workflow" {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// WaveNumFromPath returns the number of the innermost wave-NN segment in
+// path, or 0 if there is none.
+func WaveNumFromPath(path string) int {
+	dir := filepath.Clean(path)
+	for {
+		if m := waveNumRe.FindStringSubmatch(filepath.Base(dir)); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			return n
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return 0
+		}
+		dir = parent
+	}
+}
+
 // FileExists checks if a file exists (not a directory).
 func FileExists(path string) bool {
 	info, err := os.Stat(path)
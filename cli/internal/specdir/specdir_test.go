@@ -188,6 +188,35 @@ func TestResolveMissing(t *testing.T) {
 	}
 }
 
+func TestSpecDirFromPath(t *testing.T) {
+	spec := filepath.Join("/repo", ".spec-workflow", "specs", "my-spec")
+	cases := map[string]string{
+		filepath.Join(spec, "execution", "waves", "wave-01", "checkpoint", "run-002"): spec,
+		spec: spec,
+		filepath.Join("/repo", ".spec-workflow", "specs"): "",
+		filepath.Join("/repo", "src", "main.go"):          "",
+	}
+	for path, want := range cases {
+		if got := SpecDirFromPath(path); got != want {
+			t.Errorf("SpecDirFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestWaveNumFromPath(t *testing.T) {
+	cases := map[string]int{
+		"/s/execution/waves/wave-03/execution/run-001": 3,
+		"/s/qa/_comms/qa-exec/waves/wave-12/run-001":   12,
+		"/s/discover/_comms/run-001":                   0,
+		"/s/wave-notes/run-001":                        0,
+	}
+	for path, want := range cases {
+		if got := WaveNumFromPath(path); got != want {
+			t.Errorf("WaveNumFromPath(%q) = %d, want %d", path, got, want)
+		}
+	}
+}
+
 func writeJSON(t *testing.T, path string, v any) {
 	t.Helper()
 	data, err := json.Marshal(v)
@@ -0,0 +1,329 @@
+// Package stats computes delivery metrics for specs from their spec.db:
+// cycle time per phase, runs per wave, checkpoint outcomes, audit retries
+// and wave sizes.
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+// phaseOrder is the order phases are reported in; unknown phases follow.
+var phaseOrder = []string{"discover", "design", "planning", "execution", "qa", "post-mortem"}
+
+// Run commands counted per wave.
+const (
+	cmdExec       = "exec"
+	cmdCheckpoint = "checkpoint"
+)
+
+// PhaseCycle is the time a spec spent in a phase, measured from the first
+// run created in it to the last handoff recorded in it. A run without a
+// handoff counts as ending when it was created.
+type PhaseCycle struct {
+	Phase string  `json:"phase"`
+	Runs  int     `json:"runs"`
+	Start string  `json:"start"`
+	End   string  `json:"end"`
+	Hours float64 `json:"hours"`
+}
+
+// WaveStats describes one execution wave.
+type WaveStats struct {
+	Wave      int    `json:"wave"`
+	Status    string `json:"status,omitempty"`
+	Tasks     int    `json:"tasks"`
+	ExecRuns  int    `json:"exec_runs"`
+	CheckRuns int    `json:"checkpoint_runs"`
+	OverLimit bool   `json:"over_limit"`
+}
+
+// CheckpointStats counts checkpoint runs by outcome. PassRatio is
+// Pass / (Pass + Blocked), or 0 when no checkpoint has finished.
+type CheckpointStats struct {
+	Pass      int     `json:"pass"`
+	Blocked   int     `json:"blocked"`
+	Pending   int     `json:"pending"`
+	PassRatio float64 `json:"pass_ratio"`
+}
+
+// AuditStats counts inline audit retries recorded by
+// `oraculo tools audit-iteration advance`. Audits that passed on their first
+// iteration record no event and are not counted.
+type AuditStats struct {
+	RunsRetried  int `json:"runs_retried"`
+	Retries      int `json:"retries"`
+	MaxIteration int `json:"max_iteration"`
+}
+
+// SpecStats holds the metrics of one spec.
+type SpecStats struct {
+	Spec        string          `json:"spec"`
+	Stage       string          `json:"stage,omitempty"`
+	Phases      []PhaseCycle    `json:"phases"`
+	Waves       []WaveStats     `json:"waves"`
+	Checkpoints CheckpointStats `json:"checkpoints"`
+	Audits      AuditStats      `json:"audits"`
+	MaxWaveSize int             `json:"max_wave_size"`
+}
+
+// PhaseAggregate summarizes one phase across specs.
+type PhaseAggregate struct {
+	Phase       string  `json:"phase"`
+	Specs       int     `json:"specs"`
+	Runs        int     `json:"runs"`
+	MeanHours   float64 `json:"mean_hours"`
+	MedianHours float64 `json:"median_hours"`
+}
+
+// Aggregate summarizes several specs.
+type Aggregate struct {
+	Specs           int              `json:"specs"`
+	Phases          []PhaseAggregate `json:"phases"`
+	Waves           int              `json:"waves"`
+	ExecRunsPerWave float64          `json:"exec_runs_per_wave"`
+	TasksPerWave    float64          `json:"tasks_per_wave"`
+	WavesOverLimit  int              `json:"waves_over_limit"`
+	Checkpoints     CheckpointStats  `json:"checkpoints"`
+	Audits          AuditStats       `json:"audits"`
+}
+
+// ForSpec computes the metrics of the spec stored in s. maxWaveSize is the
+// configured [planning].max_wave_size; 0 disables the over-limit check.
+func ForSpec(s *store.SpecStore, name string, maxWaveSize int) (SpecStats, error) {
+	st := SpecStats{Spec: name, MaxWaveSize: maxWaveSize, Phases: []PhaseCycle{}, Waves: []WaveStats{}}
+	st.Stage, _ = s.GetMeta("stage")
+
+	runs, err := s.ListRuns()
+	if err != nil {
+		return st, err
+	}
+	waveRecs, err := s.ListWaves()
+	if err != nil {
+		return st, err
+	}
+	tasks, err := s.ListTasks()
+	if err != nil {
+		return st, err
+	}
+	audits, err := s.ListEvents(store.EventFilter{Command: "audit-iteration advance"})
+	if err != nil {
+		return st, err
+	}
+	handoffs, err := s.HandoffTimes()
+	if err != nil {
+		return st, err
+	}
+
+	st.Phases = phaseCycles(runs, handoffs)
+
+	waves := make(map[int]*WaveStats)
+	waveAt := func(n int) *WaveStats {
+		if w, ok := waves[n]; ok {
+			return w
+		}
+		w := &WaveStats{Wave: n}
+		waves[n] = w
+		return w
+	}
+	for _, w := range waveRecs {
+		waveAt(w.WaveNumber).Status = w.Status
+	}
+	for _, t := range tasks {
+		if t.Wave != nil && !t.IsDeferred {
+			waveAt(*t.Wave).Tasks++
+		}
+	}
+	for _, r := range runs {
+		if r.Command == cmdCheckpoint {
+			switch r.Status {
+			case "pass":
+				st.Checkpoints.Pass++
+			case "blocked":
+				st.Checkpoints.Blocked++
+			default:
+				st.Checkpoints.Pending++
+			}
+		}
+		if r.WaveNumber == nil {
+			continue
+		}
+		switch r.Command {
+		case cmdExec:
+			waveAt(*r.WaveNumber).ExecRuns++
+		case cmdCheckpoint:
+			waveAt(*r.WaveNumber).CheckRuns++
+		}
+	}
+	st.Checkpoints.PassRatio = passRatio(st.Checkpoints)
+
+	for _, w := range waves {
+		w.OverLimit = maxWaveSize > 0 && w.Tasks > maxWaveSize
+		st.Waves = append(st.Waves, *w)
+	}
+	sort.Slice(st.Waves, func(i, j int) bool { return st.Waves[i].Wave < st.Waves[j].Wave })
+
+	st.Audits = auditStats(audits)
+	return st, nil
+}
+
+// phaseCycles groups runs by phase and measures each phase's span.
+// handoffs maps run IDs to their last handoff time. Run updated_at is not
+// used: it moves whenever a run is touched, not when its work ends.
+func phaseCycles(runs []store.Run, handoffs map[int64]string) []PhaseCycle {
+	byPhase := make(map[string]*PhaseCycle)
+	first, last := make(map[string]time.Time), make(map[string]time.Time)
+	for _, r := range runs {
+		created, err := time.Parse(time.RFC3339, r.CreatedAt)
+		if err != nil {
+			continue
+		}
+		ended := created
+		if at, ok := handoffs[r.ID]; ok {
+			if t, err := time.Parse(time.RFC3339, at); err == nil && t.After(created) {
+				ended = t
+			}
+		}
+		pc, ok := byPhase[r.Phase]
+		if !ok {
+			pc = &PhaseCycle{Phase: r.Phase}
+			byPhase[r.Phase] = pc
+			first[r.Phase], last[r.Phase] = created, ended
+		}
+		pc.Runs++
+		if created.Before(first[r.Phase]) {
+			first[r.Phase] = created
+		}
+		if ended.After(last[r.Phase]) {
+			last[r.Phase] = ended
+		}
+	}
+
+	out := []PhaseCycle{}
+	for _, phase := range orderedPhases(byPhase) {
+		pc := byPhase[phase]
+		pc.Start = first[phase].UTC().Format(time.RFC3339)
+		pc.End = last[phase].UTC().Format(time.RFC3339)
+		pc.Hours = round1(last[phase].Sub(first[phase]).Hours())
+		out = append(out, *pc)
+	}
+	return out
+}
+
+// orderedPhases returns the keys of m in phaseOrder, then alphabetically.
+func orderedPhases[V any](m map[string]V) []string {
+	var out []string
+	known := make(map[string]bool)
+	for _, p := range phaseOrder {
+		known[p] = true
+		if _, ok := m[p]; ok {
+			out = append(out, p)
+		}
+	}
+	var rest []string
+	for p := range m {
+		if !known[p] {
+			rest = append(rest, p)
+		}
+	}
+	sort.Strings(rest)
+	return append(out, rest...)
+}
+
+// auditStats counts advance events per run dir.
+func auditStats(events []store.Event) AuditStats {
+	var a AuditStats
+	retried := make(map[string]bool)
+	for _, e := range events {
+		a.Retries++
+		if args, ok := e.Args.(map[string]any); ok {
+			if dir, ok := args["run_dir"].(string); ok {
+				retried[dir] = true
+			}
+		}
+		if after, ok := e.After.(map[string]any); ok {
+			if it, ok := after["iteration"].(float64); ok && int(it) > a.MaxIteration {
+				a.MaxIteration = int(it)
+			}
+		}
+	}
+	a.RunsRetried = len(retried)
+	return a
+}
+
+// Summarize aggregates per-spec metrics. Phase hours are averaged over the
+// specs that have runs in the phase.
+func Summarize(specs []SpecStats) Aggregate {
+	agg := Aggregate{Specs: len(specs), Phases: []PhaseAggregate{}}
+	hours := make(map[string][]float64)
+	runs := make(map[string]int)
+	var execRuns, tasks int
+	for _, sp := range specs {
+		for _, pc := range sp.Phases {
+			hours[pc.Phase] = append(hours[pc.Phase], pc.Hours)
+			runs[pc.Phase] += pc.Runs
+		}
+		for _, w := range sp.Waves {
+			agg.Waves++
+			execRuns += w.ExecRuns
+			tasks += w.Tasks
+			if w.OverLimit {
+				agg.WavesOverLimit++
+			}
+		}
+		agg.Checkpoints.Pass += sp.Checkpoints.Pass
+		agg.Checkpoints.Blocked += sp.Checkpoints.Blocked
+		agg.Checkpoints.Pending += sp.Checkpoints.Pending
+		agg.Audits.RunsRetried += sp.Audits.RunsRetried
+		agg.Audits.Retries += sp.Audits.Retries
+		agg.Audits.MaxIteration = max(agg.Audits.MaxIteration, sp.Audits.MaxIteration)
+	}
+	agg.Checkpoints.PassRatio = passRatio(agg.Checkpoints)
+	if agg.Waves > 0 {
+		agg.ExecRunsPerWave = round1(float64(execRuns) / float64(agg.Waves))
+		agg.TasksPerWave = round1(float64(tasks) / float64(agg.Waves))
+	}
+	for _, phase := range orderedPhases(hours) {
+		hs := hours[phase]
+		agg.Phases = append(agg.Phases, PhaseAggregate{
+			Phase:       phase,
+			Specs:       len(hs),
+			Runs:        runs[phase],
+			MeanHours:   round1(mean(hs)),
+			MedianHours: round1(median(hs)),
+		})
+	}
+	return agg
+}
+
+func passRatio(c CheckpointStats) float64 {
+	if done := c.Pass + c.Blocked; done > 0 {
+		return math.Round(float64(c.Pass)/float64(done)*100) / 100
+	}
+	return 0
+}
+
+func mean(xs []float64) float64 {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+func median(xs []float64) float64 {
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+func round1(x float64) float64 {
+	return math.Round(x*10) / 10
+}
@@ -0,0 +1,87 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestPhaseCycles(t *testing.T) {
+	// updated_at is ignored: a re-harvest or a move touches it long after
+	// the run's handoff.
+	runs := []store.Run{
+		{ID: 1, Phase: "execution", CreatedAt: "2026-01-02T10:00:00Z", UpdatedAt: "2026-03-01T00:00:00Z"},
+		{ID: 2, Phase: "discover", CreatedAt: "2026-01-01T08:00:00Z", UpdatedAt: "2026-03-01T00:00:00Z"},
+		{ID: 3, Phase: "execution", CreatedAt: "2026-01-02T09:00:00Z", UpdatedAt: "2026-03-01T00:00:00Z"},
+		{ID: 4, Phase: "custom", CreatedAt: "bad", UpdatedAt: "bad"},
+	}
+	handoffs := map[int64]string{
+		1: "2026-01-02T12:00:00Z",
+		2: "2026-01-01T09:30:00Z",
+		3: "2026-01-02T11:00:00Z",
+	}
+	got := phaseCycles(runs, handoffs)
+	if len(got) != 2 || got[0].Phase != "discover" || got[1].Phase != "execution" {
+		t.Fatalf("phases = %+v", got)
+	}
+	if got[0].Hours != 1.5 || got[1].Hours != 3 || got[1].Runs != 2 {
+		t.Errorf("cycles = %+v", got)
+	}
+}
+
+func TestForSpecAndSummarize(t *testing.T) {
+	s, err := store.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.CreateRun("exec", 1, "execution", intPtr(1), "execution/waves/wave-01/execution/run-001")
+	s.CreateRun("exec", 2, "execution", intPtr(1), "execution/waves/wave-01/execution/run-002")
+	pass, _ := s.CreateRun("checkpoint", 1, "execution", intPtr(1), "execution/waves/wave-01/checkpoint/run-001")
+	blocked, _ := s.CreateRun("checkpoint", 1, "execution", intPtr(2), "execution/waves/wave-02/checkpoint/run-001")
+	s.UpdateRunStatus(pass, "pass")
+	s.UpdateRunStatus(blocked, "blocked")
+	for _, id := range []string{"1", "2", "3", "4"} {
+		s.SyncTask(store.TaskRecord{TaskID: id, Title: "t" + id, Status: "pending", Wave: intPtr(1)})
+	}
+	s.SyncTask(store.TaskRecord{TaskID: "5", Title: "later", Status: "pending", Wave: intPtr(2), IsDeferred: true})
+	for i := 2; i <= 3; i++ {
+		s.RecordEvent(store.Event{
+			Command: "audit-iteration advance",
+			Args:    map[string]any{"run_dir": "planning/_comms/tasks-plan/run-001"},
+			After:   map[string]any{"iteration": i},
+		})
+	}
+
+	st, err := ForSpec(s, "alpha", 3)
+	if err != nil {
+		t.Fatalf("ForSpec: %v", err)
+	}
+	if len(st.Waves) != 2 {
+		t.Fatalf("waves = %+v", st.Waves)
+	}
+	w1, w2 := st.Waves[0], st.Waves[1]
+	if w1.ExecRuns != 2 || w1.CheckRuns != 1 || w1.Tasks != 4 || !w1.OverLimit {
+		t.Errorf("wave 1 = %+v", w1)
+	}
+	if w2.Tasks != 0 || w2.OverLimit {
+		t.Errorf("wave 2 = %+v (deferred tasks must not count)", w2)
+	}
+	if c := st.Checkpoints; c.Pass != 1 || c.Blocked != 1 || c.PassRatio != 0.5 {
+		t.Errorf("checkpoints = %+v", c)
+	}
+	if a := st.Audits; a.Retries != 2 || a.RunsRetried != 1 || a.MaxIteration != 3 {
+		t.Errorf("audits = %+v", a)
+	}
+
+	agg := Summarize([]SpecStats{st, st})
+	if agg.Specs != 2 || agg.Waves != 4 || agg.WavesOverLimit != 2 || agg.ExecRunsPerWave != 1 || agg.TasksPerWave != 2 {
+		t.Errorf("aggregate = %+v", agg)
+	}
+	if agg.Checkpoints.Pass != 2 || agg.Checkpoints.PassRatio != 0.5 {
+		t.Errorf("aggregate checkpoints = %+v", agg.Checkpoints)
+	}
+}
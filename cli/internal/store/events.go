@@ -0,0 +1,160 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CurrentActor returns who is running the command and in which session.
+// ORACULO_ACTOR and ORACULO_SESSION_ID take precedence over USER and
+// CLAUDE_SESSION_ID.
+func CurrentActor() (actor, session string) {
+	actor = firstEnv("ORACULO_ACTOR", "USER")
+	session = firstEnv("ORACULO_SESSION_ID", "CLAUDE_SESSION_ID")
+	return actor, session
+}
+
+func firstEnv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// RecordEvent appends an event to the journal. A new event (empty Timestamp)
+// is stamped with the current time and, unless given, CurrentActor. Events
+// with a Timestamp are copied as-is, which is how rebuild carries them over.
+func (s *SpecStore) RecordEvent(e Event) error {
+	return recordEvent(s.db, e)
+}
+
+func recordEvent(ex execer, e Event) error {
+	if e.Timestamp == "" {
+		e.Timestamp = now()
+		if e.Actor == "" && e.SessionID == "" {
+			e.Actor, e.SessionID = CurrentActor()
+		}
+	}
+
+	args, err := encodeEventJSON(e.Args)
+	if err != nil {
+		return fmt.Errorf("store: record event args: %w", err)
+	}
+	before, err := encodeEventJSON(e.Before)
+	if err != nil {
+		return fmt.Errorf("store: record event before: %w", err)
+	}
+	after, err := encodeEventJSON(e.After)
+	if err != nil {
+		return fmt.Errorf("store: record event after: %w", err)
+	}
+
+	_, err = ex.Exec(`
+		INSERT INTO events (ts, actor, session_id, command, wave, task_id, args, before, after)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Timestamp, nullStr(e.Actor), nullStr(e.SessionID), e.Command, e.Wave,
+		nullStr(e.TaskID), args, before, after,
+	)
+	if err != nil {
+		return fmt.Errorf("store: record event: %w", err)
+	}
+	return nil
+}
+
+// TryRecordEvent opens spec.db in specDir, appends e and closes it.
+// Like TryOpen it fails open: journaling never blocks the command itself.
+func TryRecordEvent(specDir string, e Event) {
+	if s := TryOpen(specDir); s != nil {
+		defer s.Close()
+		_ = s.RecordEvent(e)
+	}
+}
+
+// ListEvents returns journal entries matching f in chronological order.
+// With a Limit, the most recent Limit entries are returned.
+func (s *SpecStore) ListEvents(f EventFilter) ([]Event, error) {
+	var where []string
+	var args []any
+	if f.Wave != nil {
+		where = append(where, "wave = ?")
+		args = append(args, *f.Wave)
+	}
+	if f.TaskID != "" {
+		where = append(where, "task_id = ?")
+		args = append(args, f.TaskID)
+	}
+	if f.Command != "" {
+		where = append(where, "command = ?")
+		args = append(args, f.Command)
+	}
+	if f.Since != "" {
+		where = append(where, "ts >= ?")
+		args = append(args, f.Since)
+	}
+	if f.Until != "" {
+		where = append(where, "ts <= ?")
+		args = append(args, f.Until)
+	}
+
+	query := "SELECT id, ts, actor, session_id, command, wave, task_id, args, before, after FROM events"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if f.Limit > 0 {
+		// Take the newest rows, then restore chronological order.
+		query = "SELECT * FROM (" + query + " ORDER BY id DESC LIMIT ?) ORDER BY id"
+		args = append(args, f.Limit)
+	} else {
+		query += " ORDER BY id"
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("store: list events: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Event
+	for rows.Next() {
+		var e Event
+		var actor, session, taskID, argsJSON, beforeJSON, afterJSON sql.NullString
+		if err := rows.Scan(&e.ID, &e.Timestamp, &actor, &session, &e.Command, &e.Wave, &taskID, &argsJSON, &beforeJSON, &afterJSON); err != nil {
+			return nil, fmt.Errorf("store: scan event: %w", err)
+		}
+		e.Actor = actor.String
+		e.SessionID = session.String
+		e.TaskID = taskID.String
+		e.Args = decodeEventJSON(argsJSON.String)
+		e.Before = decodeEventJSON(beforeJSON.String)
+		e.After = decodeEventJSON(afterJSON.String)
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+func encodeEventJSON(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func decodeEventJSON(s string) any {
+	if s == "" {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	return v
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markers wrapped around matched terms in SearchResult highlights. They are
+// control characters so they cannot collide with markdown in the documents;
+// callers replace them with whatever their output format uses.
+const (
+	HighlightOpen  = "\x02"
+	HighlightClose = "\x03"
+)
+
+// ftsToken is one element of a parsed search query.
+type ftsToken struct {
+	kind  byte // 't' term, 'o' operator, '(' or ')'
+	value string
+}
+
+// ParseFTSQuery turns user input into a safe FTS5 MATCH expression.
+//
+//   - "exact phrase" stays a phrase
+//   - word* and "phrase"* become prefix queries
+//   - AND, OR and NOT (upper case) and parentheses are kept as operators
+//   - every other word is quoted, so hyphens, colons and other FTS5 syntax
+//     characters are matched literally
+//
+// Unbalanced parentheses are dropped. An operator without a term on both
+// sides is an error rather than being dropped: FTS5 has no unary NOT, and
+// quietly searching "draft" for "NOT draft" would return exactly what the
+// user meant to exclude. Terms next to each other are implicitly ANDed by
+// FTS5.
+func ParseFTSQuery(query string) (string, error) {
+	tokens := tokenizeFTS(query)
+	if !parensValid(tokens) {
+		tokens = dropParens(tokens)
+	}
+	if err := checkOperators(tokens); err != nil {
+		return "", err
+	}
+
+	var parts []string
+	hasTerm := false
+	for _, t := range tokens {
+		parts = append(parts, t.value)
+		if t.kind == 't' {
+			hasTerm = true
+		}
+	}
+	if !hasTerm {
+		return "", fmt.Errorf("store: search: empty query")
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func tokenizeFTS(query string) []ftsToken {
+	var tokens []ftsToken
+	rs := []rune(query)
+	for i := 0; i < len(rs); {
+		switch c := rs[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, ftsToken{kind: byte(c), value: string(c)})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(rs) && rs[j] != '"' {
+				j++
+			}
+			phrase := strings.TrimSpace(string(rs[i+1 : min(j, len(rs))]))
+			i = j + 1
+			prefix := false
+			for i < len(rs) && rs[i] == '*' {
+				prefix = true
+				i++
+			}
+			if phrase != "" {
+				tokens = append(tokens, ftsTerm(phrase, prefix))
+			}
+		default:
+			j := i
+			for j < len(rs) && !strings.ContainsRune(" \t\n\r()\"", rs[j]) {
+				j++
+			}
+			word := string(rs[i:j])
+			i = j
+			if word == "AND" || word == "OR" || word == "NOT" {
+				tokens = append(tokens, ftsToken{kind: 'o', value: word})
+				continue
+			}
+			trimmed := strings.TrimRight(word, "*")
+			if trimmed != "" {
+				tokens = append(tokens, ftsTerm(trimmed, trimmed != word))
+			}
+		}
+	}
+	return tokens
+}
+
+// ftsTerm quotes text as an FTS5 string, optionally as a prefix query.
+func ftsTerm(text string, prefix bool) ftsToken {
+	v := `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+	if prefix {
+		v += "*"
+	}
+	return ftsToken{kind: 't', value: v}
+}
+
+// parensValid reports whether parentheses are balanced and no group is
+// empty.
+func parensValid(tokens []ftsToken) bool {
+	depth := 0
+	for i, t := range tokens {
+		switch t.kind {
+		case '(':
+			depth++
+			if i+1 < len(tokens) && tokens[i+1].kind == ')' {
+				return false
+			}
+		case ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+func dropParens(tokens []ftsToken) []ftsToken {
+	out := tokens[:0:0]
+	for _, t := range tokens {
+		if t.kind != '(' && t.kind != ')' {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// checkOperators reports an operator that does not sit between two
+// operands: at the start or end, next to another operator, or just inside
+// a parenthesis.
+func checkOperators(tokens []ftsToken) error {
+	for i, t := range tokens {
+		if t.kind != 'o' {
+			continue
+		}
+		prevOK := i > 0 && (tokens[i-1].kind == 't' || tokens[i-1].kind == ')')
+		nextOK := i+1 < len(tokens) && (tokens[i+1].kind == 't' || tokens[i+1].kind == '(')
+		switch {
+		case t.value == "NOT" && !prevOK:
+			return fmt.Errorf(`store: search: NOT needs a term before it (there is no unary NOT; write "a NOT b")`)
+		case !prevOK || !nextOK:
+			return fmt.Errorf("store: search: %s needs a term on both sides", t.value)
+		}
+	}
+	return nil
+}
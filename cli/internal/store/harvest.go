@@ -2,6 +2,7 @@ package store
 
 import (
 	"crypto/sha256"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -14,7 +15,8 @@ var runDirRe = regexp.MustCompile(`^run-(\d+)$`)
 
 // HarvestRunDir scans a run directory for subagent dirs, reads their
 // brief.md, report.md, and status.json, and stores them in the database.
-// The entire operation runs in a single transaction.
+// The entire operation runs in a single transaction and may be repeated:
+// an existing run keeps its ID and has its subagents and handoff replaced.
 func (s *SpecStore) HarvestRunDir(runDir, command string, waveNum *int) error {
 	// Determine run number from directory name.
 	base := filepath.Base(runDir)
@@ -41,21 +43,50 @@ func (s *SpecStore) HarvestRunDir(runDir, command string, waveNum *int) error {
 
 	ts := now()
 
-	// Upsert run record.
-	res, err := tx.Exec(`
-		INSERT INTO runs (command, run_number, phase, wave_number, comms_path, status, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, 'in_progress', ?, ?)
-		ON CONFLICT(command, run_number, wave_number) DO UPDATE SET
-			status = runs.status,
-			updated_at = excluded.updated_at`,
-		command, runNumber, phase, waveNum, commsPath, ts, ts,
-	)
-	if err != nil {
-		return fmt.Errorf("store: harvest insert run: %w", err)
+	// Upsert run record. wave_number may be NULL, which the UNIQUE constraint
+	// does not dedupe, so look the run up explicitly.
+	var runID int64
+	var prevStatus, collected string
+	err = tx.QueryRow(
+		"SELECT id, status, COALESCE(collected, '') FROM runs WHERE command = ? AND run_number = ? AND wave_number IS ?",
+		command, runNumber, waveNum,
+	).Scan(&runID, &prevStatus, &collected)
+	switch {
+	case err == sql.ErrNoRows:
+		prevStatus = "in_progress"
+		res, err := tx.Exec(`
+			INSERT INTO runs (command, run_number, phase, wave_number, comms_path, status, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, 'in_progress', ?, ?)`,
+			command, runNumber, phase, waveNum, commsPath, ts, ts,
+		)
+		if err != nil {
+			return fmt.Errorf("store: harvest insert run: %w", err)
+		}
+		runID, err = res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("store: harvest run id: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("store: harvest lookup run: %w", err)
+	case collected != "":
+		// A collected run whose directory is back on disk is live again.
+		if _, err := tx.Exec("UPDATE runs SET updated_at = ?, collected = NULL, collected_at = NULL WHERE id = ?", ts, runID); err != nil {
+			return fmt.Errorf("store: harvest touch run: %w", err)
+		}
 	}
-	runID, err := res.LastInsertId()
+
+	// Re-harvesting replaces the run's subagents and handoffs so the
+	// operation stays idempotent. Rows whose content is unchanged keep their
+	// timestamps, which cycle times are measured from.
+	prev, err := harvestedTimes(tx, runID)
 	if err != nil {
-		return fmt.Errorf("store: harvest run id: %w", err)
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM handoffs WHERE run_id = ?", runID); err != nil {
+		return fmt.Errorf("store: harvest clear handoffs: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM subagents WHERE run_id = ?", runID); err != nil {
+		return fmt.Errorf("store: harvest clear subagents: %w", err)
 	}
 
 	// Scan for subagent directories (any subdirectory that is not a special dir).
@@ -68,7 +99,7 @@ func (s *SpecStore) HarvestRunDir(runDir, command string, waveNum *int) error {
 	hasSubagents := false
 
 	for _, e := range entries {
-		if !e.IsDir() || isSpecialDir(e.Name()) {
+		if !e.IsDir() || IsSpecialDir(e.Name()) {
 			continue
 		}
 		hasSubagents = true
@@ -94,13 +125,20 @@ func (s *SpecStore) HarvestRunDir(runDir, command string, waveNum *int) error {
 			allPass = false
 		}
 
+		created, updated := ts, ts
+		if old, ok := prev.subagents[e.Name()]; ok {
+			created = old.created
+			if old.content == subagentContent(brief, report, statusJSON) {
+				updated = old.updated
+			}
+		}
 		_, err := tx.Exec(`
 			INSERT INTO subagents (run_id, name, brief, report, status, summary, status_json, created_at, updated_at)
 			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			runID, e.Name(),
 			nullStrPtr(brief), nullStrPtr(report),
 			nullStrPtr(status), nullStrPtr(summary), nullStrPtr(statusJSON),
-			ts, ts,
+			created, updated,
 		)
 		if err != nil {
 			return fmt.Errorf("store: harvest insert subagent %s: %w", e.Name(), err)
@@ -114,9 +152,13 @@ func (s *SpecStore) HarvestRunDir(runDir, command string, waveNum *int) error {
 		if allPass && hasSubagents {
 			pass = 1
 		}
+		handoffAt := ts
+		if at, ok := prev.handoffs[handoffContent]; ok {
+			handoffAt = at
+		}
 		_, err := tx.Exec(
 			"INSERT INTO handoffs (run_id, content, all_pass, created_at) VALUES (?, ?, ?, ?)",
-			runID, handoffContent, pass, ts,
+			runID, handoffContent, pass, handoffAt,
 		)
 		if err != nil {
 			return fmt.Errorf("store: harvest insert handoff: %w", err)
@@ -127,28 +169,97 @@ func (s *SpecStore) HarvestRunDir(runDir, command string, waveNum *int) error {
 		if !allPass {
 			runStatus = "blocked"
 		}
-		_, err = tx.Exec("UPDATE runs SET status = ?, updated_at = ? WHERE id = ?", runStatus, ts, runID)
-		if err != nil {
-			return fmt.Errorf("store: harvest update run status: %w", err)
+		if runStatus != prevStatus {
+			_, err = tx.Exec("UPDATE runs SET status = ?, updated_at = ? WHERE id = ?", runStatus, ts, runID)
+			if err != nil {
+				return fmt.Errorf("store: harvest update run status: %w", err)
+			}
 		}
 	}
 
 	return tx.Commit()
 }
 
+// subagentTimes are the timestamps of a harvested subagent, with its brief,
+// report and status.json joined as content.
+type subagentTimes struct {
+	created, updated, content string
+}
+
+// subagentContent joins the harvested files of a subagent for comparison.
+func subagentContent(brief, report, statusJSON string) string {
+	return brief + "\x00" + report + "\x00" + statusJSON
+}
+
+// runTimes are the timestamps of the rows an earlier harvest of a run
+// recorded: subagents keyed by name and handoffs keyed by content.
+type runTimes struct {
+	subagents map[string]subagentTimes
+	handoffs  map[string]string
+}
+
+// harvestedTimes reads the subagent and handoff timestamps of a run before
+// a re-harvest replaces its rows.
+func harvestedTimes(tx *sql.Tx, runID int64) (runTimes, error) {
+	t := runTimes{
+		subagents: make(map[string]subagentTimes),
+		handoffs:  make(map[string]string),
+	}
+	rows, err := tx.Query(
+		"SELECT name, COALESCE(brief, ''), COALESCE(report, ''), COALESCE(status_json, ''), created_at, updated_at FROM subagents WHERE run_id = ?",
+		runID,
+	)
+	if err != nil {
+		return t, fmt.Errorf("store: harvest read subagents: %w", err)
+	}
+	for rows.Next() {
+		var name, brief, report, statusJSON, created, updated string
+		if err := rows.Scan(&name, &brief, &report, &statusJSON, &created, &updated); err != nil {
+			rows.Close()
+			return t, fmt.Errorf("store: harvest scan subagent: %w", err)
+		}
+		t.subagents[name] = subagentTimes{created, updated, subagentContent(brief, report, statusJSON)}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return t, fmt.Errorf("store: harvest read subagents: %w", err)
+	}
+
+	rows, err = tx.Query("SELECT content, created_at FROM handoffs WHERE run_id = ?", runID)
+	if err != nil {
+		return t, fmt.Errorf("store: harvest read handoffs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var content, created string
+		if err := rows.Scan(&content, &created); err != nil {
+			return t, fmt.Errorf("store: harvest scan handoff: %w", err)
+		}
+		t.handoffs[content] = created
+	}
+	return t, rows.Err()
+}
+
 // HarvestArtifact reads a file from disk, computes its SHA256 hash,
-// and upserts it into the artifacts table.
+// and upserts it into the artifacts table. When the hash differs from the
+// latest recorded version, a new artifact_versions row is added as well.
 func (s *SpecStore) HarvestArtifact(phase, relPath, absPath string) error {
 	content, err := os.ReadFile(absPath)
 	if err != nil {
 		return fmt.Errorf("store: harvest artifact read %s: %w", absPath, err)
 	}
 
-	hash := fmt.Sprintf("%x", sha256.Sum256(content))
+	hash := ContentHash(content)
 	artType := inferArtifactType(relPath)
 	ts := now()
 
-	_, err = s.db.Exec(`
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("store: harvest artifact begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
 		INSERT INTO artifacts (phase, rel_path, artifact_type, content, content_hash, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(rel_path) DO UPDATE SET
@@ -161,7 +272,10 @@ func (s *SpecStore) HarvestArtifact(phase, relPath, absPath string) error {
 	if err != nil {
 		return fmt.Errorf("store: harvest artifact upsert: %w", err)
 	}
-	return nil
+	if _, err := appendArtifactVersion(tx, relPath, string(content), hash, ts); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // HarvestImplLog reads a task implementation log file and stores it.
@@ -171,7 +285,7 @@ func (s *SpecStore) HarvestImplLog(taskID, absPath string) error {
 		return fmt.Errorf("store: harvest impl log read %s: %w", absPath, err)
 	}
 
-	hash := fmt.Sprintf("%x", sha256.Sum256(content))
+	hash := ContentHash(content)
 	ts := now()
 
 	_, err = s.db.Exec(`
@@ -192,6 +306,11 @@ func (s *SpecStore) HarvestImplLog(taskID, absPath string) error {
 
 // --- helpers ---
 
+// ContentHash returns the hex SHA256 used for artifact and impl log hashes.
+func ContentHash(content []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
 func readFileOpt(path string) string {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -207,7 +326,9 @@ func nullStrPtr(s string) interface{} {
 	return s
 }
 
-func isSpecialDir(name string) bool {
+// IsSpecialDir reports whether a run subdirectory holds run-level state
+// (names starting with "_") rather than a subagent.
+func IsSpecialDir(name string) bool {
 	switch name {
 	case "_inline-audit", "_inline-checkpoint":
 		return true
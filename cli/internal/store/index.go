@@ -31,7 +31,9 @@ CREATE TABLE IF NOT EXISTS documents (
 	title    TEXT NOT NULL,
 	snippet  TEXT,
 	content  TEXT NOT NULL,
-	created_at TEXT NOT NULL
+	created_at TEXT NOT NULL,
+	path     TEXT,
+	content_hash TEXT
 );
 
 CREATE VIRTUAL TABLE IF NOT EXISTS documents_fts USING fts5(
@@ -81,6 +83,10 @@ func OpenIndex(workspaceRoot string) (*IndexStore, error) {
 		db.Close()
 		return nil, fmt.Errorf("store: index schema: %w", err)
 	}
+	if err := upgradeIndexSchema(db); err != nil {
+		db.Close()
+		return nil, err
+	}
 
 	return &IndexStore{db: db}, nil
 }
@@ -115,42 +121,117 @@ func (ix *IndexStore) IndexDocument(spec, docType, phase, title, snippet, conten
 	return nil
 }
 
+// DocumentHashes returns path -> content_hash for the documents of spec that
+// were indexed from a file. Documents without a path (such as the completion
+// summary written by finalizar) are not included.
+func (ix *IndexStore) DocumentHashes(spec string) (map[string]string, error) {
+	rows, err := ix.db.Query(
+		"SELECT path, content_hash FROM documents WHERE spec = ? AND path IS NOT NULL", spec,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: document hashes: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var path string
+		var hash sql.NullString
+		if err := rows.Scan(&path, &hash); err != nil {
+			return nil, fmt.Errorf("store: scan document hash: %w", err)
+		}
+		hashes[path] = hash.String
+	}
+	return hashes, rows.Err()
+}
+
+// UpsertDocument indexes a file-backed document, replacing any document
+// previously indexed for the same spec and path.
+func (ix *IndexStore) UpsertDocument(d IndexDoc) error {
+	tx, err := ix.db.Begin()
+	if err != nil {
+		return fmt.Errorf("store: upsert document: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE documents SET doc_type = ?, phase = ?, title = ?, snippet = ?, content = ?, content_hash = ?, created_at = ?
+		WHERE spec = ? AND path = ?`,
+		d.DocType, d.Phase, d.Title, d.Snippet, d.Content, d.ContentHash, now(), d.Spec, d.Path,
+	)
+	if err != nil {
+		return fmt.Errorf("store: upsert document %s: %w", d.Path, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		_, err = tx.Exec(`
+			INSERT INTO documents (spec, doc_type, phase, title, snippet, content, created_at, path, content_hash)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			d.Spec, d.DocType, d.Phase, d.Title, d.Snippet, d.Content, now(), d.Path, d.ContentHash,
+		)
+		if err != nil {
+			return fmt.Errorf("store: upsert document %s: %w", d.Path, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteDocument removes the document indexed from path for spec.
+func (ix *IndexStore) DeleteDocument(spec, path string) error {
+	if _, err := ix.db.Exec("DELETE FROM documents WHERE spec = ? AND path = ?", spec, path); err != nil {
+		return fmt.Errorf("store: delete document %s: %w", path, err)
+	}
+	return nil
+}
+
 // Search performs an FTS5 full-text search across indexed documents.
 // If specFilter is non-empty, results are limited to that spec.
 func (ix *IndexStore) Search(query, specFilter string, limit int) ([]SearchResult, error) {
+	return ix.SearchFiltered(query, SearchFilter{Spec: specFilter, Limit: limit})
+}
+
+// SearchFiltered performs an FTS5 search narrowed by f. The query accepts
+// phrases, prefixes and AND/OR/NOT (see ParseFTSQuery). Matches in the title
+// and content excerpt are wrapped in HighlightOpen/HighlightClose.
+func (ix *IndexStore) SearchFiltered(query string, f SearchFilter) ([]SearchResult, error) {
+	ftsQuery, err := ParseFTSQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	limit := f.Limit
 	if limit <= 0 {
 		limit = 5
 	}
 
-	// Quote each token to avoid FTS5 syntax errors with special characters.
-	ftsQuery := quoteFTS5(query)
+	where := []string{"documents_fts MATCH ?"}
+	args := []any{HighlightOpen, HighlightClose, HighlightOpen, HighlightClose, ftsQuery}
+	for _, c := range []struct{ cond, value string }{
+		{"d.spec = ?", f.Spec},
+		{"d.doc_type = ?", f.DocType},
+		{"d.phase = ?", f.Phase},
+		{"s.stage = ?", f.Stage},
+		{"d.created_at >= ?", f.Since},
+		{"d.created_at <= ?", f.Until},
+	} {
+		if c.value != "" {
+			where = append(where, c.cond)
+			args = append(args, c.value)
+		}
+	}
+	args = append(args, limit, max(f.Offset, 0))
 
-	var (
-		rows *sql.Rows
-		err  error
+	rows, err := ix.db.Query(`
+		SELECT d.spec, d.doc_type, d.phase, d.title, d.snippet, d.path, COALESCE(s.stage, ''), d.created_at,
+			highlight(documents_fts, 0, ?, ?),
+			snippet(documents_fts, 1, ?, ?, '...', 24),
+			rank
+		FROM documents_fts f
+		JOIN documents d ON d.id = f.rowid
+		LEFT JOIN specs s ON s.name = d.spec
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY rank
+		LIMIT ? OFFSET ?`,
+		args...,
 	)
-
-	if specFilter != "" {
-		rows, err = ix.db.Query(`
-			SELECT d.spec, d.doc_type, d.phase, d.title, d.snippet, rank
-			FROM documents_fts f
-			JOIN documents d ON d.id = f.rowid
-			WHERE documents_fts MATCH ? AND d.spec = ?
-			ORDER BY rank
-			LIMIT ?`,
-			ftsQuery, specFilter, limit,
-		)
-	} else {
-		rows, err = ix.db.Query(`
-			SELECT d.spec, d.doc_type, d.phase, d.title, d.snippet, rank
-			FROM documents_fts f
-			JOIN documents d ON d.id = f.rowid
-			WHERE documents_fts MATCH ?
-			ORDER BY rank
-			LIMIT ?`,
-			ftsQuery, limit,
-		)
-	}
 	if err != nil {
 		return nil, fmt.Errorf("store: search: %w", err)
 	}
@@ -159,11 +240,13 @@ func (ix *IndexStore) Search(query, specFilter string, limit int) ([]SearchResul
 	var results []SearchResult
 	for rows.Next() {
 		var r SearchResult
-		var snippet sql.NullString
-		if err := rows.Scan(&r.Spec, &r.DocType, &r.Phase, &r.Title, &snippet, &r.Rank); err != nil {
+		var snippet, path sql.NullString
+		if err := rows.Scan(&r.Spec, &r.DocType, &r.Phase, &r.Title, &snippet, &path, &r.Stage, &r.IndexedAt,
+			&r.TitleHighlight, &r.Highlight, &r.Rank); err != nil {
 			return nil, fmt.Errorf("store: scan search result: %w", err)
 		}
 		r.Snippet = snippet.String
+		r.Path = path.String
 		results = append(results, r)
 	}
 	return results, rows.Err()
@@ -174,8 +257,34 @@ func (ix *IndexStore) Close() error {
 	return ix.db.Close()
 }
 
-// quoteFTS5 wraps each token in double quotes to safely pass through FTS5 MATCH.
-// This prevents special characters (hyphens, colons) from being interpreted as operators.
-func quoteFTS5(query string) string {
-	return `"` + strings.ReplaceAll(query, `"`, `""`) + `"`
+// upgradeIndexSchema adds the columns introduced after the first index
+// schema to databases created by older binaries.
+func upgradeIndexSchema(db *sql.DB) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('documents')")
+	if err != nil {
+		return fmt.Errorf("store: index columns: %w", err)
+	}
+	have := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("store: index columns: %w", err)
+		}
+		have[name] = true
+	}
+	rows.Close()
+
+	for _, col := range []string{"path", "content_hash"} {
+		if have[col] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE documents ADD COLUMN " + col + " TEXT"); err != nil {
+			return fmt.Errorf("store: add index column %s: %w", col, err)
+		}
+	}
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_documents_spec_path ON documents(spec, path)"); err != nil {
+		return fmt.Errorf("store: index documents path: %w", err)
+	}
+	return nil
 }
@@ -1,32 +1,201 @@
 package store
 
 import (
-	_ "embed"
+	"database/sql"
+	"embed"
+	"errors"
 	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
 )
 
-//go:embed schema.sql
-var schemaSQLv1 string
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// Migration is a single ordered schema step. Migration N moves a database
+// from PRAGMA user_version N-1 to N.
+type Migration struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	SQL     string `json:"-"`
+}
+
+// ErrSchemaTooNew is returned when spec.db was written by a newer binary
+// whose schema this build does not know how to read safely.
+var ErrSchemaTooNew = errors.New("store: spec.db schema is newer than this oraculo binary supports")
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.sql$`)
+
+// specMigrations is the embedded migration set, loaded once at init.
+var specMigrations = mustLoadMigrations(migrationFS, "migrations")
+
+// Migrations returns the embedded spec.db migrations in version order.
+func Migrations() []Migration {
+	out := make([]Migration, len(specMigrations))
+	copy(out, specMigrations)
+	return out
+}
+
+// LatestSchemaVersion returns the highest spec.db schema version this binary knows.
+func LatestSchemaVersion() int {
+	return latestVersion(specMigrations)
+}
+
+// SchemaVersion returns the database's current PRAGMA user_version.
+func (s *SpecStore) SchemaVersion() (int, error) {
+	return readUserVersion(s.db)
+}
+
+// PendingMigrations returns the migrations not yet applied to this database.
+// It returns ErrSchemaTooNew if the database is ahead of this binary.
+func (s *SpecStore) PendingMigrations() ([]Migration, error) {
+	version, err := readUserVersion(s.db)
+	if err != nil {
+		return nil, err
+	}
+	return pendingMigrations(specMigrations, version)
+}
 
 // Migrate runs schema migrations based on PRAGMA user_version.
 // It is idempotent and safe to call multiple times.
 func (s *SpecStore) Migrate() error {
+	_, err := applyMigrations(s.db, specMigrations)
+	return err
+}
+
+// ApplyMigrations runs all pending migrations and returns the ones applied.
+// Each migration runs in its own transaction together with the user_version
+// bump, so a failing step leaves the database at the previous version.
+func (s *SpecStore) ApplyMigrations() ([]Migration, error) {
+	return applyMigrations(s.db, specMigrations)
+}
+
+// applyMigrations applies every migration in list above the current version.
+func applyMigrations(db *sql.DB, list []Migration) ([]Migration, error) {
+	version, err := readUserVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := pendingMigrations(list, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range pending {
+		ok, err := applyMigration(db, m)
+		if err != nil {
+			return applied, err
+		}
+		if ok {
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// applyMigration runs one migration transactionally. It re-reads the version
+// inside the transaction so a concurrent process that already applied the
+// step is not applied twice; in that case it returns false.
+func applyMigration(db *sql.DB, m Migration) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("store: migration %d begin tx: %w", m.Version, err)
+	}
+	defer tx.Rollback()
+
+	var current int
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&current); err != nil {
+		return false, fmt.Errorf("store: read user_version: %w", err)
+	}
+	if current >= m.Version {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return false, fmt.Errorf("store: apply schema v%d (%s): %w", m.Version, m.Name, err)
+	}
+	// PRAGMA does not accept bound parameters; Version is a parsed integer.
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", m.Version)); err != nil {
+		return false, fmt.Errorf("store: set user_version: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("store: migration %d commit: %w", m.Version, err)
+	}
+	return true, nil
+}
+
+// pendingMigrations returns the migrations in list above version.
+func pendingMigrations(list []Migration, version int) ([]Migration, error) {
+	if latest := latestVersion(list); version > latest {
+		return nil, fmt.Errorf("%w (database v%d, binary v%d); upgrade oraculo", ErrSchemaTooNew, version, latest)
+	}
+	var pending []Migration
+	for _, m := range list {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+func readUserVersion(db *sql.DB) (int, error) {
 	var version int
-	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
-		return fmt.Errorf("store: read user_version: %w", err)
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("store: read user_version: %w", err)
 	}
+	return version, nil
+}
 
-	if version >= 1 {
-		return nil // already migrated
+func latestVersion(list []Migration) int {
+	if len(list) == 0 {
+		return 0
 	}
+	return list[len(list)-1].Version
+}
 
-	if _, err := s.db.Exec(schemaSQLv1); err != nil {
-		return fmt.Errorf("store: apply schema v1: %w", err)
+// loadMigrations reads NNN_name.sql files from dir. Versions must be
+// contiguous starting at 1 so every database walks the same path.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("store: read migrations: %w", err)
 	}
 
-	if _, err := s.db.Exec("PRAGMA user_version = 1"); err != nil {
-		return fmt.Errorf("store: set user_version: %w", err)
+	var list []Migration
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := migrationFileRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("store: migration %q does not match NNN_name.sql", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("store: read migration %s: %w", e.Name(), err)
+		}
+		list = append(list, Migration{Version: version, Name: m[2], SQL: string(data)})
 	}
 
-	return nil
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	for i, m := range list {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("store: migration versions must be contiguous from 1, found v%d at position %d", m.Version, i+1)
+		}
+	}
+	return list, nil
+}
+
+func mustLoadMigrations(fsys fs.FS, dir string) []Migration {
+	list, err := loadMigrations(fsys, dir)
+	if err != nil {
+		// Should not happen with valid embedded files.
+		panic(err.Error())
+	}
+	return list
 }
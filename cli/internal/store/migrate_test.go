@@ -0,0 +1,128 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrationsContiguous(t *testing.T) {
+	list := Migrations()
+	if len(list) == 0 {
+		t.Fatal("expected at least one embedded migration")
+	}
+	for i, m := range list {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d", i, m.Version)
+		}
+		if m.SQL == "" {
+			t.Errorf("migration v%d has empty SQL", m.Version)
+		}
+	}
+	if LatestSchemaVersion() != len(list) {
+		t.Errorf("LatestSchemaVersion = %d, want %d", LatestSchemaVersion(), len(list))
+	}
+}
+
+func TestOpenAppliesAllMigrations(t *testing.T) {
+	s := openTestStore(t)
+
+	version, err := s.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion: %v", err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Fatalf("version = %d, want %d", version, LatestSchemaVersion())
+	}
+
+	pending, err := s.PendingMigrations()
+	if err != nil {
+		t.Fatalf("PendingMigrations: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("expected no pending migrations, got %d", len(pending))
+	}
+}
+
+func TestOpenRefusesNewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := s.DB().Exec(fmt.Sprintf("PRAGMA user_version = %d", LatestSchemaVersion()+1)); err != nil {
+		t.Fatalf("bump user_version: %v", err)
+	}
+	s.Close()
+
+	if _, err := Open(dir); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("Open on newer schema: err = %v, want ErrSchemaTooNew", err)
+	}
+	if s := TryOpen(dir); s != nil {
+		s.Close()
+		t.Fatal("TryOpen should return nil for newer schema")
+	}
+
+	// Inspection still works without migrating.
+	raw, err := OpenNoMigrate(dir)
+	if err != nil {
+		t.Fatalf("OpenNoMigrate: %v", err)
+	}
+	defer raw.Close()
+	if _, err := raw.PendingMigrations(); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("PendingMigrations: err = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestApplyMigrationsRollsBackFailedStep(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenNoMigrate(dir)
+	if err != nil {
+		t.Fatalf("OpenNoMigrate: %v", err)
+	}
+	defer s.Close()
+
+	list := []Migration{
+		{Version: 1, Name: "one", SQL: "CREATE TABLE a (id INTEGER);"},
+		{Version: 2, Name: "two", SQL: "CREATE TABLE b (id INTEGER); INSERT INTO missing VALUES (1);"},
+	}
+
+	applied, err := applyMigrations(s.db, list)
+	if err == nil {
+		t.Fatal("expected error from failing migration")
+	}
+	if len(applied) != 1 || applied[0].Version != 1 {
+		t.Fatalf("applied = %+v, want only v1", applied)
+	}
+
+	version, _ := s.SchemaVersion()
+	if version != 1 {
+		t.Fatalf("version = %d, want 1 after failed v2", version)
+	}
+
+	// Table b from the failed step must not exist.
+	var n int
+	s.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'b'").Scan(&n)
+	if n != 0 {
+		t.Fatal("table from failed migration was not rolled back")
+	}
+}
+
+func TestLoadMigrationsRejectsGaps(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/001_initial.sql": {Data: []byte("SELECT 1;")},
+		"m/003_later.sql":   {Data: []byte("SELECT 1;")},
+	}
+	if _, err := loadMigrations(fsys, "m"); err == nil {
+		t.Fatal("expected error for non-contiguous versions")
+	}
+
+	fsys = fstest.MapFS{
+		"m/001_initial.sql": {Data: []byte("SELECT 1;")},
+		"m/notes.txt":       {Data: []byte("x")},
+	}
+	if _, err := loadMigrations(fsys, "m"); err == nil {
+		t.Fatal("expected error for badly named file")
+	}
+}
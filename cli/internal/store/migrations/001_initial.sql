@@ -1,4 +1,4 @@
--- spec.db schema v1
+-- spec.db schema v1 (initial)
 -- Per-spec SQLite database for spec-workflow runtime state and artifacts.
 
 CREATE TABLE IF NOT EXISTS spec_meta (
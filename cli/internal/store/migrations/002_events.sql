@@ -0,0 +1,30 @@
+-- spec.db schema v2: append-only event journal.
+-- One row per mutating command (dispatch-init, task-mark, wave-update, ...).
+-- args/before/after hold JSON documents.
+
+CREATE TABLE IF NOT EXISTS events (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    ts         TEXT NOT NULL,
+    actor      TEXT,
+    session_id TEXT,
+    command    TEXT NOT NULL,
+    wave       INTEGER,
+    task_id    TEXT,
+    args       TEXT,
+    before     TEXT,
+    after      TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_ts      ON events(ts);
+CREATE INDEX IF NOT EXISTS idx_events_wave    ON events(wave);
+CREATE INDEX IF NOT EXISTS idx_events_task_id ON events(task_id);
+
+CREATE TRIGGER IF NOT EXISTS events_no_update BEFORE UPDATE ON events
+BEGIN
+    SELECT RAISE(ABORT, 'events are append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS events_no_delete BEFORE DELETE ON events
+BEGIN
+    SELECT RAISE(ABORT, 'events are append-only');
+END;
@@ -0,0 +1,18 @@
+-- spec.db schema v3: artifact version history.
+-- artifacts keeps the latest content per rel_path; artifact_versions keeps
+-- every content it has had, numbered from 1 per rel_path. A new version is
+-- added whenever a harvest sees a hash different from the latest version.
+
+CREATE TABLE IF NOT EXISTS artifact_versions (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    rel_path     TEXT NOT NULL,
+    version      INTEGER NOT NULL,
+    content      TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    created_at   TEXT NOT NULL,
+    UNIQUE (rel_path, version)
+);
+
+-- Existing artifacts become version 1.
+INSERT INTO artifact_versions (rel_path, version, content, content_hash, created_at)
+SELECT rel_path, 1, content, content_hash, updated_at FROM artifacts;
@@ -0,0 +1,7 @@
+-- spec.db schema v4: run retention.
+-- `oraculo gc` compresses or deletes run dirs once they are harvested. The
+-- run and subagent rows stay; collected records what happened to the
+-- directory ('compressed' or 'deleted') so fsck does not report it missing.
+
+ALTER TABLE runs ADD COLUMN collected    TEXT;
+ALTER TABLE runs ADD COLUMN collected_at TEXT;
@@ -0,0 +1,6 @@
+-- spec.db schema v5: task metadata.
+-- Any "Key: value" line under a task that is not one of the known fields
+-- (Wave, Depends On, Files, TDD, Reason) is kept as a JSON object so
+-- `oraculo tasks list --where` and ad-hoc SQL can query it.
+
+ALTER TABLE tasks ADD COLUMN meta TEXT;
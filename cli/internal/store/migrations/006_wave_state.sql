@@ -0,0 +1,10 @@
+-- spec.db schema v6: explicit wave state machine.
+-- waves.status now holds the wave state (pending, executing,
+-- awaiting-checkpoint, passed, blocked, reopened). Rows written by a
+-- directory scan are mapped to the new names; state_set_at records when
+-- `oraculo wave transition` (or a dispatch) last set the state, and rescans
+-- leave such rows alone.
+
+UPDATE waves SET status = 'executing' WHERE status = 'in_progress';
+UPDATE waves SET status = 'passed' WHERE status = 'complete';
+ALTER TABLE waves ADD COLUMN state_set_at TEXT;
@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -37,6 +38,25 @@ func (s *SpecStore) SetMeta(key, value string) error {
 	return nil
 }
 
+// ListMeta returns every key-value pair in the spec_meta table.
+func (s *SpecStore) ListMeta() (map[string]string, error) {
+	rows, err := s.db.Query("SELECT key, value FROM spec_meta ORDER BY key")
+	if err != nil {
+		return nil, fmt.Errorf("store: list meta: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]string)
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, fmt.Errorf("store: scan meta: %w", err)
+		}
+		result[k] = v
+	}
+	return result, rows.Err()
+}
+
 // --- artifacts ---
 
 // GetArtifact retrieves a single artifact by phase and relative path.
@@ -81,6 +101,19 @@ func (s *SpecStore) ListArtifacts(phase string) ([]Artifact, error) {
 	return result, rows.Err()
 }
 
+// ArtifactHashes returns the content hash of every artifact keyed by rel_path.
+func (s *SpecStore) ArtifactHashes() (map[string]string, error) {
+	return s.hashIndex("SELECT rel_path, content_hash FROM artifacts", "artifact")
+}
+
+// DeleteArtifact removes an artifact by its relative path.
+func (s *SpecStore) DeleteArtifact(relPath string) error {
+	if _, err := s.db.Exec("DELETE FROM artifacts WHERE rel_path = ?", relPath); err != nil {
+		return fmt.Errorf("store: delete artifact: %w", err)
+	}
+	return nil
+}
+
 // --- runs ---
 
 // CreateRun inserts a new run record and returns its ID.
@@ -100,9 +133,9 @@ func (s *SpecStore) CreateRun(command string, runNumber int, phase string, waveN
 func (s *SpecStore) GetRun(command string, runNum int) (*Run, error) {
 	r := &Run{}
 	err := s.db.QueryRow(
-		"SELECT id, command, run_number, phase, wave_number, comms_path, status, created_at, updated_at FROM runs WHERE command = ? AND run_number = ?",
+		"SELECT id, command, run_number, phase, wave_number, comms_path, status, COALESCE(collected, ''), COALESCE(collected_at, ''), created_at, updated_at FROM runs WHERE command = ? AND run_number = ?",
 		command, runNum,
-	).Scan(&r.ID, &r.Command, &r.RunNumber, &r.Phase, &r.WaveNumber, &r.CommsPath, &r.Status, &r.CreatedAt, &r.UpdatedAt)
+	).Scan(&r.ID, &r.Command, &r.RunNumber, &r.Phase, &r.WaveNumber, &r.CommsPath, &r.Status, &r.Collected, &r.CollectedAt, &r.CreatedAt, &r.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -116,9 +149,9 @@ func (s *SpecStore) GetRun(command string, runNum int) (*Run, error) {
 func (s *SpecStore) LatestRun(command string) (*Run, error) {
 	r := &Run{}
 	err := s.db.QueryRow(
-		"SELECT id, command, run_number, phase, wave_number, comms_path, status, created_at, updated_at FROM runs WHERE command = ? ORDER BY run_number DESC LIMIT 1",
+		"SELECT id, command, run_number, phase, wave_number, comms_path, status, COALESCE(collected, ''), COALESCE(collected_at, ''), created_at, updated_at FROM runs WHERE command = ? ORDER BY run_number DESC LIMIT 1",
 		command,
-	).Scan(&r.ID, &r.Command, &r.RunNumber, &r.Phase, &r.WaveNumber, &r.CommsPath, &r.Status, &r.CreatedAt, &r.UpdatedAt)
+	).Scan(&r.ID, &r.Command, &r.RunNumber, &r.Phase, &r.WaveNumber, &r.CommsPath, &r.Status, &r.Collected, &r.CollectedAt, &r.CreatedAt, &r.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -140,6 +173,62 @@ func (s *SpecStore) UpdateRunStatus(runID int64, status string) error {
 	return nil
 }
 
+// UpdateRunCommsPath points the run recorded at comms path from to its new
+// location to, after its directory was moved. It reports whether a run was
+// recorded there.
+func (s *SpecStore) UpdateRunCommsPath(from, to string) (bool, error) {
+	res, err := s.db.Exec(
+		"UPDATE runs SET comms_path = ?, updated_at = ? WHERE comms_path = ?",
+		to, now(), from,
+	)
+	if err != nil {
+		return false, fmt.Errorf("store: update run comms path: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListRuns returns every run ordered by ID.
+func (s *SpecStore) ListRuns() ([]Run, error) {
+	rows, err := s.db.Query(
+		"SELECT id, command, run_number, phase, wave_number, comms_path, status, COALESCE(collected, ''), COALESCE(collected_at, ''), created_at, updated_at FROM runs ORDER BY id",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: list runs: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Run
+	for rows.Next() {
+		var r Run
+		if err := rows.Scan(&r.ID, &r.Command, &r.RunNumber, &r.Phase, &r.WaveNumber, &r.CommsPath, &r.Status, &r.Collected, &r.CollectedAt, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("store: scan run: %w", err)
+		}
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
+
+// DeleteRun removes a run together with its subagents and handoffs.
+func (s *SpecStore) DeleteRun(runID int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("store: delete run begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, q := range []string{
+		"DELETE FROM handoffs WHERE run_id = ?",
+		"DELETE FROM subagents WHERE run_id = ?",
+		"DELETE FROM runs WHERE id = ?",
+	} {
+		if _, err := tx.Exec(q, runID); err != nil {
+			return fmt.Errorf("store: delete run %d: %w", runID, err)
+		}
+	}
+	return tx.Commit()
+}
+
 // --- subagents ---
 
 // CreateSubagent inserts a new subagent record and returns its ID.
@@ -222,7 +311,8 @@ func (s *SpecStore) ListSubagents(runID int64) ([]Subagent, error) {
 
 // --- waves ---
 
-// UpsertWave inserts or updates a wave record.
+// UpsertWave inserts or updates a wave record. The status of a wave whose
+// state was set with SetWaveState is kept.
 func (s *SpecStore) UpsertWave(w WaveRecord) error {
 	stale := 0
 	if w.StaleFlag {
@@ -232,7 +322,7 @@ func (s *SpecStore) UpsertWave(w WaveRecord) error {
 		INSERT INTO waves (wave_number, status, exec_runs, check_runs, summary_status, summary_text, summary_source, stale_flag, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(wave_number) DO UPDATE SET
-			status = excluded.status,
+			status = CASE WHEN waves.state_set_at IS NULL THEN excluded.status ELSE waves.status END,
 			exec_runs = excluded.exec_runs,
 			check_runs = excluded.check_runs,
 			summary_status = excluded.summary_status,
@@ -250,15 +340,36 @@ func (s *SpecStore) UpsertWave(w WaveRecord) error {
 	return nil
 }
 
+// SetWaveState sets the state of a wave explicitly, creating its row if
+// needed. setAt is the time to record; empty means now.
+func (s *SpecStore) SetWaveState(num int, state, setAt string) error {
+	if setAt == "" {
+		setAt = now()
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO waves (wave_number, status, state_set_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(wave_number) DO UPDATE SET
+			status = excluded.status,
+			state_set_at = excluded.state_set_at,
+			updated_at = excluded.updated_at`,
+		num, state, setAt, now(),
+	)
+	if err != nil {
+		return fmt.Errorf("store: set wave state: %w", err)
+	}
+	return nil
+}
+
 // GetWave retrieves a wave by number.
 func (s *SpecStore) GetWave(num int) (*WaveRecord, error) {
 	w := &WaveRecord{}
-	var summaryStatus, summaryText, summarySource sql.NullString
+	var summaryStatus, summaryText, summarySource, stateSetAt sql.NullString
 	var stale int
 	err := s.db.QueryRow(
-		"SELECT wave_number, status, exec_runs, check_runs, summary_status, summary_text, summary_source, stale_flag, updated_at FROM waves WHERE wave_number = ?",
+		"SELECT wave_number, status, exec_runs, check_runs, summary_status, summary_text, summary_source, stale_flag, state_set_at, updated_at FROM waves WHERE wave_number = ?",
 		num,
-	).Scan(&w.WaveNumber, &w.Status, &w.ExecRuns, &w.CheckRuns, &summaryStatus, &summaryText, &summarySource, &stale, &w.UpdatedAt)
+	).Scan(&w.WaveNumber, &w.Status, &w.ExecRuns, &w.CheckRuns, &summaryStatus, &summaryText, &summarySource, &stale, &stateSetAt, &w.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -269,13 +380,14 @@ func (s *SpecStore) GetWave(num int) (*WaveRecord, error) {
 	w.SummaryText = summaryText.String
 	w.SummarySource = summarySource.String
 	w.StaleFlag = stale != 0
+	w.StateSetAt = stateSetAt.String
 	return w, nil
 }
 
 // ListWaves returns all waves ordered by wave number.
 func (s *SpecStore) ListWaves() ([]WaveRecord, error) {
 	rows, err := s.db.Query(
-		"SELECT wave_number, status, exec_runs, check_runs, summary_status, summary_text, summary_source, stale_flag, updated_at FROM waves ORDER BY wave_number",
+		"SELECT wave_number, status, exec_runs, check_runs, summary_status, summary_text, summary_source, stale_flag, state_set_at, updated_at FROM waves ORDER BY wave_number",
 	)
 	if err != nil {
 		return nil, fmt.Errorf("store: list waves: %w", err)
@@ -285,15 +397,16 @@ func (s *SpecStore) ListWaves() ([]WaveRecord, error) {
 	var result []WaveRecord
 	for rows.Next() {
 		var w WaveRecord
-		var summaryStatus, summaryText, summarySource sql.NullString
+		var summaryStatus, summaryText, summarySource, stateSetAt sql.NullString
 		var stale int
-		if err := rows.Scan(&w.WaveNumber, &w.Status, &w.ExecRuns, &w.CheckRuns, &summaryStatus, &summaryText, &summarySource, &stale, &w.UpdatedAt); err != nil {
+		if err := rows.Scan(&w.WaveNumber, &w.Status, &w.ExecRuns, &w.CheckRuns, &summaryStatus, &summaryText, &summarySource, &stale, &stateSetAt, &w.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("store: scan wave: %w", err)
 		}
 		w.SummaryStatus = summaryStatus.String
 		w.SummaryText = summaryText.String
 		w.SummarySource = summarySource.String
 		w.StaleFlag = stale != 0
+		w.StateSetAt = stateSetAt.String
 		result = append(result, w)
 	}
 	return result, rows.Err()
@@ -301,8 +414,43 @@ func (s *SpecStore) ListWaves() ([]WaveRecord, error) {
 
 // --- tasks ---
 
+// execer is satisfied by both *sql.DB and *sql.Tx, so a write can run on
+// its own or as part of a transaction.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
 // SyncTask upserts a task record.
 func (s *SpecStore) SyncTask(t TaskRecord) error {
+	return syncTask(s.db, t)
+}
+
+// SyncTaskBatch upserts tasks and appends events in a single transaction:
+// either every row is written or none is.
+func (s *SpecStore) SyncTaskBatch(tasks []TaskRecord, events []Event) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("store: sync task batch begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, t := range tasks {
+		if err := syncTask(tx, t); err != nil {
+			return err
+		}
+	}
+	for _, e := range events {
+		if err := recordEvent(tx, e); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: sync task batch commit: %w", err)
+	}
+	return nil
+}
+
+func syncTask(ex execer, t TaskRecord) error {
 	tdd := 0
 	if t.TDD {
 		tdd = 1
@@ -311,9 +459,17 @@ func (s *SpecStore) SyncTask(t TaskRecord) error {
 	if t.IsDeferred {
 		deferred = 1
 	}
-	_, err := s.db.Exec(`
-		INSERT INTO tasks (task_id, title, status, wave, depends_on, files, tdd, is_deferred, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	var meta string
+	if len(t.Meta) > 0 {
+		data, err := json.Marshal(t.Meta)
+		if err != nil {
+			return fmt.Errorf("store: sync task: %w", err)
+		}
+		meta = string(data)
+	}
+	_, err := ex.Exec(`
+		INSERT INTO tasks (task_id, title, status, wave, depends_on, files, tdd, is_deferred, meta, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(task_id) DO UPDATE SET
 			title = excluded.title,
 			status = excluded.status,
@@ -322,10 +478,11 @@ func (s *SpecStore) SyncTask(t TaskRecord) error {
 			files = excluded.files,
 			tdd = excluded.tdd,
 			is_deferred = excluded.is_deferred,
+			meta = excluded.meta,
 			updated_at = excluded.updated_at`,
 		t.TaskID, t.Title, t.Status, t.Wave,
 		nullStr(t.DependsOn), nullStr(t.Files),
-		tdd, deferred, now(),
+		tdd, deferred, nullStr(meta), now(),
 	)
 	if err != nil {
 		return fmt.Errorf("store: sync task: %w", err)
@@ -336,7 +493,7 @@ func (s *SpecStore) SyncTask(t TaskRecord) error {
 // ListTasks returns all tasks ordered by task_id.
 func (s *SpecStore) ListTasks() ([]TaskRecord, error) {
 	rows, err := s.db.Query(
-		"SELECT task_id, title, status, wave, depends_on, files, tdd, is_deferred, updated_at FROM tasks ORDER BY task_id",
+		"SELECT task_id, title, status, wave, depends_on, files, tdd, is_deferred, meta, updated_at FROM tasks ORDER BY task_id",
 	)
 	if err != nil {
 		return nil, fmt.Errorf("store: list tasks: %w", err)
@@ -346,11 +503,16 @@ func (s *SpecStore) ListTasks() ([]TaskRecord, error) {
 	var result []TaskRecord
 	for rows.Next() {
 		var t TaskRecord
-		var dependsOn, files sql.NullString
+		var dependsOn, files, meta sql.NullString
 		var tdd, deferred int
-		if err := rows.Scan(&t.TaskID, &t.Title, &t.Status, &t.Wave, &dependsOn, &files, &tdd, &deferred, &t.UpdatedAt); err != nil {
+		if err := rows.Scan(&t.TaskID, &t.Title, &t.Status, &t.Wave, &dependsOn, &files, &tdd, &deferred, &meta, &t.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("store: scan task: %w", err)
 		}
+		if meta.String != "" {
+			if err := json.Unmarshal([]byte(meta.String), &t.Meta); err != nil {
+				return nil, fmt.Errorf("store: task %s meta: %w", t.TaskID, err)
+			}
+		}
 		t.DependsOn = dependsOn.String
 		t.Files = files.String
 		t.TDD = tdd != 0
@@ -360,6 +522,14 @@ func (s *SpecStore) ListTasks() ([]TaskRecord, error) {
 	return result, rows.Err()
 }
 
+// DeleteTask removes a task record.
+func (s *SpecStore) DeleteTask(taskID string) error {
+	if _, err := s.db.Exec("DELETE FROM tasks WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("store: delete task: %w", err)
+	}
+	return nil
+}
+
 // --- handoffs ---
 
 // CreateHandoff inserts a new handoff record.
@@ -378,6 +548,27 @@ func (s *SpecStore) CreateHandoff(runID int64, content string, allPass bool) err
 	return nil
 }
 
+// HandoffTimes returns when the latest handoff of each run was recorded,
+// keyed by run ID. Runs without a handoff are absent.
+func (s *SpecStore) HandoffTimes() (map[int64]string, error) {
+	rows, err := s.db.Query("SELECT run_id, MAX(created_at) FROM handoffs GROUP BY run_id")
+	if err != nil {
+		return nil, fmt.Errorf("store: handoff times: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var at string
+		if err := rows.Scan(&id, &at); err != nil {
+			return nil, fmt.Errorf("store: scan handoff time: %w", err)
+		}
+		result[id] = at
+	}
+	return result, rows.Err()
+}
+
 // --- impl_logs ---
 
 // GetImplLog retrieves an implementation log by task ID.
@@ -396,6 +587,19 @@ func (s *SpecStore) GetImplLog(taskID string) (*ImplLog, error) {
 	return l, nil
 }
 
+// ImplLogHashes returns the content hash of every implementation log keyed by task ID.
+func (s *SpecStore) ImplLogHashes() (map[string]string, error) {
+	return s.hashIndex("SELECT task_id, content_hash FROM impl_logs", "impl log")
+}
+
+// DeleteImplLog removes an implementation log by task ID.
+func (s *SpecStore) DeleteImplLog(taskID string) error {
+	if _, err := s.db.Exec("DELETE FROM impl_logs WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("store: delete impl log: %w", err)
+	}
+	return nil
+}
+
 // --- completion_summary ---
 
 // GetCompletionSummary retrieves the singleton completion summary.
@@ -430,8 +634,68 @@ func (s *SpecStore) SaveCompletionSummary(frontmatter, body string) error {
 	return nil
 }
 
+// --- maintenance ---
+
+// countedTables lists the tables reported by TableCounts.
+var countedTables = []string{
+	"runs", "subagents", "handoffs", "waves", "tasks", "impl_logs", "artifacts", "artifact_versions", "spec_meta", "events",
+}
+
+// TableCounts returns the number of rows in each runtime table.
+func (s *SpecStore) TableCounts() (map[string]int, error) {
+	counts := make(map[string]int, len(countedTables))
+	for _, t := range countedTables {
+		var n int
+		// Table names come from the fixed list above, never from input.
+		if err := s.db.QueryRow("SELECT COUNT(*) FROM " + t).Scan(&n); err != nil {
+			return nil, fmt.Errorf("store: count %s: %w", t, err)
+		}
+		counts[t] = n
+	}
+	return counts, nil
+}
+
 // --- helpers ---
 
+// HasTable reports whether the database has a table with the given name.
+func (s *SpecStore) HasTable(name string) (bool, error) {
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("store: has table %s: %w", name, err)
+	}
+	return n > 0, nil
+}
+
+// HasColumn reports whether table has a column with the given name.
+func (s *SpecStore) HasColumn(table, column string) (bool, error) {
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("store: has column %s.%s: %w", table, column, err)
+	}
+	return n > 0, nil
+}
+
+// hashIndex runs a two-column key/hash query and returns it as a map.
+func (s *SpecStore) hashIndex(query, what string) (map[string]string, error) {
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("store: list %s hashes: %w", what, err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]string)
+	for rows.Next() {
+		var key, hash string
+		if err := rows.Scan(&key, &hash); err != nil {
+			return nil, fmt.Errorf("store: scan %s hash: %w", what, err)
+		}
+		result[key] = hash
+	}
+	return result, rows.Err()
+}
+
 // nullStr returns a sql.NullString that is null when the string is empty.
 func nullStr(s string) sql.NullString {
 	if s == "" {
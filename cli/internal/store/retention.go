@@ -0,0 +1,228 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Values of Run.Collected.
+const (
+	CollectedCompressed = "compressed"
+	CollectedDeleted    = "deleted"
+)
+
+// CollectedRun is a run whose directory is gone, with the rows harvested
+// from it. Rebuild copies these across because they have no filesystem
+// source any more.
+type CollectedRun struct {
+	Run       Run        `json:"run"`
+	Subagents []Subagent `json:"subagents"`
+	Handoffs  []Handoff  `json:"handoffs"`
+}
+
+// MarkRunCollected records that the directory of a run was removed by
+// retention. how is CollectedCompressed or CollectedDeleted.
+func (s *SpecStore) MarkRunCollected(runID int64, how string) error {
+	if how != CollectedCompressed && how != CollectedDeleted {
+		return fmt.Errorf("store: mark run collected: unknown mode %q", how)
+	}
+	ts := now()
+	res, err := s.db.Exec(
+		"UPDATE runs SET collected = ?, collected_at = ?, updated_at = ? WHERE id = ?",
+		how, ts, ts, runID,
+	)
+	if err != nil {
+		return fmt.Errorf("store: mark run collected: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("store: mark run collected: run %d not found", runID)
+	}
+	return nil
+}
+
+// ListCollectedRuns returns every collected run with its subagents and
+// handoffs, ordered by run ID.
+func (s *SpecStore) ListCollectedRuns() ([]CollectedRun, error) {
+	runs, err := s.ListRuns()
+	if err != nil {
+		return nil, err
+	}
+	var result []CollectedRun
+	for _, r := range runs {
+		if r.Collected == "" {
+			continue
+		}
+		c := CollectedRun{Run: r}
+		if c.Subagents, err = s.ListSubagents(r.ID); err != nil {
+			return nil, err
+		}
+		if c.Handoffs, err = s.ListHandoffs(r.ID); err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+// RestoreCollectedRun inserts a collected run and its rows into a database
+// that does not have it yet. IDs are reassigned. A run that already exists
+// (same command, run number and wave) is left alone and reported as false.
+func (s *SpecStore) RestoreCollectedRun(c CollectedRun) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("store: restore run begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	r := c.Run
+	var exists int
+	if err := tx.QueryRow(
+		"SELECT COUNT(*) FROM runs WHERE command = ? AND run_number = ? AND wave_number IS ?",
+		r.Command, r.RunNumber, r.WaveNumber,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("store: restore run lookup: %w", err)
+	}
+	if exists > 0 {
+		return false, nil
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO runs (command, run_number, phase, wave_number, comms_path, status, collected, collected_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Command, r.RunNumber, r.Phase, r.WaveNumber, r.CommsPath, r.Status,
+		nullStr(r.Collected), nullStr(r.CollectedAt), r.CreatedAt, r.UpdatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("store: restore run %s: %w", r.CommsPath, err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("store: restore run id: %w", err)
+	}
+
+	for _, a := range c.Subagents {
+		if _, err := tx.Exec(`
+			INSERT INTO subagents (run_id, name, brief, report, status, summary, status_json, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			runID, a.Name, nullStr(a.Brief), nullStr(a.Report), nullStr(a.Status),
+			nullStr(a.Summary), nullStr(a.StatusJSON), a.CreatedAt, a.UpdatedAt,
+		); err != nil {
+			return false, fmt.Errorf("store: restore subagent %s: %w", a.Name, err)
+		}
+	}
+	for _, h := range c.Handoffs {
+		pass := 0
+		if h.AllPass {
+			pass = 1
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO handoffs (run_id, content, all_pass, created_at) VALUES (?, ?, ?, ?)",
+			runID, h.Content, pass, h.CreatedAt,
+		); err != nil {
+			return false, fmt.Errorf("store: restore handoff: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("store: restore run commit: %w", err)
+	}
+	return true, nil
+}
+
+// ListHandoffs returns the handoffs recorded for a run in insertion order.
+func (s *SpecStore) ListHandoffs(runID int64) ([]Handoff, error) {
+	rows, err := s.db.Query(
+		"SELECT id, run_id, content, all_pass, created_at FROM handoffs WHERE run_id = ? ORDER BY id",
+		runID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: list handoffs: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Handoff
+	for rows.Next() {
+		var h Handoff
+		if err := rows.Scan(&h.ID, &h.RunID, &h.Content, &h.AllPass, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("store: scan handoff: %w", err)
+		}
+		result = append(result, h)
+	}
+	return result, rows.Err()
+}
+
+// RunTimes are the timestamps of a run and its handoffs. A harvest stamps
+// rows with the time it runs, so Rebuild copies these across to keep
+// cycle times intact.
+type RunTimes struct {
+	Command    string    `json:"command"`
+	RunNumber  int       `json:"run_number"`
+	WaveNumber *int      `json:"wave_number,omitempty"`
+	CreatedAt  string    `json:"created_at"`
+	UpdatedAt  string    `json:"updated_at"`
+	Handoffs   []Handoff `json:"handoffs"`
+}
+
+// ListRunTimes returns the timestamps of every run, ordered by run ID.
+func (s *SpecStore) ListRunTimes() ([]RunTimes, error) {
+	runs, err := s.ListRuns()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]RunTimes, 0, len(runs))
+	for _, r := range runs {
+		t := RunTimes{
+			Command:    r.Command,
+			RunNumber:  r.RunNumber,
+			WaveNumber: r.WaveNumber,
+			CreatedAt:  r.CreatedAt,
+			UpdatedAt:  r.UpdatedAt,
+		}
+		if t.Handoffs, err = s.ListHandoffs(r.ID); err != nil {
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// RestoreRunTimes sets the timestamps of an existing run (same command, run
+// number and wave) and of its handoffs whose content is unchanged. It
+// reports false when the run is not in the database.
+func (s *SpecStore) RestoreRunTimes(t RunTimes) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("store: restore run times begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var runID int64
+	err = tx.QueryRow(
+		"SELECT id FROM runs WHERE command = ? AND run_number = ? AND wave_number IS ?",
+		t.Command, t.RunNumber, t.WaveNumber,
+	).Scan(&runID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("store: restore run times lookup: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE runs SET created_at = ?, updated_at = ? WHERE id = ?",
+		t.CreatedAt, t.UpdatedAt, runID,
+	); err != nil {
+		return false, fmt.Errorf("store: restore run times: %w", err)
+	}
+	for _, h := range t.Handoffs {
+		if _, err := tx.Exec(
+			"UPDATE handoffs SET created_at = ? WHERE run_id = ? AND content = ?",
+			h.CreatedAt, runID, h.Content,
+		); err != nil {
+			return false, fmt.Errorf("store: restore handoff time: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("store: restore run times commit: %w", err)
+	}
+	return true, nil
+}
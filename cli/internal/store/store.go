@@ -16,9 +16,37 @@ type SpecStore struct {
 }
 
 // Open opens (or creates) the spec.db file inside specDir.
-// It sets WAL journal mode, busy_timeout=5000ms, and enables foreign keys.
+// It sets WAL journal mode, busy_timeout=5000ms, and enables foreign keys,
+// then applies pending migrations. A database written by a newer binary is
+// refused with ErrSchemaTooNew rather than opened.
 func Open(specDir string) (*SpecStore, error) {
-	dbPath := filepath.Join(specDir, "spec.db")
+	return OpenAt(specDir, filepath.Join(specDir, "spec.db"))
+}
+
+// OpenAt is like Open but uses dbPath instead of <specDir>/spec.db.
+// Paths stored in the database stay relative to specDir, which lets
+// `oraculo db rebuild` build a replacement database side by side.
+func OpenAt(specDir, dbPath string) (*SpecStore, error) {
+	s, err := openNoMigrate(specDir, dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Migrate(); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("store: migrate: %w", err)
+	}
+
+	return s, nil
+}
+
+// OpenNoMigrate opens spec.db with the same pragmas as Open but leaves the
+// schema untouched. Used by inspection commands such as `oraculo db status`.
+func OpenNoMigrate(specDir string) (*SpecStore, error) {
+	return openNoMigrate(specDir, filepath.Join(specDir, "spec.db"))
+}
+
+func openNoMigrate(specDir, dbPath string) (*SpecStore, error) {
 	db, err := sql.Open("sqlite", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("store: open %s: %w", dbPath, err)
@@ -37,18 +65,11 @@ func Open(specDir string) (*SpecStore, error) {
 		}
 	}
 
-	s := &SpecStore{
+	return &SpecStore{
 		db:      db,
 		specDir: specDir,
 		name:    filepath.Base(specDir),
-	}
-
-	if err := s.Migrate(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("store: migrate: %w", err)
-	}
-
-	return s, nil
+	}, nil
 }
 
 // TryOpen returns a SpecStore or nil on error (fail-open helper).
@@ -69,3 +90,17 @@ func (s *SpecStore) Close() error {
 func (s *SpecStore) DB() *sql.DB {
 	return s.db
 }
+
+// Checkpoint copies every committed WAL frame into spec.db and truncates the
+// WAL, so the main database file can be copied on its own.
+func (s *SpecStore) Checkpoint() error {
+	var busy, logFrames, checkpointed int
+	err := s.db.QueryRow("PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logFrames, &checkpointed)
+	if err != nil {
+		return fmt.Errorf("store: checkpoint: %w", err)
+	}
+	if busy != 0 {
+		return fmt.Errorf("store: checkpoint: database is busy")
+	}
+	return nil
+}
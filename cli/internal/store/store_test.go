@@ -1,9 +1,11 @@
 package store
 
 import (
+	"database/sql"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -105,6 +107,20 @@ func TestSetGetMeta(t *testing.T) {
 	}
 }
 
+func TestListMeta(t *testing.T) {
+	s := openTestStore(t)
+	s.SetMeta("stage", "design")
+	s.SetMeta("status", "active")
+
+	meta, err := s.ListMeta()
+	if err != nil {
+		t.Fatalf("ListMeta: %v", err)
+	}
+	if len(meta) != 2 || meta["stage"] != "design" || meta["status"] != "active" {
+		t.Fatalf("unexpected meta: %v", meta)
+	}
+}
+
 func TestRunCRUD(t *testing.T) {
 	s := openTestStore(t)
 
@@ -151,6 +167,19 @@ func TestRunCRUD(t *testing.T) {
 		t.Fatalf("expected status=pass, got %q", r.Status)
 	}
 
+	// Move the run directory.
+	moved := "execution/waves/wave-01/execution/_archived/run-001"
+	if ok, err := s.UpdateRunCommsPath("execution/waves/wave-01/execution/run-001", moved); err != nil || !ok {
+		t.Fatalf("UpdateRunCommsPath = %v, %v", ok, err)
+	}
+	r, _ = s.GetRun("exec", 1)
+	if r.CommsPath != moved {
+		t.Fatalf("expected comms_path=%s, got %q", moved, r.CommsPath)
+	}
+	if ok, _ := s.UpdateRunCommsPath("missing/run-001", moved); ok {
+		t.Fatal("UpdateRunCommsPath reported a move for an unknown path")
+	}
+
 	// Get nonexistent run.
 	r, err = s.GetRun("nonexistent", 99)
 	if err != nil {
@@ -253,6 +282,27 @@ func TestWaveCRUD(t *testing.T) {
 	}
 }
 
+func TestSetWaveState(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.SetWaveState(2, "executing", ""); err != nil {
+		t.Fatalf("SetWaveState: %v", err)
+	}
+	got, _ := s.GetWave(2)
+	if got == nil || got.Status != "executing" || got.StateSetAt == "" {
+		t.Fatalf("wave after SetWaveState = %+v", got)
+	}
+
+	// A rescan updates the run counts but keeps the explicit state.
+	if err := s.UpsertWave(WaveRecord{WaveNumber: 2, Status: "passed", ExecRuns: 1}); err != nil {
+		t.Fatalf("UpsertWave: %v", err)
+	}
+	got, _ = s.GetWave(2)
+	if got.Status != "executing" || got.ExecRuns != 1 {
+		t.Errorf("wave after rescan = %+v, want executing with 1 exec run", got)
+	}
+}
+
 func TestTaskCRUD(t *testing.T) {
 	s := openTestStore(t)
 
@@ -290,6 +340,52 @@ func TestTaskCRUD(t *testing.T) {
 	if tasks[0].Status != "done" {
 		t.Fatalf("expected done, got %q", tasks[0].Status)
 	}
+	if tasks[0].Meta != nil {
+		t.Fatalf("expected no meta, got %v", tasks[0].Meta)
+	}
+
+	// Metadata round-trips as JSON.
+	task.Meta = map[string]string{"Owner": "alice", "Priority": "high"}
+	if err := s.SyncTask(task); err != nil {
+		t.Fatalf("SyncTask meta: %v", err)
+	}
+	tasks, _ = s.ListTasks()
+	if tasks[0].Meta["Owner"] != "alice" || tasks[0].Meta["Priority"] != "high" {
+		t.Fatalf("unexpected meta: %v", tasks[0].Meta)
+	}
+}
+
+func TestSyncTaskBatch(t *testing.T) {
+	s := openTestStore(t)
+
+	tasks := []TaskRecord{
+		{TaskID: "1", Title: "One", Status: "done"},
+		{TaskID: "2", Title: "Two", Status: "done"},
+	}
+	events := []Event{
+		{Command: "tasks mark-many", TaskID: "1", After: map[string]any{"status": "done"}},
+		{Command: "tasks mark-many", TaskID: "2", After: map[string]any{"status": "done"}},
+	}
+	if err := s.SyncTaskBatch(tasks, events); err != nil {
+		t.Fatalf("SyncTaskBatch: %v", err)
+	}
+	got, _ := s.ListTasks()
+	if len(got) != 2 {
+		t.Fatalf("tasks = %d, want 2", len(got))
+	}
+	evs, _ := s.ListEvents(EventFilter{Command: "tasks mark-many"})
+	if len(evs) != 2 {
+		t.Fatalf("events = %d, want 2", len(evs))
+	}
+
+	// An invalid event rolls back the task rows written before it.
+	bad := []Event{{Command: "tasks mark-many", Args: map[string]any{"x": make(chan int)}}}
+	if err := s.SyncTaskBatch([]TaskRecord{{TaskID: "3", Title: "Three", Status: "done"}}, bad); err == nil {
+		t.Fatal("expected error for unencodable event")
+	}
+	if got, _ := s.ListTasks(); len(got) != 2 {
+		t.Errorf("tasks after failed batch = %d, want 2", len(got))
+	}
 }
 
 func TestHandoff(t *testing.T) {
@@ -348,6 +444,70 @@ func TestHarvestArtifact(t *testing.T) {
 	}
 }
 
+func TestArtifactVersions(t *testing.T) {
+	s := openTestStore(t)
+	filePath := filepath.Join(t.TempDir(), "design.md")
+	harvest := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.HarvestArtifact("design", "design.md", filePath); err != nil {
+			t.Fatalf("HarvestArtifact: %v", err)
+		}
+	}
+
+	harvest("v1")
+	harvest("v1") // unchanged: no new version
+	harvest("v2")
+	harvest("v1") // reverting is a change too
+
+	versions, err := s.ListArtifactVersions("design.md")
+	if err != nil {
+		t.Fatalf("ListArtifactVersions: %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("expected 3 versions, got %d", len(versions))
+	}
+	for i, v := range versions {
+		if v.Version != i+1 || v.Content != "" || v.Size != 2 {
+			t.Errorf("version %d = %+v", i, v)
+		}
+	}
+
+	v2, err := s.GetArtifactVersion("design.md", 2)
+	if err != nil || v2 == nil || v2.Content != "v2" {
+		t.Fatalf("GetArtifactVersion(2) = %+v, %v", v2, err)
+	}
+	if v, err := s.GetArtifactVersion("design.md", 9); err != nil || v != nil {
+		t.Fatalf("GetArtifactVersion(9) = %+v, %v", v, err)
+	}
+
+	// Restoring history into another store keeps it and appends the current
+	// artifact content when it differs.
+	other := openTestStore(t)
+	if err := os.WriteFile(filePath, []byte("v3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.HarvestArtifact("design", "design.md", filePath); err != nil {
+		t.Fatal(err)
+	}
+	all, err := s.AllArtifactVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.RestoreArtifactVersions(all); err != nil {
+		t.Fatalf("RestoreArtifactVersions: %v", err)
+	}
+	restored, _ := other.ListArtifactVersions("design.md")
+	if len(restored) != 4 {
+		t.Fatalf("expected 4 versions after restore, got %d", len(restored))
+	}
+	if last, _ := other.GetArtifactVersion("design.md", 4); last == nil || last.Content != "v3" {
+		t.Fatalf("current content not appended: %+v", last)
+	}
+}
+
 func TestHarvestImplLog(t *testing.T) {
 	s := openTestStore(t)
 
@@ -415,6 +575,57 @@ func TestHarvestRunDir(t *testing.T) {
 	if len(subs) != 2 {
 		t.Fatalf("expected 2 subagents, got %d", len(subs))
 	}
+
+	// Re-harvesting the same run dir must not duplicate rows.
+	if err := s.HarvestRunDir(runDir, "discover", nil); err != nil {
+		t.Fatalf("HarvestRunDir (again): %v", err)
+	}
+	counts, err := s.TableCounts()
+	if err != nil {
+		t.Fatalf("TableCounts: %v", err)
+	}
+	if counts["runs"] != 1 || counts["subagents"] != 2 || counts["handoffs"] != 1 {
+		t.Fatalf("re-harvest duplicated rows: %v", counts)
+	}
+
+	// A re-harvest that finds nothing new leaves every timestamp alone.
+	const past = "2026-01-01T00:00:00Z"
+	for _, q := range []string{
+		"UPDATE runs SET created_at = ?, updated_at = ?",
+		"UPDATE subagents SET created_at = ?, updated_at = ?",
+	} {
+		if _, err := s.db.Exec(q, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.db.Exec("UPDATE handoffs SET created_at = ?", past); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.HarvestRunDir(runDir, "discover", nil); err != nil {
+		t.Fatalf("HarvestRunDir (unchanged): %v", err)
+	}
+	r, _ = s.LatestRun("discover")
+	subs, _ = s.ListSubagents(r.ID)
+	handoffs, _ := s.ListHandoffs(r.ID)
+	if r.CreatedAt != past || r.UpdatedAt != past || subs[0].UpdatedAt != past || handoffs[0].CreatedAt != past {
+		t.Errorf("unchanged re-harvest moved timestamps: run %+v, subagent %+v, handoff %+v", r, subs[0], handoffs[0])
+	}
+
+	// A changed report only moves that subagent.
+	os.WriteFile(filepath.Join(researcherDir, "report.md"), []byte("Revised report"), 0644)
+	if err := s.HarvestRunDir(runDir, "discover", nil); err != nil {
+		t.Fatalf("HarvestRunDir (changed): %v", err)
+	}
+	subs, _ = s.ListSubagents(r.ID)
+	for _, sub := range subs {
+		moved := sub.UpdatedAt != past
+		if moved != (sub.Name == "researcher") || sub.CreatedAt != past {
+			t.Errorf("subagent %s: created %s, updated %s", sub.Name, sub.CreatedAt, sub.UpdatedAt)
+		}
+	}
+	if r, _ = s.LatestRun("discover"); r.UpdatedAt != past {
+		t.Errorf("run updated_at = %s, want %s", r.UpdatedAt, past)
+	}
 }
 
 func TestIndexStore(t *testing.T) {
@@ -471,3 +682,218 @@ func TestIndexStore(t *testing.T) {
 		t.Fatalf("expected 0 results, got %d", len(results))
 	}
 }
+
+func TestIndexStoreUpgradesOldSchema(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".spec-workflow"), 0755)
+
+	// Index created before documents had path/content_hash columns.
+	db, err := sql.Open("sqlite", filepath.Join(dir, ".spec-workflow", ".oraculo-index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE documents (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, spec TEXT NOT NULL, doc_type TEXT NOT NULL,
+		phase TEXT NOT NULL, title TEXT NOT NULL, snippet TEXT, content TEXT NOT NULL,
+		created_at TEXT NOT NULL)`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ix, err := OpenIndex(dir)
+	if err != nil {
+		t.Fatalf("OpenIndex: %v", err)
+	}
+	defer ix.Close()
+
+	doc := IndexDoc{Spec: "s", Path: "tasks.md", DocType: "tasks", Phase: "planning", Title: "Tasks", Content: "alpha", ContentHash: "h1"}
+	if err := ix.UpsertDocument(doc); err != nil {
+		t.Fatalf("UpsertDocument: %v", err)
+	}
+	doc.Content, doc.ContentHash = "beta", "h2"
+	if err := ix.UpsertDocument(doc); err != nil {
+		t.Fatalf("UpsertDocument update: %v", err)
+	}
+
+	hashes, err := ix.DocumentHashes("s")
+	if err != nil || len(hashes) != 1 || hashes["tasks.md"] != "h2" {
+		t.Fatalf("DocumentHashes = %v, %v", hashes, err)
+	}
+	if r, _ := ix.Search("alpha", "", 5); len(r) != 0 {
+		t.Fatalf("stale content still searchable: %v", r)
+	}
+	if err := ix.DeleteDocument("s", "tasks.md"); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ := ix.Search("beta", "", 5); len(r) != 0 {
+		t.Fatalf("deleted document still searchable: %v", r)
+	}
+}
+
+func TestParseFTSQuery(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"user auth", `"user" "auth"`},
+		{`"user auth" flow`, `"user auth" "flow"`},
+		{"auth* tok", `"auth"* "tok"`},
+		{`"user au"*`, `"user au"*`},
+		{"a OR b", `"a" OR "b"`},
+		{"a NOT b", `"a" NOT "b"`},
+		{"(a OR b) AND c", `( "a" OR "b" ) AND "c"`},
+		{"wave-01 task:3", `"wave-01" "task:3"`},
+		{"and or", `"and" "or"`},
+		{"(a OR b", `"a" OR "b"`},
+		{"a NOT (b OR c)", `"a" NOT ( "b" OR "c" )`},
+		{`say "hi`, `"say" "hi"`},
+	}
+	for _, tc := range cases {
+		got, err := ParseFTSQuery(tc.in)
+		if err != nil {
+			t.Errorf("ParseFTSQuery(%q): %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseFTSQuery(%q) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+	for _, in := range []string{"", "  ", "AND OR", "()", `""`, "*",
+		"NOT draft", "draft NOT", "OR a AND", "a AND OR b", "a AND ()", "(NOT a) b", "a (b OR)"} {
+		if got, err := ParseFTSQuery(in); err == nil {
+			t.Errorf("ParseFTSQuery(%q) = %s, want error", in, got)
+		}
+	}
+}
+
+func TestSearchFiltered(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".spec-workflow"), 0755)
+	ix, err := OpenIndex(dir)
+	if err != nil {
+		t.Fatalf("OpenIndex: %v", err)
+	}
+	defer ix.Close()
+
+	ix.IndexSpec("alpha", "execution", "/a/spec.db")
+	ix.IndexSpec("beta", "design", "/b/spec.db")
+	docs := []IndexDoc{
+		{Spec: "alpha", Path: "design.md", DocType: "design", Phase: "design", Title: "Token refresh design", Content: "Rotate the refresh token on every use."},
+		{Spec: "alpha", Path: "execution/_implementation-logs/task-1.md", DocType: "impl_log", Phase: "execution", Title: "Task 1", Content: "Implemented token rotation in the session store."},
+		{Spec: "beta", Path: "requirements.md", DocType: "requirements", Phase: "requirements", Title: "Billing", Content: "Invoices must show the token count."},
+	}
+	for _, d := range docs {
+		if err := ix.UpsertDocument(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count := func(query string, f SearchFilter) int {
+		t.Helper()
+		r, err := ix.SearchFiltered(query, f)
+		if err != nil {
+			t.Fatalf("SearchFiltered(%q, %+v): %v", query, f, err)
+		}
+		return len(r)
+	}
+
+	if n := count("token", SearchFilter{Limit: 10}); n != 3 {
+		t.Errorf("unfiltered = %d, want 3", n)
+	}
+	if n := count("token", SearchFilter{DocType: "impl_log", Limit: 10}); n != 1 {
+		t.Errorf("doc_type filter = %d, want 1", n)
+	}
+	if n := count("token", SearchFilter{Phase: "design", Limit: 10}); n != 1 {
+		t.Errorf("phase filter = %d, want 1", n)
+	}
+	if n := count("token", SearchFilter{Stage: "design", Limit: 10}); n != 1 {
+		t.Errorf("stage filter = %d, want 1", n)
+	}
+	if n := count("token", SearchFilter{Since: "2000-01-01T00:00:00Z", Until: "2000-12-31T00:00:00Z", Limit: 10}); n != 0 {
+		t.Errorf("date range filter = %d, want 0", n)
+	}
+	if n := count("token", SearchFilter{Limit: 2, Offset: 2}); n != 1 {
+		t.Errorf("offset page = %d, want 1", n)
+	}
+	if n := count("rot*", SearchFilter{Limit: 10}); n != 2 {
+		t.Errorf("prefix = %d, want 2", n)
+	}
+	if n := count(`"refresh token"`, SearchFilter{Limit: 10}); n != 1 {
+		t.Errorf("phrase = %d, want 1", n)
+	}
+	if n := count("token NOT rotation", SearchFilter{Limit: 10}); n != 2 {
+		t.Errorf("NOT = %d, want 2", n)
+	}
+
+	r, err := ix.SearchFiltered("invoices", SearchFilter{})
+	if err != nil || len(r) != 1 {
+		t.Fatalf("SearchFiltered(invoices) = %v, %v", r, err)
+	}
+	want := HighlightOpen + "Invoices" + HighlightClose
+	if !strings.Contains(r[0].Highlight, want) {
+		t.Errorf("highlight = %q, want it to contain %q", r[0].Highlight, want)
+	}
+	if r[0].Stage != "design" || r[0].Path != "requirements.md" {
+		t.Errorf("unexpected result: %+v", r[0])
+	}
+}
+
+func TestEvents(t *testing.T) {
+	s := openTestStore(t)
+	t.Setenv("ORACULO_ACTOR", "alice")
+	t.Setenv("ORACULO_SESSION_ID", "sess-1")
+
+	w1, w2 := 1, 2
+	events := []Event{
+		{Command: "dispatch-init", Wave: &w1, Args: map[string]any{"command": "exec"}},
+		{Command: "task-mark", TaskID: "3", Before: map[string]any{"status": "pending"}, After: map[string]any{"status": "done"}},
+		{Command: "wave-update", Wave: &w2, After: map[string]any{"status": "pass"}},
+		{Command: "task-mark", TaskID: "4", Timestamp: "2020-01-01T00:00:00Z", Actor: "bob"},
+	}
+	for _, e := range events {
+		if err := s.RecordEvent(e); err != nil {
+			t.Fatalf("RecordEvent: %v", err)
+		}
+	}
+
+	all, err := s.ListEvents(EventFilter{})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(all))
+	}
+	if all[0].Actor != "alice" || all[0].SessionID != "sess-1" {
+		t.Errorf("actor not stamped: %+v", all[0])
+	}
+	if all[3].Actor != "bob" || all[3].SessionID != "" {
+		t.Errorf("explicit timestamp should keep actor as given: %+v", all[3])
+	}
+	after, ok := all[1].After.(map[string]any)
+	if !ok || after["status"] != "done" {
+		t.Errorf("after state not decoded: %#v", all[1].After)
+	}
+
+	byWave, _ := s.ListEvents(EventFilter{Wave: &w2})
+	if len(byWave) != 1 || byWave[0].Command != "wave-update" {
+		t.Errorf("wave filter: %+v", byWave)
+	}
+	byTask, _ := s.ListEvents(EventFilter{TaskID: "3"})
+	if len(byTask) != 1 {
+		t.Errorf("task filter: %+v", byTask)
+	}
+	old, _ := s.ListEvents(EventFilter{Until: "2021-01-01T00:00:00Z"})
+	if len(old) != 1 || old[0].TaskID != "4" {
+		t.Errorf("until filter: %+v", old)
+	}
+	recent, _ := s.ListEvents(EventFilter{Since: "2021-01-01T00:00:00Z", Limit: 2})
+	if len(recent) != 2 || recent[0].Command != "task-mark" || recent[1].Command != "wave-update" {
+		t.Errorf("since+limit filter: %+v", recent)
+	}
+
+	// The journal is append-only.
+	if _, err := s.DB().Exec("DELETE FROM events"); err == nil {
+		t.Error("expected delete from events to be rejected")
+	}
+	if _, err := s.DB().Exec("UPDATE events SET command = 'x'"); err == nil {
+		t.Error("expected update of events to be rejected")
+	}
+}
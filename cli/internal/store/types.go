@@ -14,17 +14,32 @@ type Artifact struct {
 	UpdatedAt    string `json:"updated_at"`
 }
 
+// ArtifactVersion is one recorded content of an artifact. Content is only
+// filled by queries that load a specific version.
+type ArtifactVersion struct {
+	RelPath     string `json:"rel_path"`
+	Version     int    `json:"version"`
+	ContentHash string `json:"content_hash"`
+	Size        int    `json:"size"`
+	Content     string `json:"content,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
+
 // Run represents a single command dispatch run (run-NNN directory).
+// Collected is "compressed" or "deleted" once `oraculo gc` has removed the
+// run directory, and empty while it is on disk.
 type Run struct {
-	ID         int64  `json:"id"`
-	Command    string `json:"command"`
-	RunNumber  int    `json:"run_number"`
-	Phase      string `json:"phase"`
-	WaveNumber *int   `json:"wave_number,omitempty"`
-	CommsPath  string `json:"comms_path"`
-	Status     string `json:"status"`
-	CreatedAt  string `json:"created_at"`
-	UpdatedAt  string `json:"updated_at"`
+	ID          int64  `json:"id"`
+	Command     string `json:"command"`
+	RunNumber   int    `json:"run_number"`
+	Phase       string `json:"phase"`
+	WaveNumber  *int   `json:"wave_number,omitempty"`
+	CommsPath   string `json:"comms_path"`
+	Status      string `json:"status"`
+	Collected   string `json:"collected,omitempty"`
+	CollectedAt string `json:"collected_at,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
 }
 
 // Subagent represents a subagent within a run.
@@ -41,7 +56,9 @@ type Subagent struct {
 	UpdatedAt  string `json:"updated_at"`
 }
 
-// WaveRecord represents the state of a single execution wave.
+// WaveRecord represents the state of a single execution wave. Status is
+// the wave state; StateSetAt is set when it was set explicitly rather than
+// inferred from the wave directory.
 type WaveRecord struct {
 	WaveNumber    int    `json:"wave_number"`
 	Status        string `json:"status"`
@@ -51,6 +68,7 @@ type WaveRecord struct {
 	SummaryText   string `json:"summary_text,omitempty"`
 	SummarySource string `json:"summary_source,omitempty"`
 	StaleFlag     bool   `json:"stale_flag"`
+	StateSetAt    string `json:"state_set_at,omitempty"`
 	UpdatedAt     string `json:"updated_at"`
 }
 
@@ -64,7 +82,10 @@ type TaskRecord struct {
 	Files      string `json:"files,omitempty"`
 	TDD        bool   `json:"tdd"`
 	IsDeferred bool   `json:"is_deferred"`
-	UpdatedAt  string `json:"updated_at"`
+	// Meta holds any other "Key: value" metadata lines of the task,
+	// stored as a JSON object.
+	Meta      map[string]string `json:"meta,omitempty"`
+	UpdatedAt string            `json:"updated_at"`
 }
 
 // ImplLog represents a task's implementation log stored in the database.
@@ -101,12 +122,72 @@ type CompletionRecord struct {
 	GeneratedAt string `json:"generated_at"`
 }
 
+// IndexDoc is a file-backed document in the global index. Path is relative
+// to the spec directory; ContentHash lets re-indexing skip unchanged files.
+type IndexDoc struct {
+	Spec        string `json:"spec"`
+	Path        string `json:"path"`
+	DocType     string `json:"doc_type"`
+	Phase       string `json:"phase"`
+	Title       string `json:"title"`
+	Snippet     string `json:"snippet"`
+	Content     string `json:"-"`
+	ContentHash string `json:"content_hash"`
+}
+
 // SearchResult represents a document found via FTS5 search.
+// TitleHighlight and Highlight carry the matched terms wrapped in
+// HighlightOpen/HighlightClose.
 type SearchResult struct {
-	Spec    string  `json:"spec"`
-	DocType string  `json:"doc_type"`
-	Phase   string  `json:"phase"`
-	Title   string  `json:"title"`
-	Snippet string  `json:"snippet"`
-	Rank    float64 `json:"rank"`
+	Spec           string  `json:"spec"`
+	DocType        string  `json:"doc_type"`
+	Phase          string  `json:"phase"`
+	Stage          string  `json:"stage,omitempty"`
+	Path           string  `json:"path,omitempty"`
+	Title          string  `json:"title"`
+	Snippet        string  `json:"snippet"`
+	TitleHighlight string  `json:"title_highlight"`
+	Highlight      string  `json:"highlight"`
+	IndexedAt      string  `json:"indexed_at"`
+	Rank           float64 `json:"rank"`
+}
+
+// SearchFilter narrows and pages IndexStore.SearchFiltered. Empty fields do
+// not filter. Since and Until are RFC3339 bounds on the time a document was
+// last indexed; Stage matches the spec's stage in the index.
+type SearchFilter struct {
+	Spec    string
+	DocType string
+	Phase   string
+	Stage   string
+	Since   string
+	Until   string
+	Limit   int
+	Offset  int
+}
+
+// Event is one entry in the append-only events journal. Args, Before and
+// After are stored as JSON and decoded back into generic values on read.
+type Event struct {
+	ID        int64  `json:"id"`
+	Timestamp string `json:"ts"`
+	Actor     string `json:"actor,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	Command   string `json:"command"`
+	Wave      *int   `json:"wave,omitempty"`
+	TaskID    string `json:"task_id,omitempty"`
+	Args      any    `json:"args,omitempty"`
+	Before    any    `json:"before,omitempty"`
+	After     any    `json:"after,omitempty"`
+}
+
+// EventFilter narrows ListEvents. Zero values match everything; Since and
+// Until are inclusive RFC3339 timestamps.
+type EventFilter struct {
+	Wave    *int
+	TaskID  string
+	Command string
+	Since   string
+	Until   string
+	Limit   int
 }
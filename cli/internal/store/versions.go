@@ -0,0 +1,147 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// appendArtifactVersion adds content as the next version of relPath unless
+// it matches the latest version already recorded. It reports whether a row
+// was added.
+func appendArtifactVersion(tx *sql.Tx, relPath, content, hash, ts string) (bool, error) {
+	var latest int
+	var latestHash sql.NullString
+	err := tx.QueryRow(`
+		SELECT version, content_hash FROM artifact_versions
+		WHERE rel_path = ? ORDER BY version DESC LIMIT 1`, relPath,
+	).Scan(&latest, &latestHash)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("store: latest artifact version: %w", err)
+	}
+	if latestHash.Valid && latestHash.String == hash {
+		return false, nil
+	}
+	_, err = tx.Exec(`
+		INSERT INTO artifact_versions (rel_path, version, content, content_hash, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		relPath, latest+1, content, hash, ts,
+	)
+	if err != nil {
+		return false, fmt.Errorf("store: insert artifact version: %w", err)
+	}
+	return true, nil
+}
+
+// ListArtifactVersions returns the versions of relPath, oldest first,
+// without their content.
+func (s *SpecStore) ListArtifactVersions(relPath string) ([]ArtifactVersion, error) {
+	rows, err := s.db.Query(`
+		SELECT rel_path, version, content_hash, length(CAST(content AS BLOB)), created_at
+		FROM artifact_versions WHERE rel_path = ? ORDER BY version`, relPath,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: list artifact versions: %w", err)
+	}
+	defer rows.Close()
+
+	var result []ArtifactVersion
+	for rows.Next() {
+		var v ArtifactVersion
+		if err := rows.Scan(&v.RelPath, &v.Version, &v.ContentHash, &v.Size, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("store: scan artifact version: %w", err)
+		}
+		result = append(result, v)
+	}
+	return result, rows.Err()
+}
+
+// GetArtifactVersion returns one version of relPath with its content, or
+// nil if it does not exist.
+func (s *SpecStore) GetArtifactVersion(relPath string, version int) (*ArtifactVersion, error) {
+	v := &ArtifactVersion{}
+	err := s.db.QueryRow(`
+		SELECT rel_path, version, content_hash, content, created_at
+		FROM artifact_versions WHERE rel_path = ? AND version = ?`, relPath, version,
+	).Scan(&v.RelPath, &v.Version, &v.ContentHash, &v.Content, &v.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store: get artifact version: %w", err)
+	}
+	v.Size = len(v.Content)
+	return v, nil
+}
+
+// AllArtifactVersions returns every version of every artifact, with
+// content, ordered by path and version.
+func (s *SpecStore) AllArtifactVersions() ([]ArtifactVersion, error) {
+	rows, err := s.db.Query(`
+		SELECT rel_path, version, content_hash, content, created_at
+		FROM artifact_versions ORDER BY rel_path, version`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: all artifact versions: %w", err)
+	}
+	defer rows.Close()
+
+	var result []ArtifactVersion
+	for rows.Next() {
+		var v ArtifactVersion
+		if err := rows.Scan(&v.RelPath, &v.Version, &v.ContentHash, &v.Content, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("store: scan artifact version: %w", err)
+		}
+		v.Size = len(v.Content)
+		result = append(result, v)
+	}
+	return result, rows.Err()
+}
+
+// RestoreArtifactVersions replaces the history of each path in versions
+// with the given rows (as read from another spec.db by AllArtifactVersions).
+// If the current artifact content differs from the last restored version,
+// it is appended as a new version so the history stays complete.
+func (s *SpecStore) RestoreArtifactVersions(versions []ArtifactVersion) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("store: restore artifact versions begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	paths := make(map[string]bool)
+	var order []string
+	for _, v := range versions {
+		if !paths[v.RelPath] {
+			paths[v.RelPath] = true
+			order = append(order, v.RelPath)
+			if _, err := tx.Exec("DELETE FROM artifact_versions WHERE rel_path = ?", v.RelPath); err != nil {
+				return fmt.Errorf("store: restore artifact versions: %w", err)
+			}
+		}
+		_, err := tx.Exec(`
+			INSERT INTO artifact_versions (rel_path, version, content, content_hash, created_at)
+			VALUES (?, ?, ?, ?, ?)`,
+			v.RelPath, v.Version, v.Content, v.ContentHash, v.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("store: restore artifact version %s@%d: %w", v.RelPath, v.Version, err)
+		}
+	}
+
+	for _, relPath := range order {
+		var content, hash, ts string
+		err := tx.QueryRow(
+			"SELECT content, content_hash, updated_at FROM artifacts WHERE rel_path = ?", relPath,
+		).Scan(&content, &hash, &ts)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("store: restore artifact versions: %w", err)
+		}
+		if _, err := appendArtifactVersion(tx, relPath, content, hash, ts); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
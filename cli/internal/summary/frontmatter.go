@@ -36,6 +36,8 @@ type ProgressFrontmatter struct {
 	TasksTotal      int       `yaml:"tasks_total"`
 	TasksPending    int       `yaml:"tasks_pending"`
 	TasksInProgress int       `yaml:"tasks_in_progress,omitempty"`
+	TasksBlocked    int       `yaml:"tasks_blocked,omitempty"`
+	TasksSkipped    int       `yaml:"tasks_skipped,omitempty"`
 	CurrentWave     int       `yaml:"current_wave"`
 	WavesTotal      int       `yaml:"waves_total"`
 	FilesChanged    []string  `yaml:"files_changed,omitempty"`
@@ -27,9 +27,9 @@ func GenerateCompletion(specDir string, doc tasks.Document, waves []wave.WaveSta
 	// Count checkpoint passes/failures from waves.
 	var passes, failures int
 	for _, w := range waves {
-		if w.Status == "complete" {
+		if w.Status == wave.StatePassed {
 			passes++
-		} else if w.Status == "blocked" {
+		} else if w.Status == wave.StateBlocked {
 			failures++
 		}
 	}
@@ -75,21 +75,11 @@ func GenerateProgress(specDir string, stage string, doc *tasks.Document, waves [
 	files := CollectFilesChanged(doc.Tasks)
 	techs := InferTechnologies(files)
 
-	var done, pending, inProgress int
-	for _, t := range doc.Tasks {
-		switch t.Status {
-		case "done":
-			done++
-		case "pending":
-			pending++
-		case "in_progress":
-			inProgress++
-		}
-	}
+	counts := doc.Count()
 
 	var currentWave int
 	for _, w := range waves {
-		if w.Status == "in_progress" || w.Status == "pending" {
+		if w.Status != wave.StatePassed && w.Status != wave.StateBlocked {
 			currentWave = w.WaveNum
 			break
 		}
@@ -101,10 +91,12 @@ func GenerateProgress(specDir string, stage string, doc *tasks.Document, waves [
 		Status:          "in_progress",
 		Stage:           stage,
 		AsOf:            time.Now().UTC(),
-		TasksDone:       done,
+		TasksDone:       counts.Done,
 		TasksTotal:      len(doc.Tasks),
-		TasksPending:    pending,
-		TasksInProgress: inProgress,
+		TasksPending:    counts.Pending,
+		TasksInProgress: counts.InProgress,
+		TasksBlocked:    counts.Blocked,
+		TasksSkipped:    counts.Skipped,
 		CurrentWave:     currentWave,
 		WavesTotal:      len(waves),
 		FilesChanged:    files,
@@ -154,6 +146,19 @@ func renderCompletionBody(specName string, taskList []tasks.Task, waves []wave.W
 	}
 	b.WriteString("\n")
 
+	// Skipped tasks, with the recorded reasons.
+	var skipped []string
+	for _, t := range taskList {
+		if t.Status == "skipped" {
+			skipped = append(skipped, fmt.Sprintf("- Task %s: %s — %s\n", t.ID, t.Title, t.Reason))
+		}
+	}
+	if len(skipped) > 0 {
+		b.WriteString("## Skipped Tasks\n\n")
+		b.WriteString(strings.Join(skipped, ""))
+		b.WriteString("\n")
+	}
+
 	// Wave History table.
 	b.WriteString("## Wave History\n\n")
 	b.WriteString("| Wave | Status | Tasks | Execution Runs | Checkpoint |\n")
@@ -215,7 +220,7 @@ func renderProgressBody(specName string, taskList []tasks.Task, waves []wave.Wav
 	b.WriteString(fmt.Sprintf("# Progress Summary: %s\n\n", specName))
 
 	// Task Status overview.
-	var done, pending, inProgress int
+	var done, pending, inProgress, blocked, skipped int
 	for _, t := range taskList {
 		switch t.Status {
 		case "done":
@@ -224,6 +229,10 @@ func renderProgressBody(specName string, taskList []tasks.Task, waves []wave.Wav
 			pending++
 		case "in_progress":
 			inProgress++
+		case "blocked":
+			blocked++
+		case "skipped":
+			skipped++
 		}
 	}
 	total := len(taskList)
@@ -239,13 +248,19 @@ func renderProgressBody(specName string, taskList []tasks.Task, waves []wave.Wav
 	if pending > 0 {
 		b.WriteString(fmt.Sprintf("  Pending: %d\n", pending))
 	}
+	if blocked > 0 {
+		b.WriteString(fmt.Sprintf("  Blocked: %d\n", blocked))
+	}
+	if skipped > 0 {
+		b.WriteString(fmt.Sprintf("  Skipped: %d\n", skipped))
+	}
 	b.WriteString("\n")
 
 	// Task table.
 	b.WriteString("| # | Task | Status | Wave |\n")
 	b.WriteString("|---|------|--------|------|\n")
 	for _, t := range taskList {
-		b.WriteString(fmt.Sprintf("| %s | %s | %s | %d |\n", t.ID, t.Title, t.Status, t.Wave))
+		b.WriteString(fmt.Sprintf("| %s | %s | %s | %d |\n", t.ID, t.Title, taskStatusCell(t), t.Wave))
 	}
 	b.WriteString("\n")
 
@@ -257,9 +272,9 @@ func renderProgressBody(specName string, taskList []tasks.Task, waves []wave.Wav
 		for _, w := range waves {
 			taskIDs := strings.Join(w.TaskIDs, ", ")
 			checkpoint := "---"
-			if w.Status == "complete" {
+			if w.Status == wave.StatePassed {
 				checkpoint = "passed"
-			} else if w.Status == "blocked" {
+			} else if w.Status == wave.StateBlocked {
 				checkpoint = "failed"
 			}
 			b.WriteString(fmt.Sprintf("| %d | %s | %s | %s |\n",
@@ -290,3 +305,12 @@ func queryImplLogs(s *store.SpecStore) []store.ImplLog {
 	}
 	return logs
 }
+
+// taskStatusCell renders a task status for a summary table, with the
+// reason of a blocked or skipped task.
+func taskStatusCell(t tasks.Task) string {
+	if t.Reason == "" {
+		return t.Status
+	}
+	return fmt.Sprintf("%s: %s", t.Status, strings.ReplaceAll(t.Reason, "|", "\\|"))
+}
@@ -282,8 +282,8 @@ func TestGenerateCompletion(t *testing.T) {
 		},
 	}
 	waves := []wave.WaveState{
-		{WaveNum: 1, Status: "complete", TaskIDs: []string{"1", "2"}, ExecRuns: 1, CheckRuns: 1},
-		{WaveNum: 2, Status: "complete", TaskIDs: []string{"3"}, ExecRuns: 1, CheckRuns: 1},
+		{WaveNum: 1, Status: wave.StatePassed, TaskIDs: []string{"1", "2"}, ExecRuns: 1, CheckRuns: 1},
+		{WaveNum: 2, Status: wave.StatePassed, TaskIDs: []string{"3"}, ExecRuns: 1, CheckRuns: 1},
 	}
 
 	cs, err := GenerateCompletion("", doc, waves, nil)
@@ -349,8 +349,8 @@ func TestGenerateProgress(t *testing.T) {
 		},
 	}
 	waves := []wave.WaveState{
-		{WaveNum: 1, Status: "complete", TaskIDs: []string{"1"}, ExecRuns: 1, CheckRuns: 1},
-		{WaveNum: 2, Status: "in_progress", TaskIDs: []string{"2", "3"}, ExecRuns: 1},
+		{WaveNum: 1, Status: wave.StatePassed, TaskIDs: []string{"1"}, ExecRuns: 1, CheckRuns: 1},
+		{WaveNum: 2, Status: wave.StateExecuting, TaskIDs: []string{"2", "3"}, ExecRuns: 1},
 	}
 
 	ps, err := GenerateProgress("", "execution", doc, waves)
@@ -407,6 +407,30 @@ func TestGenerateProgress(t *testing.T) {
 	}
 }
 
+func TestGenerateProgressBlockedAndSkipped(t *testing.T) {
+	doc := &tasks.Document{
+		Frontmatter: tasks.Frontmatter{Spec: "my-feature"},
+		Tasks: []tasks.Task{
+			{ID: "1", Title: "Task one", Status: "done", Wave: 1},
+			{ID: "2", Title: "Task two", Status: "blocked", Wave: 1, Reason: "needs credentials"},
+			{ID: "3", Title: "Task three", Status: "skipped", Wave: 2, Reason: "out of scope"},
+		},
+	}
+
+	ps, err := GenerateProgress("", "execution", doc, nil)
+	if err != nil {
+		t.Fatalf("GenerateProgress error: %v", err)
+	}
+	if ps.Frontmatter.TasksBlocked != 1 || ps.Frontmatter.TasksSkipped != 1 || ps.Frontmatter.TasksPending != 0 {
+		t.Errorf("frontmatter counts = %+v", ps.Frontmatter)
+	}
+	for _, want := range []string{"  Blocked: 1\n", "  Skipped: 1\n", "| 2 | Task two | blocked: needs credentials | 1 |"} {
+		if !strings.Contains(ps.Body, want) {
+			t.Errorf("body missing %q:\n%s", want, ps.Body)
+		}
+	}
+}
+
 func TestGenerateProgressEmpty(t *testing.T) {
 	doc := &tasks.Document{
 		Frontmatter: tasks.Frontmatter{Spec: "empty-spec"},
@@ -0,0 +1,196 @@
+package tasks
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/lucas-stellet/oraculo/internal/config"
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+// calibrationLift is how far a factor's troubled rate must sit above (or
+// below) the overall rate before its weight moves by one point.
+const calibrationLift = 0.25
+
+// HistoryTask is a task of a past wave together with that wave's outcome.
+// A wave is troubled when a checkpoint blocked it or an inline audit had to
+// retry.
+type HistoryTask struct {
+	Task     Task
+	Troubled bool
+}
+
+// Calibration holds per-factor weight adjustments learned from history.
+type Calibration struct {
+	Samples     int            `json:"samples"`
+	Troubled    int            `json:"troubled"`
+	Adjustments map[string]int `json:"adjustments"`
+}
+
+func (c *Calibration) delta(key string) int {
+	if c == nil {
+		return 0
+	}
+	return c.Adjustments[key]
+}
+
+// CollectHistory returns the tasks of the spec in specDir whose wave has an
+// outcome in spec.db: a finished checkpoint or an audit retry. Task
+// metadata comes from tasks.md when it parses, otherwise from the stored
+// task rows. Skipped tasks are left out.
+func CollectHistory(s *store.SpecStore, specDir string) ([]HistoryTask, error) {
+	runs, err := s.ListRuns()
+	if err != nil {
+		return nil, err
+	}
+	audits, err := s.ListEvents(store.EventFilter{Command: "audit-iteration advance"})
+	if err != nil {
+		return nil, err
+	}
+
+	troubled := make(map[int]bool) // wave -> troubled; absent means no outcome
+	for _, r := range runs {
+		if r.Command != "checkpoint" || r.WaveNumber == nil {
+			continue
+		}
+		switch r.Status {
+		case "blocked":
+			troubled[*r.WaveNumber] = true
+		case "pass":
+			if _, ok := troubled[*r.WaveNumber]; !ok {
+				troubled[*r.WaveNumber] = false
+			}
+		}
+	}
+	for _, e := range audits {
+		if e.Wave != nil {
+			troubled[*e.Wave] = true
+		}
+	}
+	if len(troubled) == 0 {
+		return nil, nil
+	}
+
+	var all []Task
+	if doc, err := ParseFile(specdir.TasksPath(specDir)); err == nil && len(doc.Tasks) > 0 {
+		all = doc.Tasks
+	} else {
+		recs, err := s.ListTasks()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range recs {
+			all = append(all, taskFromRecord(r))
+		}
+	}
+
+	var out []HistoryTask
+	for _, t := range all {
+		if t.Status == "skipped" {
+			continue
+		}
+		if bad, ok := troubled[t.Wave]; ok {
+			out = append(out, HistoryTask{Task: t, Troubled: bad})
+		}
+	}
+	return out, nil
+}
+
+// ReadHistory collects the history of the spec in specDir from its spec.db
+// without migrating it: calibration reads other specs' databases, which are
+// not this command's to change. A database at an older schema is reported
+// and not read.
+func ReadHistory(specDir string) ([]HistoryTask, error) {
+	if !specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+		return nil, nil
+	}
+	s, err := store.OpenNoMigrate(specDir)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	pending, err := s.PendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		version, _ := s.SchemaVersion()
+		return nil, fmt.Errorf("spec.db is at schema version %d, older than %d (run oraculo db migrate)",
+			version, store.LatestSchemaVersion())
+	}
+	return CollectHistory(s, specDir)
+}
+
+// taskFromRecord rebuilds the fields scoring needs from a stored task row.
+// The row keeps TDD only as a flag, so it maps to "yes".
+func taskFromRecord(r store.TaskRecord) Task {
+	t := Task{
+		ID:         r.TaskID,
+		Title:      r.Title,
+		Status:     r.Status,
+		Files:      r.Files,
+		IsDeferred: r.IsDeferred,
+		Meta:       r.Meta,
+	}
+	if r.Wave != nil {
+		t.Wave = *r.Wave
+	}
+	if r.DependsOn != "" {
+		t.DependsOn = parseIDList(r.DependsOn)
+	}
+	if r.TDD {
+		t.TDD = "yes"
+	}
+	return t
+}
+
+// Calibrate compares, for each factor seen on at least
+// cfg.CalibrationMinSamples history tasks, the share of those tasks that
+// landed in a troubled wave with the share over all history tasks. A factor
+// troubled calibrationLift more often than average gains one point; one
+// troubled calibrationLift less often loses one. Factors disabled with a
+// weight of 0 are not calibrated.
+func Calibrate(history []HistoryTask, cfg config.ComplexityConfig) *Calibration {
+	c := &Calibration{Samples: len(history), Adjustments: map[string]int{}}
+	type tally struct{ n, troubled int }
+	byKey := make(map[string]*tally)
+	for _, h := range history {
+		if h.Troubled {
+			c.Troubled++
+		}
+		for _, f := range complexityFactors(h.Task, cfg) {
+			if f.weight == 0 {
+				continue
+			}
+			t, ok := byKey[f.key]
+			if !ok {
+				t = &tally{}
+				byKey[f.key] = t
+			}
+			t.n++
+			if h.Troubled {
+				t.troubled++
+			}
+		}
+	}
+	if c.Samples == 0 {
+		return c
+	}
+
+	base := float64(c.Troubled) / float64(c.Samples)
+	minSamples := max(cfg.CalibrationMinSamples, 1)
+	for key, t := range byKey {
+		if t.n < minSamples {
+			continue
+		}
+		rate := float64(t.troubled) / float64(t.n)
+		switch {
+		case rate-base >= calibrationLift:
+			c.Adjustments[key] = 1
+		case base-rate >= calibrationLift:
+			c.Adjustments[key] = -1
+		}
+	}
+	return c
+}
@@ -1,76 +1,140 @@
 package tasks
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/config"
 )
 
-// ScoreComplexity returns a complexity score and model routing hint for a task.
+// factor is one complexity factor that applies to a task. Key identifies
+// it for calibration (e.g. "files_many", "keyword:migration").
+type factor struct {
+	key    string
+	label  string
+	weight int
+}
+
+// ScoreComplexity scores a task with the default [complexity] settings.
 // Factors: number of files, dependency count, TDD requirement, deferred status.
 // Low (1-3) -> haiku, Medium (4-6) -> sonnet, High (7+) -> opus
 func ScoreComplexity(task Task) ComplexityResult {
-	score := 0
-	var factors []string
+	return ScoreComplexityWith(task, config.Defaults().Complexity, nil)
+}
+
+// ScoreComplexityWith scores a task with the weights, thresholds and extra
+// factors of cfg. A non-nil cal adjusts the weight of calibrated factors;
+// factors disabled with a weight of 0 stay disabled.
+func ScoreComplexityWith(task Task, cfg config.ComplexityConfig, cal *Calibration) ComplexityResult {
+	score, adjustment := 0, 0
+	var labels []string
+	for _, f := range complexityFactors(task, cfg) {
+		if f.weight == 0 {
+			continue // disabled in [complexity.weights]
+		}
+		label := f.label
+		if d := max(cal.delta(f.key), -f.weight); d != 0 {
+			// A calibrated weight never drops below zero.
+			adjustment += d
+			f.weight += d
+			label = fmt.Sprintf("%s (%+d calibrated)", label, d)
+		}
+		score += f.weight
+		labels = append(labels, label)
+	}
+
+	// Ensure minimum score of 1
+	if score < 1 {
+		score = 1
+	}
+
+	return ComplexityResult{
+		TaskID:     task.ID,
+		Score:      score,
+		ModelHint:  modelHint(score, cfg),
+		Factors:    labels,
+		Adjustment: adjustment,
+	}
+}
+
+// complexityFactors lists the factors that apply to task with their
+// configured weights.
+func complexityFactors(task Task, cfg config.ComplexityConfig) []factor {
+	w := cfg.Weights
+	var fs []factor
 
 	// Factor 1: Number of files (count backtick-delimited entries)
 	fileCount := countFiles(task.Files)
 	switch {
 	case fileCount >= 5:
-		score += 3
-		factors = append(factors, "many files (5+)")
+		fs = append(fs, factor{"files_many", "many files (5+)", w.FilesMany})
 	case fileCount >= 3:
-		score += 2
-		factors = append(factors, "moderate files (3-4)")
+		fs = append(fs, factor{"files_moderate", "moderate files (3-4)", w.FilesModerate})
 	case fileCount >= 1:
-		score += 1
-		factors = append(factors, "few files (1-2)")
+		fs = append(fs, factor{"files_few", "few files (1-2)", w.FilesFew})
 	}
 
 	// Factor 2: Dependency count
 	depCount := len(task.DependsOn)
 	switch {
 	case depCount >= 3:
-		score += 3
-		factors = append(factors, "many dependencies (3+)")
+		fs = append(fs, factor{"deps_many", "many dependencies (3+)", w.DepsMany})
 	case depCount >= 2:
-		score += 2
-		factors = append(factors, "moderate dependencies (2)")
+		fs = append(fs, factor{"deps_moderate", "moderate dependencies (2)", w.DepsModerate})
 	case depCount == 1:
-		score += 1
-		factors = append(factors, "single dependency")
+		fs = append(fs, factor{"deps_single", "single dependency", w.DepsSingle})
 	}
 
 	// Factor 3: TDD requirement
 	tddLower := strings.ToLower(strings.TrimSpace(task.TDD))
 	if tddLower == "yes" || tddLower == "true" || tddLower == "required" {
-		score += 2
-		factors = append(factors, "TDD required")
+		fs = append(fs, factor{"tdd", "TDD required", w.TDD})
 	}
 
 	// Factor 4: Deferred status (deferred tasks are often more complex/risky)
 	if task.IsDeferred {
-		score += 1
-		factors = append(factors, "deferred task")
+		fs = append(fs, factor{"deferred", "deferred task", w.Deferred})
 	}
 
-	// Ensure minimum score of 1
-	if score < 1 {
-		score = 1
+	// Factor 5: Title keywords
+	for _, kw := range cfg.Keywords {
+		kw = strings.ToLower(strings.TrimSpace(kw))
+		if kw != "" && containsWord(task.Title, kw) {
+			fs = append(fs, factor{"keyword:" + kw, fmt.Sprintf("keyword %q", kw), w.Keyword})
+		}
 	}
 
-	return ComplexityResult{
-		TaskID:    task.ID,
-		Score:     score,
-		ModelHint: modelHint(score),
-		Factors:   factors,
+	// Factor 6: File types, once per listed suffix
+	files := task.FileList()
+	for _, ft := range cfg.FileTypes {
+		ft = strings.ToLower(strings.TrimSpace(ft))
+		if ft == "" {
+			continue
+		}
+		for _, f := range files {
+			if strings.HasSuffix(strings.ToLower(f), ft) {
+				fs = append(fs, factor{"file_type:" + ft, "file type " + ft, w.FileType})
+				break
+			}
+		}
 	}
+	return fs
+}
+
+// containsWord reports whether s contains word as a whole word,
+// case-insensitively.
+func containsWord(s, word string) bool {
+	re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`)
+	return re.MatchString(s)
 }
 
 // modelHint maps a numeric score to a model routing hint.
-func modelHint(score int) string {
+func modelHint(score int, cfg config.ComplexityConfig) string {
 	switch {
-	case score >= 7:
+	case score >= cfg.OpusMin:
 		return "opus"
-	case score >= 4:
+	case score >= cfg.SonnetMin:
 		return "sonnet"
 	default:
 		return "haiku"
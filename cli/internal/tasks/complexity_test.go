@@ -0,0 +1,153 @@
+package tasks
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lucas-stellet/oraculo/internal/config"
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+func TestScoreComplexityDefaults(t *testing.T) {
+	task := Task{ID: "1", Files: "`a.go`, `b.go`, `c.go`", DependsOn: []string{"2", "3"}, TDD: "yes"}
+	got := ScoreComplexity(task)
+	want := ComplexityResult{
+		TaskID:    "1",
+		Score:     6,
+		ModelHint: "sonnet",
+		Factors:   []string{"moderate files (3-4)", "moderate dependencies (2)", "TDD required"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScoreComplexity = %+v, want %+v", got, want)
+	}
+	if got := ScoreComplexity(Task{ID: "2"}); got.Score != 1 || got.ModelHint != "haiku" {
+		t.Errorf("empty task = %+v, want score 1 haiku", got)
+	}
+}
+
+func TestScoreComplexityConfigured(t *testing.T) {
+	cfg := config.Defaults().Complexity
+	cfg.Keywords = []string{"Migration", "auth"}
+	cfg.FileTypes = []string{".sql"}
+	cfg.Weights.Keyword = 3
+	cfg.Weights.FilesFew = 0
+	cfg.OpusMin = 5
+
+	task := Task{ID: "1", Title: "Add users migration", Files: "`db/001.sql`, `db/002.sql`"}
+	got := ScoreComplexityWith(task, cfg, nil)
+	if got.Score != 4 || got.ModelHint != "sonnet" {
+		t.Errorf("score = %d %s, want 4 sonnet", got.Score, got.ModelHint)
+	}
+	// files_few weighs 0 and is left out; "auth" is not a word of the title.
+	if want := []string{`keyword "migration"`, "file type .sql"}; !reflect.DeepEqual(got.Factors, want) {
+		t.Errorf("factors = %v, want %v", got.Factors, want)
+	}
+
+	cal := &Calibration{Adjustments: map[string]int{"file_type:.sql": 1, "keyword:migration": -1}}
+	got = ScoreComplexityWith(task, cfg, cal)
+	if got.Score != 4 || got.Adjustment != 0 {
+		t.Errorf("calibrated score = %d (adjustment %d), want 4 (0)", got.Score, got.Adjustment)
+	}
+	cal.Adjustments["keyword:migration"] = 0
+	if got = ScoreComplexityWith(task, cfg, cal); got.Score != 5 || got.ModelHint != "opus" || got.Adjustment != 1 {
+		t.Errorf("calibrated score = %+v, want 5 opus (+1)", got)
+	}
+	// Calibration does not bring back a factor disabled with weight 0.
+	cal.Adjustments["files_few"] = 1
+	if got = ScoreComplexityWith(task, cfg, cal); got.Score != 5 || got.Adjustment != 1 || len(got.Factors) != 2 {
+		t.Errorf("disabled factor calibrated: %+v", got)
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	cfg := config.Defaults().Complexity
+	cfg.CalibrationMinSamples = 2
+	many := Task{Files: "`a`, `b`, `c`, `d`, `e`"}
+	few := Task{Files: "`a`"}
+	history := []HistoryTask{
+		{Task: many, Troubled: true},
+		{Task: many, Troubled: true},
+		{Task: few},
+		{Task: few},
+		{Task: few, Troubled: true},
+		{Task: Task{Files: "`a`", TDD: "yes"}}, // tdd: one sample only
+	}
+	c := Calibrate(history, cfg)
+	if c.Samples != 6 || c.Troubled != 3 {
+		t.Errorf("samples = %d troubled = %d, want 6 3", c.Samples, c.Troubled)
+	}
+	// base rate 0.5: files_many 1.0 (+1), files_few 0.25 (-1)
+	want := map[string]int{"files_many": 1, "files_few": -1}
+	if !reflect.DeepEqual(c.Adjustments, want) {
+		t.Errorf("adjustments = %v, want %v", c.Adjustments, want)
+	}
+}
+
+func TestCollectHistory(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	w1, w2, w3 := 1, 2, 3
+	for _, r := range []store.TaskRecord{
+		{TaskID: "1", Title: "One", Status: "done", Wave: &w1, Files: "a.go"},
+		{TaskID: "2", Title: "Two", Status: "done", Wave: &w2, DependsOn: "1"},
+		{TaskID: "3", Title: "Three", Status: "skipped", Wave: &w2},
+		{TaskID: "4", Title: "Four", Status: "pending", Wave: &w3},
+	} {
+		if err := s.SyncTask(r); err != nil {
+			t.Fatalf("SyncTask: %v", err)
+		}
+	}
+	pass, _ := s.CreateRun("checkpoint", 1, "execution", &w1, "c/1")
+	blocked, _ := s.CreateRun("checkpoint", 1, "execution", &w2, "c/2")
+	s.UpdateRunStatus(pass, "pass")
+	s.UpdateRunStatus(blocked, "blocked")
+
+	history, err := CollectHistory(s, dir)
+	if err != nil {
+		t.Fatalf("CollectHistory: %v", err)
+	}
+	got := make(map[string]bool)
+	for _, h := range history {
+		got[h.Task.ID] = h.Troubled
+	}
+	// Task 3 is skipped; wave 3 has no outcome yet.
+	if want := map[string]bool{"1": false, "2": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("history = %v, want %v", got, want)
+	}
+}
+
+func TestReadHistoryDoesNotMigrate(t *testing.T) {
+	dir := t.TempDir()
+	if h, err := ReadHistory(dir); h != nil || err != nil {
+		t.Fatalf("no spec.db: %v, %v", h, err)
+	}
+
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	old := store.LatestSchemaVersion() - 1
+	if _, err := s.DB().Exec(fmt.Sprintf("PRAGMA user_version = %d", old)); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	if _, err := ReadHistory(dir); err == nil || !strings.Contains(err.Error(), "older than") {
+		t.Fatalf("older schema: err = %v", err)
+	}
+	s, err = store.OpenNoMigrate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if v, _ := s.SchemaVersion(); v != old {
+		t.Errorf("schema version = %d, want %d (migrated)", v, old)
+	}
+}
@@ -0,0 +1,796 @@
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/fsutil"
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+var (
+	// metaLineRe splits an indented "Key: value" line into its prefix
+	// (indent plus optional "- " list marker), key, separator and value.
+	metaLineRe = regexp.MustCompile(`^(\s+(?:-\s+)?)([A-Za-z_][A-Za-z _]*?):(\s*)(.*)$`)
+
+	// wavePlanLineRe matches a Wave Plan entry, keeping everything before
+	// the ID list so it can be rewritten in place. The ID list is optional:
+	// templates ship placeholder entries such as "- Wave 1:".
+	wavePlanLineRe = regexp.MustCompile(`(?i)^(\s*-\s+Wave\s+(\d+):\s*(?:Tasks?\s+)?)(.*)$`)
+
+	// fmTaskIDsLineRe matches the frontmatter task_ids list, at any
+	// indentation, in flow style.
+	fmTaskIDsLineRe = regexp.MustCompile(`^(\s*task_ids:\s*)\[([^\]]*)\](.*)$`)
+
+	// taskIDRe is a valid task ID: N, N.M or N.M.K.
+	taskIDRe = regexp.MustCompile(`^` + taskIDPattern + `$`)
+)
+
+// Editor edits tasks.md as a list of lines. Every operation rewrites only
+// the lines it has to, so formatting the parser does not look at (prose,
+// extra metadata, spacing) survives an edit unchanged. The frontmatter
+// task_ids list and the Wave Plan section are kept in sync with the tasks.
+type Editor struct {
+	lines []string
+}
+
+// NewTask describes a task to add. An empty ID takes the next integer ID.
+type NewTask struct {
+	ID        string
+	Title     string
+	Wave      int
+	DependsOn []string
+	Files     []string
+	TDD       string
+}
+
+// taskBlock is the line range of one task: the checkbox line and the
+// indented lines under it, without trailing blank lines.
+type taskBlock struct {
+	id       string
+	start    int
+	end      int // exclusive
+	deferred bool
+}
+
+// NewEditor returns an editor over tasks.md content.
+func NewEditor(content string) *Editor {
+	return &Editor{lines: strings.Split(content, "\n")}
+}
+
+// LoadEditor reads tasks.md into an editor.
+func LoadEditor(path string) (*Editor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read tasks.md: %w", err)
+	}
+	return NewEditor(string(data)), nil
+}
+
+// Content returns the edited document.
+func (e *Editor) Content() string {
+	return strings.Join(e.lines, "\n")
+}
+
+// Document parses the edited document.
+func (e *Editor) Document() Document {
+	return Parse(e.Content())
+}
+
+// AddTask appends a pending task after the last task of its wave and
+// returns its ID. The metadata lines follow the style of the existing
+// tasks.
+func (e *Editor) AddTask(nt NewTask) (string, error) {
+	if strings.TrimSpace(nt.Title) == "" {
+		return "", fmt.Errorf("task title is required")
+	}
+	if nt.Wave < 1 {
+		return "", fmt.Errorf("wave must be a positive number")
+	}
+	blocks := e.blocks()
+	id := nt.ID
+	if id == "" {
+		id = nextTaskID(blocks)
+	}
+	if !taskIDRe.MatchString(id) {
+		return "", fmt.Errorf("invalid task ID %q", id)
+	}
+	if findBlock(blocks, id) != nil {
+		return "", fmt.Errorf("task %s already exists", id)
+	}
+	doc := e.Document()
+	for _, dep := range nt.DependsOn {
+		if doc.TaskByID(dep) == nil {
+			return "", fmt.Errorf("dependency %s does not exist", dep)
+		}
+	}
+
+	st := e.style()
+	lines := []string{fmt.Sprintf("- [ ] %s %s", id, strings.TrimSpace(nt.Title))}
+	lines = append(lines, st.prefix+"Wave: "+strconv.Itoa(nt.Wave))
+	lines = append(lines, st.prefix+"Depends On: "+formatDeps(nt.DependsOn, st.taskWord))
+	if len(nt.Files) > 0 {
+		lines = append(lines, st.prefix+"Files: "+formatFiles(nt.Files, st.backticks))
+	}
+	if nt.TDD != "" {
+		lines = append(lines, st.prefix+"TDD: "+nt.TDD)
+	}
+
+	e.insertBlock(lines, e.placeFor(nt.Wave))
+	e.editTaskIDs(func(ids []string) []string { return append(ids, id) })
+	e.syncWavePlan()
+	return id, nil
+}
+
+// MoveTask sets the wave of a task and moves its block after the last task
+// of the target wave, if that wave has any.
+func (e *Editor) MoveTask(id string, wave int) error {
+	if wave < 1 {
+		return fmt.Errorf("wave must be a positive number")
+	}
+	b := findBlock(e.blocks(), id)
+	if b == nil {
+		return fmt.Errorf("task %s not found", id)
+	}
+	e.setMeta(*b, "Wave", strconv.Itoa(wave))
+
+	if e.hasWave(wave, id) {
+		b = findBlock(e.blocks(), id)
+		block := append([]string(nil), e.lines[b.start:b.end]...)
+		e.removeBlock(*b)
+		e.insertBlock(block, e.placeFor(wave))
+	}
+	e.syncWavePlan()
+	return nil
+}
+
+// SplitTask replaces task id with one subtask per title, numbered id.1,
+// id.2, ... Each subtask keeps the original checkbox and metadata; tasks
+// that depended on id depend on every subtask instead. It returns the new
+// IDs. IDs nest three levels deep, so an N.M.K task cannot be split.
+func (e *Editor) SplitTask(id string, titles []string) ([]string, error) {
+	if len(titles) < 2 {
+		return nil, fmt.Errorf("split needs at least 2 subtasks")
+	}
+	if strings.Count(id, ".") >= 2 {
+		return nil, fmt.Errorf("task %s is already three levels deep and cannot be split", id)
+	}
+	blocks := e.blocks()
+	b := findBlock(blocks, id)
+	if b == nil {
+		return nil, fmt.Errorf("task %s not found", id)
+	}
+	m := taskLineRe.FindStringSubmatch(e.lines[b.start])
+	meta := e.lines[b.start+1 : b.end]
+
+	var newIDs []string
+	var out []string
+	for i, title := range titles {
+		subID := fmt.Sprintf("%s.%d", id, i+1)
+		if findBlock(blocks, subID) != nil {
+			return nil, fmt.Errorf("task %s already exists", subID)
+		}
+		newIDs = append(newIDs, subID)
+		if i > 0 {
+			out = append(out, "")
+		}
+		out = append(out, fmt.Sprintf("- [%s] %s %s", m[1], subID, strings.TrimSpace(title)))
+		out = append(out, meta...)
+	}
+
+	e.lines = splice(e.lines, b.start, b.end, out)
+	e.rewriteDeps(func(deps []string) []string {
+		var res []string
+		for _, d := range deps {
+			if d == id {
+				res = append(res, newIDs...)
+			} else {
+				res = append(res, d)
+			}
+		}
+		return res
+	})
+	e.editTaskIDs(func(ids []string) []string {
+		var res []string
+		for _, x := range ids {
+			if x == id {
+				res = append(res, newIDs...)
+			} else {
+				res = append(res, x)
+			}
+		}
+		return res
+	})
+	e.syncWavePlan()
+	return newIDs, nil
+}
+
+// RemoveTask deletes a task. A task other tasks depend on is only removed
+// with force, which also drops it from their Depends On lists.
+func (e *Editor) RemoveTask(id string, force bool) error {
+	b := findBlock(e.blocks(), id)
+	if b == nil {
+		return fmt.Errorf("task %s not found", id)
+	}
+	var dependents []string
+	for _, t := range e.Document().Tasks {
+		for _, d := range t.DependsOn {
+			if d == id {
+				dependents = append(dependents, t.ID)
+			}
+		}
+	}
+	if len(dependents) > 0 && !force {
+		return fmt.Errorf("task %s is a dependency of %s (use --force to drop the references)",
+			id, strings.Join(dependents, ", "))
+	}
+
+	e.removeBlock(*b)
+	e.rewriteDeps(func(deps []string) []string { return without(deps, id) })
+	e.editTaskIDs(func(ids []string) []string { return without(ids, id) })
+	e.syncWavePlan()
+	return nil
+}
+
+// SetStatus changes the checkbox of a task and sets its Reason line, or
+// removes it when reason is empty. It returns the previous status and
+// reason.
+func (e *Editor) SetStatus(id, status, reason string) (prevStatus, prevReason string, err error) {
+	char, ok := statusToChar[status]
+	if !ok {
+		return "", "", fmt.Errorf("invalid status %q", status)
+	}
+	b := findBlock(e.blocks(), id)
+	if b == nil {
+		return "", "", fmt.Errorf("task %s not found", id)
+	}
+	line := e.lines[b.start]
+	prevStatus = charToStatus(line[3:4])
+	if i := e.metaLine(*b, "Reason"); i >= 0 {
+		prevReason = strings.TrimSpace(metaLineRe.FindStringSubmatch(e.lines[i])[4])
+	}
+
+	// "- [" is three bytes, so the checkbox character is at index 3.
+	e.lines[b.start] = line[:3] + char + line[4:]
+	if reason != "" {
+		e.setMeta(*b, "Reason", reason)
+	} else {
+		e.removeMeta(*b, "Reason")
+	}
+	return prevStatus, prevReason, nil
+}
+
+// SetWaves rewrites the Wave line of each task in waves, leaving task
+// blocks where they are, then updates the Wave Plan.
+func (e *Editor) SetWaves(waves map[string]int) error {
+	for id, w := range waves {
+		if w < 1 {
+			return fmt.Errorf("wave must be a positive number")
+		}
+		b := findBlock(e.blocks(), id)
+		if b == nil {
+			return fmt.Errorf("task %s not found", id)
+		}
+		e.setMeta(*b, "Wave", strconv.Itoa(w))
+	}
+	e.syncWavePlan()
+	return nil
+}
+
+// --- blocks ---
+
+// blocks returns the task blocks in file order.
+func (e *Editor) blocks() []taskBlock {
+	var out []taskBlock
+	var cur *taskBlock
+	inDeferred := false
+	flush := func() {
+		if cur != nil {
+			out = append(out, *cur)
+			cur = nil
+		}
+	}
+	for i, line := range e.lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "## ") {
+			flush()
+			inDeferred = strings.Contains(strings.ToLower(trimmed), "deferred")
+			continue
+		}
+		if m := taskLineRe.FindStringSubmatch(line); m != nil {
+			flush()
+			cur = &taskBlock{id: m[2], start: i, end: i + 1, deferred: inDeferred}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"):
+			cur.end = i + 1
+		default:
+			flush()
+		}
+	}
+	flush()
+	return out
+}
+
+func findBlock(blocks []taskBlock, id string) *taskBlock {
+	for i := range blocks {
+		if blocks[i].id == id {
+			return &blocks[i]
+		}
+	}
+	return nil
+}
+
+// nextTaskID returns one more than the highest integer task ID.
+func nextTaskID(blocks []taskBlock) string {
+	highest := 0
+	for _, b := range blocks {
+		n, _ := strconv.Atoi(strings.SplitN(b.id, ".", 2)[0])
+		highest = max(highest, n)
+	}
+	return strconv.Itoa(highest + 1)
+}
+
+// placement is where a new task block goes: after the block ending at
+// line, or before the block starting at line.
+type placement struct {
+	line   int
+	before bool
+}
+
+// placeFor picks where a task of the given wave goes: after the last task
+// of that wave, else after the last task of an earlier wave, else before
+// the first task, else at the end of the Tasks section. Deferred tasks are
+// ignored.
+func (e *Editor) placeFor(wave int) placement {
+	waves := make(map[string]int)
+	for _, t := range e.Document().Tasks {
+		waves[t.ID] = t.Wave
+	}
+	same, earlier, first := -1, -1, -1
+	for _, b := range e.blocks() {
+		if b.deferred {
+			continue
+		}
+		if first < 0 {
+			first = b.start
+		}
+		switch w := waves[b.id]; {
+		case w == wave:
+			same = b.end
+		case w < wave:
+			earlier = b.end
+		}
+	}
+	switch {
+	case same >= 0:
+		return placement{line: same}
+	case earlier >= 0:
+		return placement{line: earlier}
+	case first >= 0:
+		return placement{line: first, before: true}
+	}
+	return placement{line: e.tasksSectionEnd()}
+}
+
+// hasWave reports whether a planned task other than skip is in wave.
+func (e *Editor) hasWave(wave int, skip string) bool {
+	for _, t := range e.Document().Tasks {
+		if t.ID != skip && t.Wave == wave && !t.IsDeferred {
+			return true
+		}
+	}
+	return false
+}
+
+// insertBlock inserts a task block at p, separated from its neighbour by a
+// blank line.
+func (e *Editor) insertBlock(block []string, p placement) {
+	if p.before {
+		e.lines = splice(e.lines, p.line, p.line, append(append([]string(nil), block...), ""))
+		return
+	}
+	e.lines = splice(e.lines, p.line, p.line, append([]string{""}, block...))
+}
+
+// tasksSectionEnd returns the index after the last non-blank line of the
+// "## Tasks" section, or of the document when there is none.
+func (e *Editor) tasksSectionEnd() int {
+	start, end := 0, len(e.lines)
+	for i, line := range e.lines {
+		trimmed := strings.ToLower(strings.TrimSpace(line))
+		if trimmed == "## tasks" {
+			start = i + 1
+			end = len(e.lines)
+			for j := i + 1; j < len(e.lines); j++ {
+				if strings.HasPrefix(strings.TrimSpace(e.lines[j]), "## ") {
+					end = j
+					break
+				}
+			}
+			break
+		}
+	}
+	for end > start && strings.TrimSpace(e.lines[end-1]) == "" {
+		end--
+	}
+	return end
+}
+
+// removeBlock deletes a block together with one blank line next to it.
+func (e *Editor) removeBlock(b taskBlock) {
+	start, end := b.start, b.end
+	if start > 0 && strings.TrimSpace(e.lines[start-1]) == "" {
+		start--
+	} else if end < len(e.lines) && strings.TrimSpace(e.lines[end]) == "" {
+		end++
+	}
+	e.lines = splice(e.lines, start, end, nil)
+}
+
+// --- metadata ---
+
+// editStyle is how the existing tasks write their metadata.
+type editStyle struct {
+	prefix    string // indentation and optional list marker
+	backticks bool   // Files entries wrapped in backticks
+	taskWord  bool   // Depends On written as "Task 1"
+}
+
+func (e *Editor) style() editStyle {
+	st := editStyle{prefix: "  ", backticks: true}
+	var seenWave, seenFiles bool
+	for _, b := range e.blocks() {
+		for _, line := range e.lines[b.start+1 : b.end] {
+			m := metaLineRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			switch strings.ToLower(m[2]) {
+			case "wave":
+				if !seenWave {
+					st.prefix = m[1]
+					seenWave = true
+				}
+			case "files":
+				if !seenFiles {
+					st.backticks = strings.Contains(m[4], "`")
+					seenFiles = true
+				}
+			case "depends on":
+				if strings.Contains(strings.ToLower(m[4]), "task") {
+					st.taskWord = true
+				}
+			}
+		}
+	}
+	return st
+}
+
+// metaLines returns the indexes of a block's direct metadata lines: those
+// at the indent of its first metadata line, as the parser reads them.
+// Items nested under a key, such as the Reason of the template's No-Test
+// Justification list, are not metadata of the task.
+func (e *Editor) metaLines(b taskBlock) []int {
+	var out []int
+	indent := ""
+	for i := b.start + 1; i < b.end; i++ {
+		m := metaLineRe.FindStringSubmatch(e.lines[i])
+		if m == nil {
+			continue
+		}
+		lead := m[1][:len(m[1])-len(strings.TrimLeft(m[1], " \t"))]
+		if indent == "" {
+			indent = lead
+		}
+		if lead == indent {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// metaLine returns the index of a block's direct metadata line with the
+// given key, or -1.
+func (e *Editor) metaLine(b taskBlock, key string) int {
+	for _, i := range e.metaLines(b) {
+		if strings.EqualFold(metaLineRe.FindStringSubmatch(e.lines[i])[2], key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// setMeta sets a metadata value of a block, keeping the line's prefix and
+// spacing, or inserts the line right after the checkbox line.
+func (e *Editor) setMeta(b taskBlock, key, value string) {
+	if i := e.metaLine(b, key); i >= 0 {
+		m := metaLineRe.FindStringSubmatch(e.lines[i])
+		e.lines[i] = m[1] + m[2] + ":" + m[3] + value
+		return
+	}
+	line := e.style().prefix + key + ": " + value
+	e.lines = splice(e.lines, b.start+1, b.start+1, []string{line})
+}
+
+// removeMeta deletes the direct metadata lines of a block with the given
+// key.
+func (e *Editor) removeMeta(b taskBlock, key string) {
+	lines := e.metaLines(b)
+	for j := len(lines) - 1; j >= 0; j-- {
+		i := lines[j]
+		if m := metaLineRe.FindStringSubmatch(e.lines[i]); strings.EqualFold(m[2], key) {
+			e.lines = splice(e.lines, i, i+1, nil)
+		}
+	}
+}
+
+// rewriteDeps applies fn to every task's Depends On list and rewrites the
+// lines whose list changed.
+func (e *Editor) rewriteDeps(fn func([]string) []string) {
+	for _, b := range e.blocks() {
+		for i := b.start + 1; i < b.end; i++ {
+			m := metaLineRe.FindStringSubmatch(e.lines[i])
+			if m == nil || !strings.EqualFold(m[2], "depends on") {
+				continue
+			}
+			deps := parseIDList(m[4])
+			updated := fn(deps)
+			if !equalIDs(deps, updated) {
+				taskWord := strings.Contains(strings.ToLower(m[4]), "task")
+				e.lines[i] = m[1] + m[2] + ":" + m[3] + formatDeps(updated, taskWord)
+			}
+		}
+	}
+}
+
+func formatDeps(ids []string, taskWord bool) string {
+	switch {
+	case len(ids) == 0:
+		return "none"
+	case !taskWord:
+		return strings.Join(ids, ", ")
+	case len(ids) == 1:
+		return "Task " + ids[0]
+	default:
+		return "Tasks " + strings.Join(ids, ", ")
+	}
+}
+
+func formatFiles(files []string, backticks bool) string {
+	out := make([]string, len(files))
+	for i, f := range files {
+		f = strings.Trim(strings.TrimSpace(f), "`")
+		if backticks {
+			f = "`" + f + "`"
+		}
+		out[i] = f
+	}
+	return strings.Join(out, ", ")
+}
+
+// --- frontmatter and wave plan ---
+
+// editTaskIDs rewrites the frontmatter task_ids list with fn. An absent or
+// empty list is left alone: the parser only treats task_ids as the set of
+// planned tasks when it is non-empty.
+func (e *Editor) editTaskIDs(fn func([]string) []string) {
+	if len(e.lines) == 0 || strings.TrimSpace(e.lines[0]) != "---" {
+		return
+	}
+	for i := 1; i < len(e.lines); i++ {
+		if strings.TrimSpace(e.lines[i]) == "---" {
+			return
+		}
+		m := fmTaskIDsLineRe.FindStringSubmatch(e.lines[i])
+		if m == nil {
+			continue
+		}
+		ids := parseIDList(m[2])
+		if len(ids) == 0 {
+			return
+		}
+		updated := fn(append([]string(nil), ids...))
+		if equalIDs(ids, updated) {
+			return
+		}
+		quote := strings.Contains(m[2], `"`)
+		items := make([]string, len(updated))
+		for j, id := range updated {
+			if quote {
+				id = `"` + id + `"`
+			}
+			items[j] = id
+		}
+		e.lines[i] = m[1] + "[" + strings.Join(items, ", ") + "]" + m[3]
+		return
+	}
+}
+
+// syncWavePlan makes the Wave Plan entries list the planned (non-deferred)
+// tasks of each wave in file order. Entries that already match are left
+// untouched, entries of emptied waves are removed and missing waves get a
+// new "- Wave N: Tasks ..." line in wave order.
+func (e *Editor) syncWavePlan() {
+	header := -1
+	for i, line := range e.lines {
+		if t := strings.TrimSpace(line); strings.HasPrefix(t, "## ") && strings.Contains(strings.ToLower(t), "wave plan") {
+			header = i
+			break
+		}
+	}
+	if header < 0 {
+		return
+	}
+
+	want := make(map[int][]string)
+	for _, t := range e.Document().Tasks {
+		if t.Wave > 0 && !t.IsDeferred {
+			want[t.Wave] = append(want[t.Wave], t.ID)
+		}
+	}
+
+	type entry struct{ line, wave int }
+	var entries []entry
+	for i := header + 1; i < len(e.lines); i++ {
+		t := strings.TrimSpace(e.lines[i])
+		if strings.HasPrefix(t, "## ") || t == "---" {
+			break
+		}
+		if m := wavePlanLineRe.FindStringSubmatch(e.lines[i]); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			entries = append(entries, entry{i, n})
+		}
+	}
+
+	present := make(map[int]bool)
+	for j := len(entries) - 1; j >= 0; j-- {
+		en := entries[j]
+		m := wavePlanLineRe.FindStringSubmatch(e.lines[en.line])
+		present[en.wave] = true
+		ids := want[en.wave]
+		current := parseIDList(m[3])
+		switch {
+		case len(ids) == 0 && len(current) > 0:
+			e.lines = splice(e.lines, en.line, en.line+1, nil)
+			entries = append(entries[:j], entries[j+1:]...)
+			for k := j; k < len(entries); k++ {
+				entries[k].line--
+			}
+		case len(ids) > 0 && !equalIDs(current, ids):
+			prefix := m[1]
+			if len(current) == 0 {
+				prefix = strings.TrimRight(prefix, " ") + " Tasks "
+			}
+			e.lines[en.line] = prefix + strings.Join(ids, ", ")
+		}
+	}
+
+	var missing []int
+	for w := range want {
+		if !present[w] {
+			missing = append(missing, w)
+		}
+	}
+	sort.Ints(missing)
+	for _, w := range missing {
+		line := fmt.Sprintf("- Wave %d: Tasks %s", w, strings.Join(want[w], ", "))
+		at := -1
+		for _, en := range entries {
+			if en.wave < w {
+				at = en.line + 1
+			}
+		}
+		if at < 0 {
+			if len(entries) > 0 {
+				at = entries[0].line
+			} else {
+				at = header + 1
+				if at < len(e.lines) && strings.TrimSpace(e.lines[at]) == "" {
+					at++
+				}
+			}
+		}
+		e.lines = splice(e.lines, at, at, []string{line})
+		for k := range entries {
+			if entries[k].line >= at {
+				entries[k].line++
+			}
+		}
+		entries = append(entries, entry{at, w})
+		sort.Slice(entries, func(a, b int) bool { return entries[a].line < entries[b].line })
+	}
+}
+
+// --- helpers ---
+
+func splice(lines []string, start, end int, repl []string) []string {
+	out := make([]string, 0, len(lines)-(end-start)+len(repl))
+	out = append(out, lines[:start]...)
+	out = append(out, repl...)
+	return append(out, lines[end:]...)
+}
+
+func without(ids []string, id string) []string {
+	var out []string
+	for _, x := range ids {
+		if x != id {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// --- persistence ---
+
+// EditFile loads tasks.md, applies edit and saves the result with SaveEdit,
+// all under an advisory lock on the spec directory so concurrent edits and
+// marks are applied one after the other. edit may fill in ev.
+func EditFile(filePath, specDir string, edit func(*Editor) error, ev *store.Event) error {
+	return fsutil.WithLock(filePath, func() error {
+		e, err := LoadEditor(filePath)
+		if err != nil {
+			return err
+		}
+		if err := edit(e); err != nil {
+			return err
+		}
+		return SaveEdit(filePath, specDir, e, *ev)
+	})
+}
+
+// SaveEdit atomically replaces tasks.md with the edited content and, when
+// specDir is set, brings the tasks table in line with it (upserting every
+// task and deleting rows of tasks that no longer exist) and journals ev.
+// Callers that loaded the editor themselves should hold the lock EditFile
+// takes.
+func SaveEdit(filePath, specDir string, e *Editor, ev store.Event) error {
+	if err := fsutil.WriteAtomic(filePath, []byte(e.Content()), 0644); err != nil {
+		return err
+	}
+	if specDir == "" {
+		return nil
+	}
+	s := store.TryOpen(specDir)
+	if s == nil {
+		return nil
+	}
+	defer s.Close()
+
+	doc := e.Document()
+	present := make(map[string]bool, len(doc.Tasks))
+	for _, t := range doc.Tasks {
+		present[t.ID] = true
+		s.SyncTask(t.Record())
+	}
+	if rows, err := s.ListTasks(); err == nil {
+		for _, r := range rows {
+			if !present[r.TaskID] {
+				s.DeleteTask(r.TaskID)
+			}
+		}
+	}
+	s.RecordEvent(ev)
+	return nil
+}
@@ -0,0 +1,222 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+)
+
+const editorDoc = `---
+spec: edit-test
+task_ids: [1, 2, 3]
+---
+
+# Tasks: edit-test
+
+## Wave Plan
+
+- Wave 1: Tasks 1, 2
+- Wave 2: Tasks 3 (integration)
+
+## Tasks
+
+- [x] 1 First task
+  Wave: 1
+  Depends On: none
+  Files: ` + "`a.go`" + `
+  Notes: keep this line
+
+- [ ] 2 Second task
+  Wave: 1
+  Depends On: Task 1
+  Files: ` + "`b.go`" + `
+
+- [ ] 3 Third task
+  Wave: 2
+  Depends On: Task 2
+  Files: ` + "`c.go`" + `
+`
+
+func TestEditorRoundTrip(t *testing.T) {
+	for _, name := range []string{"basic.md", "deferred-ready.md", "mixed-deps.md"} {
+		content := readFixture(t, name)
+		if got := NewEditor(content).Content(); got != content {
+			t.Errorf("%s: content changed without edits", name)
+		}
+	}
+}
+
+func TestEditorAddTask(t *testing.T) {
+	e := NewEditor(editorDoc)
+	id, err := e.AddTask(NewTask{Title: "Fourth task", Wave: 1, DependsOn: []string{"1"}, Files: []string{"d.go"}})
+	if err != nil {
+		t.Fatalf("AddTask: %v", err)
+	}
+	if id != "4" {
+		t.Errorf("id = %s, want 4", id)
+	}
+
+	want := strings.Replace(editorDoc, "task_ids: [1, 2, 3]", "task_ids: [1, 2, 3, 4]", 1)
+	want = strings.Replace(want, "- Wave 1: Tasks 1, 2\n", "- Wave 1: Tasks 1, 2, 4\n", 1)
+	want = strings.Replace(want, "  Files: `b.go`\n", "  Files: `b.go`\n\n- [ ] 4 Fourth task\n  Wave: 1\n  Depends On: Task 1\n  Files: `d.go`\n", 1)
+	if got := e.Content(); got != want {
+		t.Errorf("content after add:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := e.AddTask(NewTask{ID: "2", Title: "dup", Wave: 1}); err == nil {
+		t.Error("AddTask accepted a duplicate ID")
+	}
+	if _, err := e.AddTask(NewTask{Title: "bad dep", Wave: 1, DependsOn: []string{"9"}}); err == nil {
+		t.Error("AddTask accepted a missing dependency")
+	}
+}
+
+func TestEditorMoveTask(t *testing.T) {
+	e := NewEditor(editorDoc)
+	if err := e.MoveTask("2", 3); err != nil {
+		t.Fatalf("MoveTask: %v", err)
+	}
+	doc := e.Document()
+	if doc.TaskByID("2").Wave != 3 {
+		t.Errorf("task 2 wave = %d", doc.TaskByID("2").Wave)
+	}
+	got := e.Content()
+	for _, want := range []string{"- Wave 1: Tasks 1\n", "- Wave 2: Tasks 3 (integration)\n", "- Wave 3: Tasks 2\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("wave plan missing %q:\n%s", want, got)
+		}
+	}
+
+	// Moving into a wave with tasks relocates the block after them.
+	if err := e.MoveTask("1", 2); err != nil {
+		t.Fatal(err)
+	}
+	doc = e.Document()
+	if ids := taskIDs(doc); strings.Join(ids, ",") != "2,3,1" && strings.Join(ids, ",") != "3,1,2" {
+		t.Errorf("task order = %v", ids)
+	}
+	if !strings.Contains(e.Content(), "  Notes: keep this line") {
+		t.Error("move dropped unrelated metadata")
+	}
+	if strings.Contains(e.Content(), "- Wave 1:") {
+		t.Errorf("emptied wave 1 still in the plan:\n%s", e.Content())
+	}
+}
+
+func TestEditorSplitTask(t *testing.T) {
+	e := NewEditor(editorDoc)
+	ids, err := e.SplitTask("2", []string{"Second, part one", "Second, part two"})
+	if err != nil {
+		t.Fatalf("SplitTask: %v", err)
+	}
+	if strings.Join(ids, ",") != "2.1,2.2" {
+		t.Errorf("ids = %v", ids)
+	}
+	got := e.Content()
+	for _, want := range []string{
+		"task_ids: [1, 2.1, 2.2, 3]",
+		"- Wave 1: Tasks 1, 2.1, 2.2\n",
+		"- [ ] 2.1 Second, part one\n  Wave: 1\n  Depends On: Task 1\n",
+		"- [ ] 2.2 Second, part two\n",
+		"  Depends On: Tasks 2.1, 2.2\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+
+	// Subtasks split one level further, then no more.
+	ids, err = e.SplitTask("2.1", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("SplitTask(2.1): %v", err)
+	}
+	if strings.Join(ids, ",") != "2.1.1,2.1.2" {
+		t.Errorf("ids = %v", ids)
+	}
+	got = e.Content()
+	for _, want := range []string{
+		"task_ids: [1, 2.1.1, 2.1.2, 2.2, 3]",
+		"- [ ] 2.1.2 b\n",
+		"  Depends On: Tasks 2.1.1, 2.1.2, 2.2\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	doc := e.Document()
+	if task := doc.TaskByID("2.1.2"); task == nil || task.Title != "b" {
+		t.Errorf("2.1.2 not parsed: %+v", task)
+	}
+	if task := doc.TaskByID("3"); task == nil || strings.Join(task.DependsOn, ",") != "2.1.1,2.1.2,2.2" {
+		t.Errorf("3 depends on %+v", task)
+	}
+	if _, err := e.SplitTask("2.1.1", []string{"a", "b"}); err == nil {
+		t.Error("split a three-level task")
+	}
+}
+
+func TestEditorRemoveTask(t *testing.T) {
+	e := NewEditor(editorDoc)
+	if err := e.RemoveTask("2", false); err == nil || !strings.Contains(err.Error(), "dependency of 3") {
+		t.Fatalf("RemoveTask without force: err = %v", err)
+	}
+	if err := e.RemoveTask("2", true); err != nil {
+		t.Fatalf("RemoveTask: %v", err)
+	}
+	got := e.Content()
+	if strings.Contains(got, "Second task") {
+		t.Error("task 2 still present")
+	}
+	for _, want := range []string{"task_ids: [1, 3]", "- Wave 1: Tasks 1\n", "  Depends On: none\n  Files: `c.go`"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "\n\n\n") {
+		t.Errorf("removal left a double blank line:\n%s", got)
+	}
+}
+
+func TestEditorFollowsTemplateStyle(t *testing.T) {
+	content := `---
+oraculo:
+  spec: "edit-test"
+  task_ids: []
+---
+
+## Wave Plan
+- Wave 1:
+- Wave 2:
+
+---
+
+- [ ] 1.1 Build thing
+  - Wave: 1
+  - Depends On: none
+  - Files: lib/thing.ex
+`
+	e := NewEditor(content)
+	if _, err := e.AddTask(NewTask{ID: "2.1", Title: "Wire thing", Wave: 2, DependsOn: []string{"1.1"}, Files: []string{"lib/wire.ex"}}); err != nil {
+		t.Fatalf("AddTask: %v", err)
+	}
+	got := e.Content()
+	for _, want := range []string{
+		"- Wave 1: Tasks 1.1\n",
+		"- Wave 2: Tasks 2.1\n",
+		"- [ ] 2.1 Wire thing\n  - Wave: 2\n  - Depends On: 1.1\n  - Files: lib/wire.ex\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if doc := e.Document(); doc.TaskByID("2.1").Wave != 2 {
+		t.Errorf("parsed wave = %d", doc.TaskByID("2.1").Wave)
+	}
+}
+
+func taskIDs(doc Document) []string {
+	var ids []string
+	for _, t := range doc.Tasks {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
@@ -0,0 +1,214 @@
+package tasks
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Overlap kinds reported in a FileConflict.
+const (
+	OverlapExact     = "exact"
+	OverlapDirectory = "directory"
+)
+
+// FileConflict is a pair of tasks in the same wave whose declared files
+// overlap, either as the same path or as a directory and a path under it.
+type FileConflict struct {
+	Wave  int    `json:"wave"`
+	TaskA string `json:"task_a"`
+	PathA string `json:"path_a"`
+	TaskB string `json:"task_b"`
+	PathB string `json:"path_b"`
+	Kind  string `json:"kind"`
+}
+
+// String describes the conflict in one line.
+func (c FileConflict) String() string {
+	if c.Kind == OverlapExact {
+		return fmt.Sprintf("tasks %s and %s both touch %s", c.TaskA, c.TaskB, c.PathA)
+	}
+	return fmt.Sprintf("task %s touches %s, which overlaps %s of task %s", c.TaskA, c.PathA, c.PathB, c.TaskB)
+}
+
+// FileList splits the Files metadata of t into paths, dropping backticks
+// and blanks.
+func (t Task) FileList() []string {
+	var files []string
+	for _, p := range strings.Split(t.Files, ",") {
+		p = strings.TrimSpace(strings.Trim(strings.TrimSpace(p), "`"))
+		if p != "" {
+			files = append(files, p)
+		}
+	}
+	return files
+}
+
+// FindConflicts reports file overlaps between the non-deferred tasks of
+// each wave, or of the given wave only when wave > 0.
+func FindConflicts(doc Document, wave int) []FileConflict {
+	byWave := make(map[int][]Task)
+	for _, t := range doc.Tasks {
+		if !t.IsDeferred && (wave <= 0 || t.Wave == wave) {
+			byWave[t.Wave] = append(byWave[t.Wave], t)
+		}
+	}
+	waves := make([]int, 0, len(byWave))
+	for w := range byWave {
+		waves = append(waves, w)
+	}
+	sort.Ints(waves)
+
+	conflicts := []FileConflict{}
+	for _, w := range waves {
+		for _, c := range ConflictsAmong(byWave[w]) {
+			c.Wave = w
+			conflicts = append(conflicts, c)
+		}
+	}
+	return conflicts
+}
+
+// ConflictsAmong reports file overlaps between every pair of tasks, in
+// body order. Wave is left at zero.
+func ConflictsAmong(tasks []Task) []FileConflict {
+	var conflicts []FileConflict
+	for i, a := range tasks {
+		filesA := a.FileList()
+		for _, b := range tasks[i+1:] {
+			for _, pa := range filesA {
+				for _, pb := range b.FileList() {
+					if kind := overlapKind(pa, pb); kind != "" {
+						conflicts = append(conflicts, FileConflict{TaskA: a.ID, PathA: pa, TaskB: b.ID, PathB: pb, Kind: kind})
+					}
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// overlapKind returns OverlapExact when a and b name the same path,
+// OverlapDirectory when one is a directory containing the other, and ""
+// otherwise.
+func overlapKind(a, b string) string {
+	a, b = cleanPath(a), cleanPath(b)
+	switch {
+	case a == "" || b == "":
+		return ""
+	case a == b:
+		return OverlapExact
+	case strings.HasPrefix(b, a+"/") || strings.HasPrefix(a, b+"/"):
+		return OverlapDirectory
+	}
+	return ""
+}
+
+// pathsOverlap reports whether two declared paths touch the same file:
+// they are equal, or one is a directory containing the other.
+func pathsOverlap(a, b string) bool {
+	return overlapKind(a, b) != ""
+}
+
+func cleanPath(p string) string {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return ""
+	}
+	if p = path.Clean(p); p == "." {
+		return ""
+	}
+	return p
+}
+
+// filesOverlap reports whether any path of a overlaps any path of b.
+func filesOverlap(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if pathsOverlap(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ScopeReport compares the files a task declares with the files its commits
+// changed.
+type ScopeReport struct {
+	Declared []string `json:"declared"`
+	Changed  []string `json:"changed"`
+	// Undeclared lists changed files no declared entry covers.
+	Undeclared []string `json:"undeclared"`
+	// Untouched lists declared entries no changed file falls under.
+	Untouched []string `json:"untouched"`
+	// Ignored lists changed workflow files (under .spec-workflow/), which
+	// commits carry alongside the code.
+	Ignored []string `json:"ignored,omitempty"`
+}
+
+// InScope reports whether every changed file is declared and every
+// declared entry was touched.
+func (r ScopeReport) InScope() bool {
+	return len(r.Undeclared) == 0 && len(r.Untouched) == 0
+}
+
+// CompareScope checks changed files against declared entries. A declared
+// entry covers a changed file when it names it, is a directory containing
+// it, or is a glob pattern (path.Match syntax) matching it.
+func CompareScope(declared, changed []string) ScopeReport {
+	r := ScopeReport{Declared: declared, Changed: changed, Undeclared: []string{}, Untouched: []string{}}
+	if r.Declared == nil {
+		r.Declared = []string{}
+	}
+	if r.Changed == nil {
+		r.Changed = []string{}
+	}
+	touched := make(map[int]bool)
+	for _, f := range changed {
+		if strings.HasPrefix(cleanPath(f), ".spec-workflow/") {
+			r.Ignored = append(r.Ignored, f)
+			continue
+		}
+		covered := false
+		for i, d := range declared {
+			if declares(d, f) {
+				covered = true
+				touched[i] = true
+			}
+		}
+		if !covered {
+			r.Undeclared = append(r.Undeclared, f)
+		}
+	}
+	for i, d := range declared {
+		if !touched[i] {
+			r.Untouched = append(r.Untouched, d)
+		}
+	}
+	return r
+}
+
+// declares reports whether the declared entry d covers file f.
+func declares(d, f string) bool {
+	d, f = cleanPath(d), cleanPath(f)
+	if d == "" || f == "" {
+		return false
+	}
+	if d == f || strings.HasPrefix(f, d+"/") {
+		return true
+	}
+	if strings.ContainsAny(d, "*?[") {
+		if ok, _ := path.Match(d, f); ok {
+			return true
+		}
+		// A pattern may also name a directory: src/*/ covers src/a/b.go.
+		for dir := path.Dir(f); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if ok, _ := path.Match(d, dir); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package tasks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFileList(t *testing.T) {
+	task := Task{Files: "`src/a.ts`, src/b.ts ,, `lib/`"}
+	if got := task.FileList(); !reflect.DeepEqual(got, []string{"src/a.ts", "src/b.ts", "lib/"}) {
+		t.Errorf("FileList = %q", got)
+	}
+}
+
+func TestFindConflicts(t *testing.T) {
+	doc := Document{Tasks: []Task{
+		{ID: "1", Wave: 1, Files: "`src/api.ts`, `lib/`"},
+		{ID: "2", Wave: 1, Files: "`./src/api.ts`"},
+		{ID: "3", Wave: 1, Files: "`lib/util/x.go`"},
+		{ID: "4", Wave: 2, Files: "`src/api.ts`"},
+		{ID: "5", Wave: 1, Files: "`src/api.ts`", IsDeferred: true},
+		{ID: "6", Wave: 2, Files: "`src/api.tsx`"},
+	}}
+	got := FindConflicts(doc, 0)
+	want := []FileConflict{
+		{Wave: 1, TaskA: "1", PathA: "src/api.ts", TaskB: "2", PathB: "./src/api.ts", Kind: OverlapExact},
+		{Wave: 1, TaskA: "1", PathA: "lib/", TaskB: "3", PathB: "lib/util/x.go", Kind: OverlapDirectory},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("conflicts = %+v, want %+v", got, want)
+	}
+	if got := FindConflicts(doc, 2); len(got) != 0 {
+		t.Errorf("wave 2 conflicts = %+v", got)
+	}
+	if s := want[1].String(); s != "task 1 touches lib/, which overlaps lib/util/x.go of task 3" {
+		t.Errorf("String = %q", s)
+	}
+}
+
+func TestResolveNextWaveWarnsOnFileConflicts(t *testing.T) {
+	doc := Document{Tasks: []Task{
+		{ID: "1", Status: "pending", Wave: 1, Files: "`a.go`"},
+		{ID: "2", Status: "pending", Wave: 1, Files: "`a.go`"},
+		{ID: "3", Status: "pending", Wave: 1, Files: "`b.go`"},
+	}}
+	res := ResolveNextWave(doc, nil)
+	if res.Action != "execute" {
+		t.Fatalf("action = %s", res.Action)
+	}
+	if len(res.Warnings) != 1 || !strings.HasPrefix(res.Warnings[0], "file_conflict: tasks 1 and 2 both touch a.go") {
+		t.Errorf("warnings = %v", res.Warnings)
+	}
+}
+
+func TestCompareScope(t *testing.T) {
+	declared := []string{"src/api.ts", "lib/", "web/*.css", "docs/guide.md"}
+	changed := []string{"src/api.ts", "lib/util/x.go", "web/site.css", "src/extra.ts", ".spec-workflow/specs/demo/tasks.md"}
+	got := CompareScope(declared, changed)
+	if !reflect.DeepEqual(got.Undeclared, []string{"src/extra.ts"}) {
+		t.Errorf("undeclared = %v", got.Undeclared)
+	}
+	if !reflect.DeepEqual(got.Untouched, []string{"docs/guide.md"}) {
+		t.Errorf("untouched = %v", got.Untouched)
+	}
+	if !reflect.DeepEqual(got.Ignored, []string{".spec-workflow/specs/demo/tasks.md"}) {
+		t.Errorf("ignored = %v", got.Ignored)
+	}
+	if got.InScope() {
+		t.Error("InScope = true, want false")
+	}
+	if r := CompareScope([]string{"src/"}, []string{"src/a.go"}); !r.InScope() {
+		t.Errorf("CompareScope = %+v, want in scope", r)
+	}
+}
@@ -0,0 +1,106 @@
+package tasks
+
+import "strings"
+
+// Graph is the dependency graph built from the Depends On metadata of a
+// document's tasks. Edges point from a task to the tasks it depends on;
+// references to IDs that are not in the body are kept apart in Missing.
+type Graph struct {
+	// IDs lists the task IDs in body order.
+	IDs []string
+	// Deps maps a task ID to the existing tasks it depends on.
+	Deps map[string][]string
+	// Dependents maps a task ID to the tasks that depend on it.
+	Dependents map[string][]string
+	// Missing maps a task ID to the dependency IDs it names that do not
+	// exist.
+	Missing map[string][]string
+}
+
+// BuildGraph builds the dependency graph of doc.
+func BuildGraph(doc Document) *Graph {
+	g := &Graph{
+		Deps:       make(map[string][]string),
+		Dependents: make(map[string][]string),
+		Missing:    make(map[string][]string),
+	}
+	exists := make(map[string]bool, len(doc.Tasks))
+	for _, t := range doc.Tasks {
+		exists[t.ID] = true
+		g.IDs = append(g.IDs, t.ID)
+	}
+	for _, t := range doc.Tasks {
+		for _, dep := range t.DependsOn {
+			if !exists[dep] {
+				g.Missing[t.ID] = append(g.Missing[t.ID], dep)
+				continue
+			}
+			g.Deps[t.ID] = append(g.Deps[t.ID], dep)
+			g.Dependents[dep] = append(g.Dependents[dep], t.ID)
+		}
+	}
+	return g
+}
+
+// Cycles returns every elementary cycle reachable by a depth-first walk in
+// body order, each once, starting at its earliest task in the body. A
+// self-dependency is a cycle of one task.
+func (g *Graph) Cycles() [][]string {
+	const (
+		white = iota
+		grey
+		black
+	)
+	order := make(map[string]int, len(g.IDs))
+	for i, id := range g.IDs {
+		order[id] = i
+	}
+	color := make(map[string]int, len(g.IDs))
+	seen := make(map[string]bool)
+	var cycles [][]string
+	var path []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		color[id] = grey
+		path = append(path, id)
+		for _, dep := range g.Deps[id] {
+			switch color[dep] {
+			case white:
+				visit(dep)
+			case grey:
+				start := len(path) - 1
+				for path[start] != dep {
+					start--
+				}
+				cycle := rotateToFirst(path[start:], order)
+				if key := strings.Join(cycle, ">"); !seen[key] {
+					seen[key] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		color[id] = black
+	}
+	for _, id := range g.IDs {
+		if color[id] == white {
+			visit(id)
+		}
+	}
+	return cycles
+}
+
+// rotateToFirst returns a copy of cycle starting at the task that comes
+// first in the body, so the same cycle always reads the same way.
+func rotateToFirst(cycle []string, order map[string]int) []string {
+	first := 0
+	for i, id := range cycle {
+		if order[id] < order[cycle[first]] {
+			first = i
+		}
+	}
+	out := make([]string, 0, len(cycle))
+	out = append(out, cycle[first:]...)
+	return append(out, cycle[:first]...)
+}
@@ -0,0 +1,200 @@
+package tasks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Status fill and stroke colors shared by the Mermaid and DOT renderers.
+var statusColors = map[string][2]string{
+	"done":        {"#d4edda", "#28a745"},
+	"in_progress": {"#fff3cd", "#d39e00"},
+	"pending":     {"#f8f9fa", "#6c757d"},
+	"blocked":     {"#f8d7da", "#dc3545"},
+	"skipped":     {"#e2e3e5", "#adb5bd"},
+}
+
+// GraphView is a render-ready view of the task graph: tasks grouped by
+// wave, deferred tasks in a group of their own, and one edge per existing
+// dependency.
+type GraphView struct {
+	Groups []GraphGroup `json:"groups"`
+	Nodes  []GraphNode  `json:"nodes"`
+	Edges  []GraphEdge  `json:"edges"`
+}
+
+// GraphGroup is a wave (Wave > 0), the tasks without a wave (Wave 0) or
+// the deferred tasks (Deferred).
+type GraphGroup struct {
+	Label    string   `json:"label"`
+	Wave     int      `json:"wave,omitempty"`
+	Deferred bool     `json:"deferred,omitempty"`
+	TaskIDs  []string `json:"task_ids"`
+}
+
+// GraphNode is one task.
+type GraphNode struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Status   string `json:"status"`
+	Wave     int    `json:"wave"`
+	Deferred bool   `json:"deferred"`
+}
+
+// GraphEdge points from a dependency to the task that depends on it.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// BuildView groups the tasks of doc by wave. Waves come in ascending
+// order, then tasks without a wave, then deferred tasks.
+func BuildView(doc Document) GraphView {
+	v := GraphView{Groups: []GraphGroup{}, Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	byWave := make(map[int][]string)
+	var deferred []string
+	for _, t := range doc.Tasks {
+		v.Nodes = append(v.Nodes, GraphNode{ID: t.ID, Title: t.Title, Status: t.Status, Wave: t.Wave, Deferred: t.IsDeferred})
+		if t.IsDeferred {
+			deferred = append(deferred, t.ID)
+		} else {
+			byWave[t.Wave] = append(byWave[t.Wave], t.ID)
+		}
+	}
+
+	waves := make([]int, 0, len(byWave))
+	for w := range byWave {
+		if w > 0 {
+			waves = append(waves, w)
+		}
+	}
+	sort.Ints(waves)
+	for _, w := range waves {
+		v.Groups = append(v.Groups, GraphGroup{Label: fmt.Sprintf("Wave %d", w), Wave: w, TaskIDs: byWave[w]})
+	}
+	if ids := byWave[0]; len(ids) > 0 {
+		v.Groups = append(v.Groups, GraphGroup{Label: "No wave", TaskIDs: ids})
+	}
+	if len(deferred) > 0 {
+		v.Groups = append(v.Groups, GraphGroup{Label: "Deferred", Deferred: true, TaskIDs: deferred})
+	}
+
+	g := BuildGraph(doc)
+	for _, id := range g.IDs {
+		for _, dep := range g.Deps[id] {
+			v.Edges = append(v.Edges, GraphEdge{From: dep, To: id})
+		}
+	}
+	return v
+}
+
+// RenderMermaid renders v as a Mermaid flowchart with one subgraph per
+// group and one class per status. Deferred tasks are also dashed.
+func RenderMermaid(v GraphView) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	nodes := nodesByID(v)
+	for i, grp := range v.Groups {
+		fmt.Fprintf(&b, "  subgraph g%d[\"%s\"]\n", i+1, grp.Label)
+		for _, id := range grp.TaskIDs {
+			n := nodes[id]
+			fmt.Fprintf(&b, "    %s[\"%s\"]\n", mermaidID(id), mermaidEscape(n.ID+" "+n.Title))
+		}
+		b.WriteString("  end\n")
+	}
+	for _, e := range v.Edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", mermaidID(e.From), mermaidID(e.To))
+	}
+
+	byStatus := make(map[string][]string)
+	var deferred []string
+	for _, n := range v.Nodes {
+		byStatus[n.Status] = append(byStatus[n.Status], mermaidID(n.ID))
+		if n.Deferred {
+			deferred = append(deferred, mermaidID(n.ID))
+		}
+	}
+	for _, status := range sortedKeys(byStatus) {
+		fill, stroke := nodeColors(status)
+		fmt.Fprintf(&b, "  classDef %s fill:%s,stroke:%s\n", status, fill, stroke)
+		fmt.Fprintf(&b, "  class %s %s\n", strings.Join(byStatus[status], ","), status)
+	}
+	if len(deferred) > 0 {
+		b.WriteString("  classDef deferred stroke-dasharray:5 5\n")
+		fmt.Fprintf(&b, "  class %s deferred\n", strings.Join(deferred, ","))
+	}
+	return b.String()
+}
+
+// RenderDot renders v as a Graphviz digraph with one cluster per group.
+func RenderDot(v GraphView) string {
+	var b strings.Builder
+	b.WriteString("digraph tasks {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\"];\n")
+	nodes := nodesByID(v)
+	for i, grp := range v.Groups {
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n", i+1)
+		fmt.Fprintf(&b, "    label=%s;\n", dotQuote(grp.Label))
+		if grp.Deferred {
+			b.WriteString("    style=dashed;\n")
+		}
+		for _, id := range grp.TaskIDs {
+			n := nodes[id]
+			fill, stroke := nodeColors(n.Status)
+			style := ""
+			if n.Deferred {
+				style = ", style=\"rounded,filled,dashed\""
+			}
+			fmt.Fprintf(&b, "    %s [label=%s, fillcolor=%s, color=%s%s];\n",
+				dotQuote(id), dotQuote(n.ID+" "+n.Title), dotQuote(fill), dotQuote(stroke), style)
+		}
+		b.WriteString("  }\n")
+	}
+	for _, e := range v.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func nodesByID(v GraphView) map[string]GraphNode {
+	m := make(map[string]GraphNode, len(v.Nodes))
+	for _, n := range v.Nodes {
+		m[n.ID] = n
+	}
+	return m
+}
+
+func nodeColors(status string) (fill, stroke string) {
+	c, ok := statusColors[status]
+	if !ok {
+		c = statusColors["pending"]
+	}
+	return c[0], c[1]
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mermaidID turns a task ID into a Mermaid node ID ("2.1" -> "t2_1").
+func mermaidID(id string) string {
+	return "t" + strings.ReplaceAll(id, ".", "_")
+}
+
+// mermaidEscape makes s safe inside a quoted Mermaid label.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s)
+}
+
+// dotQuote returns s as a quoted DOT ID.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(s) + `"`
+}
@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildView(t *testing.T) {
+	v := BuildView(Parse(readFixture(t, "deferred-ready.md")))
+	var labels []string
+	for _, g := range v.Groups {
+		labels = append(labels, g.Label)
+	}
+	if got := strings.Join(labels, ","); got != "Wave 1,Wave 2,Deferred" {
+		t.Errorf("groups = %s", got)
+	}
+	if last := v.Groups[len(v.Groups)-1]; !last.Deferred || strings.Join(last.TaskIDs, ",") != "5" {
+		t.Errorf("deferred group = %+v", last)
+	}
+	if len(v.Edges) != 4 || v.Edges[0] != (GraphEdge{From: "1", To: "2"}) {
+		t.Errorf("edges = %+v", v.Edges)
+	}
+}
+
+func TestRenderMermaidAndDot(t *testing.T) {
+	doc := Parse(`## Tasks
+
+- [x] 1 Parse "config"
+  Wave: 1
+  Depends On: none
+
+- [-] 2.1 Wire it
+  Wave: 2
+  Depends On: Task 1, Task 8
+`)
+	mm := RenderMermaid(BuildView(doc))
+	for _, want := range []string{
+		"flowchart LR\n",
+		"  subgraph g2[\"Wave 2\"]\n    t2_1[\"2.1 Wire it\"]\n  end\n",
+		`t1["1 Parse #quot;config#quot;"]`,
+		"  t1 --> t2_1\n",
+		"  class t2_1 in_progress\n",
+	} {
+		if !strings.Contains(mm, want) {
+			t.Errorf("mermaid missing %q:\n%s", want, mm)
+		}
+	}
+	if strings.Contains(mm, "t8") {
+		t.Errorf("mermaid has an edge to a missing task:\n%s", mm)
+	}
+
+	dot := RenderDot(BuildView(doc))
+	for _, want := range []string{
+		"digraph tasks {\n",
+		`label="1 Parse \"config\""`,
+		`"1" -> "2.1";`,
+		`fillcolor="#fff3cd"`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("dot missing %q:\n%s", want, dot)
+		}
+	}
+}
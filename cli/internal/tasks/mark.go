@@ -1,12 +1,14 @@
 package tasks
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/lucas-stellet/oraculo/internal/fsutil"
 	"github.com/lucas-stellet/oraculo/internal/specdir"
 	"github.com/lucas-stellet/oraculo/internal/store"
 )
@@ -16,69 +18,175 @@ var statusToChar = map[string]string{
 	"done":        "x",
 	"in_progress": "-",
 	"pending":     " ",
+	"blocked":     "!",
+	"skipped":     "~",
 }
 
 // implLogRe matches implementation log filenames: task-<id>.md or task_<id>.md
 var implLogRe = regexp.MustCompile(`^task[_\-]?(\S+)\.md$`)
 
+// NeedsReason reports whether a status must be recorded with a reason.
+func NeedsReason(status string) bool {
+	return status == "blocked" || status == "skipped"
+}
+
+// ErrStale is returned when tasks.md no longer has the content hash the
+// caller expected, i.e. someone else changed it in between.
+var ErrStale = errors.New("tasks.md was modified concurrently")
+
+// MarkRequest describes one checkbox update.
+type MarkRequest struct {
+	TaskID string
+	Status string
+	// Reason is required for blocked and skipped and dropped otherwise.
+	Reason string
+	// RequireImplLog refuses "done" unless the task has an implementation
+	// log.
+	RequireImplLog bool
+	// ExpectHash, when set, is the content hash (or a prefix of it, as
+	// printed by `tasks state`) tasks.md must still have.
+	ExpectHash string
+}
+
 // MarkTaskInFile atomically updates a single task's checkbox in tasks.md.
-// The update is surgical: only the checkbox character changes.
+// The update is surgical: the checkbox character changes and a leftover
+// Reason line of the task is removed; nothing else in the file moves.
 // If requireImplLog is true, the task cannot be marked "done" unless an
 // implementation log exists at execution/_implementation-logs/task-<id>.md.
 func MarkTaskInFile(filePath, taskID, newStatus string, requireImplLog bool, specDir string) error {
-	char, ok := statusToChar[newStatus]
-	if !ok {
-		return fmt.Errorf("invalid status %q: must be done, in_progress, or pending", newStatus)
+	return MarkTaskWithReason(filePath, taskID, newStatus, "", requireImplLog, specDir)
+}
+
+// MarkTaskWithReason is MarkTaskInFile with a reason. Marking a task
+// blocked or skipped requires one, recorded as a "Reason:" line under the
+// task; marking any other status removes that line.
+func MarkTaskWithReason(filePath, taskID, newStatus, reason string, requireImplLog bool, specDir string) error {
+	_, err := Mark(filePath, specDir, MarkRequest{TaskID: taskID, Status: newStatus, Reason: reason, RequireImplLog: requireImplLog})
+	return err
+}
+
+// Mark applies req to tasks.md and returns the new content hash. The
+// read-modify-write runs under an advisory lock on the spec directory and
+// the file is replaced atomically, so concurrent marks never lose an
+// update. The task row and journal event reach spec.db under the same
+// lock, so spec.db sees marks in the order tasks.md does. With
+// req.ExpectHash set, Mark fails with ErrStale when the file changed since
+// the caller read it.
+func Mark(filePath, specDir string, req MarkRequest) (string, error) {
+	reason, err := statusReason(req.Status, req.Reason)
+	if err != nil {
+		return "", err
 	}
 
 	// If marking done, check for implementation log
-	if newStatus == "done" && requireImplLog {
-		if err := checkImplLog(specDir, taskID); err != nil {
-			return err
+	if req.Status == "done" && req.RequireImplLog {
+		if err := checkImplLog(specDir, req.TaskID); err != nil {
+			return "", err
 		}
 	}
 
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("cannot read %s: %w", filePath, err)
-	}
-
-	lines := strings.Split(string(data), "\n")
-	found := false
+	var hash string
+	err = fsutil.WithLock(filePath, func() error {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", filePath, err)
+		}
+		if err := CheckHash(data, req.ExpectHash); err != nil {
+			return err
+		}
 
-	// Build a regex to match the specific task line
-	taskPattern := regexp.MustCompile(`^(- \[)([ x\-])(\] ` + regexp.QuoteMeta(taskID) + `\s)`)
+		ed := NewEditor(string(data))
+		prevStatus, prevReason, err := ed.SetStatus(req.TaskID, req.Status, reason)
+		if err != nil {
+			return fmt.Errorf("task %s not found in %s", req.TaskID, filePath)
+		}
 
-	for i, line := range lines {
-		if m := taskPattern.FindStringSubmatchIndex(line); m != nil {
-			// Replace only the checkbox character (group 2)
-			lines[i] = line[:m[4]] + char + line[m[5]:]
-			found = true
-			break
+		// Write back preserving original line endings
+		content := []byte(ed.Content())
+		if err := fsutil.WriteAtomic(filePath, content, 0644); err != nil {
+			return err
+		}
+		if err := syncMark(specDir, ed.Document(), req, prevStatus, prevReason, reason); err != nil {
+			// Put tasks.md back so it stays in step with spec.db.
+			if rerr := fsutil.WriteAtomic(filePath, data, 0644); rerr != nil {
+				return fmt.Errorf("%w (and restoring %s failed: %v)", err, filePath, rerr)
+			}
+			return err
 		}
+		hash = store.ContentHash(content)
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
+	return hash, nil
+}
 
-	if !found {
-		return fmt.Errorf("task %s not found in %s", taskID, filePath)
+// syncMark writes the marked task's row and its journal event to spec.db
+// in one transaction. Like every dual write it is skipped when the spec has
+// no usable spec.db.
+func syncMark(specDir string, doc Document, req MarkRequest, prevStatus, prevReason, reason string) error {
+	if specDir == "" {
+		return nil
+	}
+	s := store.TryOpen(specDir)
+	if s == nil {
+		return nil
 	}
+	defer s.Close()
 
-	// Write back preserving original line endings
-	output := strings.Join(lines, "\n")
-	if err := os.WriteFile(filePath, []byte(output), 0644); err != nil {
-		return err
+	var records []store.TaskRecord
+	if t := doc.TaskByID(req.TaskID); t != nil {
+		records = append(records, t.Record())
+	}
+	before := map[string]any{"status": prevStatus}
+	if prevReason != "" {
+		before["reason"] = prevReason
+	}
+	after := map[string]any{"status": req.Status}
+	args := map[string]any{"task_id": req.TaskID, "status": req.Status}
+	if reason != "" {
+		after["reason"] = reason
+		args["reason"] = reason
 	}
+	return s.SyncTaskBatch(records, []store.Event{{
+		Command: "tasks mark",
+		TaskID:  req.TaskID,
+		Args:    args,
+		Before:  before,
+		After:   after,
+	}})
+}
 
-	// Dual-write: sync task status to spec.db
-	if specDir != "" {
-		if s := store.TryOpen(specDir); s != nil {
-			defer s.Close()
-			s.SyncTask(store.TaskRecord{
-				TaskID: taskID,
-				Status: newStatus,
-			})
-		}
+// statusReason validates status and returns the reason to record with it:
+// required and trimmed for blocked and skipped, empty for the rest.
+func statusReason(status, reason string) (string, error) {
+	if _, ok := statusToChar[status]; !ok {
+		return "", fmt.Errorf("invalid status %q: must be done, in_progress, pending, blocked, or skipped", status)
+	}
+	reason = strings.TrimSpace(reason)
+	if !NeedsReason(status) {
+		return "", nil
 	}
+	if reason == "" {
+		return "", fmt.Errorf("status %s requires a reason", status)
+	}
+	return reason, nil
+}
 
+// CheckHash returns ErrStale when expect is set and is neither the content
+// hash of data nor a prefix of it (at least 8 characters).
+func CheckHash(data []byte, expect string) error {
+	expect = strings.ToLower(strings.TrimSpace(expect))
+	if expect == "" {
+		return nil
+	}
+	if len(expect) < 8 {
+		return fmt.Errorf("expected hash %q is too short (use at least 8 characters)", expect)
+	}
+	if actual := store.ContentHash(data); !strings.HasPrefix(actual, expect) {
+		return fmt.Errorf("%w: expected hash %s, found %s", ErrStale, expect, actual)
+	}
 	return nil
 }
 
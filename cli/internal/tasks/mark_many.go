@@ -0,0 +1,214 @@
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/fsutil"
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+// BatchRequest describes one status applied to several tasks at once.
+type BatchRequest struct {
+	// TaskIDs are the tasks to update.
+	TaskIDs []string
+	// Wave, when positive, adds every task of that wave except skipped
+	// ones; name a skipped task in TaskIDs to change it.
+	Wave   int
+	Status string
+	// Reason is required for blocked and skipped and dropped otherwise.
+	Reason         string
+	RequireImplLog bool
+	// ExpectHash, when set, is the content hash (or a prefix of it)
+	// tasks.md must still have.
+	ExpectHash string
+}
+
+// Transition is the status change of one task in a batch.
+type Transition struct {
+	TaskID  string `json:"task_id"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Changed bool   `json:"changed"`
+}
+
+// BatchResult is the outcome of MarkMany.
+type BatchResult struct {
+	Hash        string       `json:"hash"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// BatchError lists every transition of a batch that failed validation.
+type BatchError struct {
+	Problems []string
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d of the requested transitions are invalid, nothing was changed: %s",
+		len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// MarkMany applies req to every selected task, all or none. Every
+// transition is validated first (task exists, reason given, implementation
+// log present when required for a task not yet done) and any failure
+// aborts the batch with a BatchError naming all of them. tasks.md is then
+// rewritten once under the spec directory lock and spec.db is updated in a
+// single transaction; if that transaction fails, tasks.md is put back as it
+// was.
+func MarkMany(filePath, specDir string, req BatchRequest) (BatchResult, error) {
+	var res BatchResult
+	reason, err := statusReason(req.Status, req.Reason)
+	if err != nil {
+		return res, err
+	}
+
+	err = fsutil.WithLock(filePath, func() error {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", filePath, err)
+		}
+		if err := CheckHash(data, req.ExpectHash); err != nil {
+			return err
+		}
+
+		ed := NewEditor(string(data))
+		doc := ed.Document()
+		ids, err := batchIDs(doc, req)
+		if err != nil {
+			return err
+		}
+
+		var problems []string
+		for _, id := range ids {
+			t := doc.TaskByID(id)
+			if t == nil {
+				problems = append(problems, fmt.Sprintf("task %s not found", id))
+				continue
+			}
+			// A task that is already done was checked when it was marked.
+			if req.Status == "done" && t.Status != "done" && req.RequireImplLog {
+				if err := checkImplLog(specDir, id); err != nil {
+					problems = append(problems, err.Error())
+				}
+			}
+		}
+		if len(problems) > 0 {
+			return &BatchError{Problems: problems}
+		}
+
+		for _, id := range ids {
+			prevStatus, prevReason, err := ed.SetStatus(id, req.Status, reason)
+			if err != nil {
+				return err
+			}
+			res.Transitions = append(res.Transitions, Transition{
+				TaskID:  id,
+				From:    prevStatus,
+				To:      req.Status,
+				Changed: prevStatus != req.Status || prevReason != reason,
+			})
+		}
+
+		content := []byte(ed.Content())
+		if err := fsutil.WriteAtomic(filePath, content, 0644); err != nil {
+			return err
+		}
+		if err := syncBatch(specDir, ed.Document(), res.Transitions, reason); err != nil {
+			// Put tasks.md back so it stays in step with spec.db.
+			if rerr := fsutil.WriteAtomic(filePath, data, 0644); rerr != nil {
+				return fmt.Errorf("%w (and restoring %s failed: %v)", err, filePath, rerr)
+			}
+			return err
+		}
+		res.Hash = store.ContentHash(content)
+		return nil
+	})
+	if err != nil {
+		return BatchResult{}, err
+	}
+	return res, nil
+}
+
+// batchIDs returns the task IDs req selects in doc, in the order given and
+// without duplicates.
+func batchIDs(doc Document, req BatchRequest) ([]string, error) {
+	var ids []string
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range req.TaskIDs {
+		add(strings.TrimSpace(id))
+	}
+	if req.Wave > 0 {
+		found := false
+		for _, t := range doc.Tasks {
+			if t.Wave != req.Wave {
+				continue
+			}
+			found = true
+			if t.Status != "skipped" {
+				add(t.ID)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("wave %d has no tasks", req.Wave)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no tasks selected")
+	}
+	return ids, nil
+}
+
+// syncBatch writes the changed task rows and one journal event per changed
+// task to spec.db in one transaction. Like every dual write it is skipped
+// when the spec has no usable spec.db.
+func syncBatch(specDir string, doc Document, transitions []Transition, reason string) error {
+	if specDir == "" {
+		return nil
+	}
+	s := store.TryOpen(specDir)
+	if s == nil {
+		return nil
+	}
+	defer s.Close()
+
+	var (
+		records []store.TaskRecord
+		events  []store.Event
+		ids     []string
+	)
+	for _, tr := range transitions {
+		ids = append(ids, tr.TaskID)
+	}
+	for _, tr := range transitions {
+		if !tr.Changed {
+			continue
+		}
+		if t := doc.TaskByID(tr.TaskID); t != nil {
+			records = append(records, t.Record())
+		}
+		args := map[string]any{"task_ids": ids, "status": tr.To}
+		after := map[string]any{"status": tr.To}
+		if reason != "" {
+			args["reason"] = reason
+			after["reason"] = reason
+		}
+		events = append(events, store.Event{
+			Command: "tasks mark-many",
+			TaskID:  tr.TaskID,
+			Args:    args,
+			Before:  map[string]any{"status": tr.From},
+			After:   after,
+		})
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return s.SyncTaskBatch(records, events)
+}
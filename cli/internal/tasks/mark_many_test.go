@@ -0,0 +1,84 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+func TestMarkMany_AllOrNone(t *testing.T) {
+	dir := t.TempDir()
+	filePath := writeTasksFile(t, dir, markTestContent)
+
+	_, err := MarkMany(filePath, dir, BatchRequest{TaskIDs: []string{"2", "3", "99"}, Status: "done", RequireImplLog: true})
+	var be *BatchError
+	if !errors.As(err, &be) {
+		t.Fatalf("err = %v, want BatchError", err)
+	}
+	// Missing impl logs for 2 and 3, and 99 does not exist.
+	if len(be.Problems) != 3 {
+		t.Errorf("problems = %v, want 3", be.Problems)
+	}
+	if data, _ := os.ReadFile(filePath); string(data) != markTestContent {
+		t.Error("tasks.md changed although the batch was rejected")
+	}
+
+	mkImplLog(t, dir, "2")
+	mkImplLog(t, dir, "3")
+	res, err := MarkMany(filePath, dir, BatchRequest{TaskIDs: []string{"1", "2", "3", "2"}, Status: "done", RequireImplLog: true})
+	if err != nil {
+		t.Fatalf("MarkMany: %v", err)
+	}
+	if len(res.Transitions) != 3 {
+		t.Fatalf("transitions = %+v, want 3 (duplicate dropped)", res.Transitions)
+	}
+	if res.Transitions[0].Changed || !res.Transitions[1].Changed {
+		t.Errorf("changed flags = %+v, want task 1 unchanged and 2 changed", res.Transitions)
+	}
+	doc, _ := ParseFile(filePath)
+	for _, task := range doc.Tasks {
+		if task.Status != "done" {
+			t.Errorf("task %s = %s, want done", task.ID, task.Status)
+		}
+	}
+
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	rows, _ := s.ListTasks()
+	if len(rows) != 2 {
+		t.Errorf("spec.db rows = %d, want 2 (only changed tasks)", len(rows))
+	}
+	evs, _ := s.ListEvents(store.EventFilter{Command: "tasks mark-many"})
+	if len(evs) != 2 {
+		t.Errorf("events = %d, want 2", len(evs))
+	}
+}
+
+func TestMarkMany_Wave(t *testing.T) {
+	dir := t.TempDir()
+	filePath := writeTasksFile(t, dir, markTestContent)
+	if err := MarkTaskWithReason(filePath, "2", "skipped", "not needed", false, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := MarkMany(filePath, "", BatchRequest{Wave: 1, Status: "pending"})
+	if err != nil {
+		t.Fatalf("MarkMany: %v", err)
+	}
+	// The skipped task of wave 1 is left alone.
+	if len(res.Transitions) != 1 || res.Transitions[0].TaskID != "1" {
+		t.Errorf("transitions = %+v, want only task 1", res.Transitions)
+	}
+
+	if _, err := MarkMany(filePath, "", BatchRequest{Wave: 9, Status: "done"}); err == nil {
+		t.Error("expected error for a wave without tasks")
+	}
+	if _, err := MarkMany(filePath, "", BatchRequest{Wave: 2, Status: "blocked"}); err == nil {
+		t.Error("expected error for blocked without a reason")
+	}
+}
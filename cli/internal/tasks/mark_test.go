@@ -1,11 +1,16 @@
 package tasks
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+
+	"github.com/lucas-stellet/oraculo/internal/embedded"
+	"github.com/lucas-stellet/oraculo/internal/store"
 )
 
 // mkImplLog creates an implementation log file for a task.
@@ -183,3 +188,195 @@ func TestMarkTaskInFile_NotFound(t *testing.T) {
 		t.Errorf("error = %q, want 'task 99 not found'", err.Error())
 	}
 }
+
+// Blocked needs a reason, recorded under the task; marking pending clears it.
+func TestMarkTaskWithReason_BlockedThenPending(t *testing.T) {
+	dir := t.TempDir()
+	filePath := writeTasksFile(t, dir, markTestContent)
+
+	if err := MarkTaskWithReason(filePath, "2", "blocked", "", false, ""); err == nil {
+		t.Fatal("expected error for blocked without a reason")
+	}
+	if err := MarkTaskWithReason(filePath, "2", "blocked", "waiting on API keys", false, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(filePath)
+	if !strings.Contains(string(data), "- [!] 2 Second task\n  Reason: waiting on API keys\n  Wave: 1\n") {
+		t.Errorf("blocked task not recorded:\n%s", data)
+	}
+	doc := Parse(string(data))
+	if task := doc.TaskByID("2"); task.Status != "blocked" || task.Reason != "waiting on API keys" {
+		t.Errorf("parsed task = %+v", task)
+	}
+
+	if err := MarkTaskInFile(filePath, "2", "pending", false, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ = os.ReadFile(filePath)
+	if string(data) != markTestContent {
+		t.Errorf("pending did not restore the original content:\n%s", data)
+	}
+}
+
+// The template nests a Reason item under No-Test Justification; it is not
+// the task's Reason and a mark must neither read nor rewrite it.
+func TestMarkTaskWithReason_Template(t *testing.T) {
+	tmpl, err := embedded.Defaults.ReadFile("defaults/user-templates/tasks-template.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const nested = "  - No-Test Justification (only for exception):\n    - Reason:\n"
+	if !strings.Contains(string(tmpl), nested) {
+		t.Fatalf("template no longer nests a Reason under No-Test Justification")
+	}
+	filled := strings.Replace(string(tmpl), nested, "  - No-Test Justification (only for exception):\n    - Reason: legacy module\n", 1)
+	doc := Parse(filled)
+	if task := doc.TaskByID("1.2"); task == nil || task.Reason != "" {
+		t.Fatalf("nested reason read as the task's: %+v", task)
+	}
+
+	dir := t.TempDir()
+	filePath := writeTasksFile(t, dir, filled)
+	if err := MarkTaskWithReason(filePath, "1.2", "blocked", "waiting on API keys", false, ""); err != nil {
+		t.Fatalf("mark blocked: %v", err)
+	}
+	data, _ := os.ReadFile(filePath)
+	if !strings.Contains(string(data), "- [!] 1.2 [Task title]\n  - Reason: waiting on API keys\n  - Wave: 1\n") {
+		t.Errorf("reason not added at the metadata indent:\n%s", data)
+	}
+	if !strings.Contains(string(data), "    - Reason: legacy module\n") {
+		t.Errorf("nested reason rewritten:\n%s", data)
+	}
+	doc = Parse(string(data))
+	if task := doc.TaskByID("1.2"); task.Reason != "waiting on API keys" {
+		t.Errorf("reason = %q", task.Reason)
+	}
+
+	if err := MarkTaskInFile(filePath, "1.2", "pending", false, ""); err != nil {
+		t.Fatalf("mark pending: %v", err)
+	}
+	data, _ = os.ReadFile(filePath)
+	if string(data) != filled {
+		t.Errorf("pending did not restore the template:\n%s", data)
+	}
+}
+
+func TestMarkTaskWithReason_SkippedCounts(t *testing.T) {
+	dir := t.TempDir()
+	filePath := writeTasksFile(t, dir, markTestContent)
+
+	if err := MarkTaskWithReason(filePath, "3", "skipped", "covered by task 2", false, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc, err := ParseFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := doc.Count()
+	if c.Skipped != 1 || c.Done != 1 || c.Pending != 1 {
+		t.Errorf("counts = %+v", c)
+	}
+}
+
+func TestMark_ExpectHash(t *testing.T) {
+	dir := t.TempDir()
+	filePath := writeTasksFile(t, dir, markTestContent)
+	hash := store.ContentHash([]byte(markTestContent))
+
+	if _, err := Mark(filePath, "", MarkRequest{TaskID: "2", Status: "in_progress", ExpectHash: "deadbeef"}); !errors.Is(err, ErrStale) {
+		t.Fatalf("wrong hash: err = %v, want ErrStale", err)
+	}
+	if _, err := Mark(filePath, "", MarkRequest{TaskID: "2", Status: "in_progress", ExpectHash: hash[:4]}); err == nil {
+		t.Fatal("expected error for a hash prefix shorter than 8 characters")
+	}
+
+	newHash, err := Mark(filePath, "", MarkRequest{TaskID: "2", Status: "in_progress", ExpectHash: hash[:8]})
+	if err != nil {
+		t.Fatalf("Mark: %v", err)
+	}
+	data, _ := os.ReadFile(filePath)
+	if newHash != store.ContentHash(data) {
+		t.Errorf("returned hash %s does not match the file", newHash)
+	}
+
+	// The old hash is now stale.
+	if _, err := Mark(filePath, "", MarkRequest{TaskID: "3", Status: "in_progress", ExpectHash: hash}); !errors.Is(err, ErrStale) {
+		t.Errorf("stale hash: err = %v, want ErrStale", err)
+	}
+}
+
+func TestMark_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	var sb strings.Builder
+	sb.WriteString("# Tasks\n\n")
+	const n = 12
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "- [ ] %d Task %d\n  Wave: 1\n\n", i, i)
+	}
+	filePath := writeTasksFile(t, dir, sb.String())
+
+	var wg sync.WaitGroup
+	for i := 1; i <= n; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			if _, err := Mark(filePath, "", MarkRequest{TaskID: id, Status: "done"}); err != nil {
+				t.Errorf("Mark %s: %v", id, err)
+			}
+		}(fmt.Sprint(i))
+	}
+	wg.Wait()
+
+	doc, err := ParseFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range doc.Tasks {
+		if task.Status != "done" {
+			t.Errorf("task %s status = %s, want done (update lost)", task.ID, task.Status)
+		}
+	}
+}
+
+// Marks racing on one task leave spec.db where tasks.md ends up: the row
+// and the last journal event are written under the tasks.md lock.
+func TestMark_ConcurrentSpecDB(t *testing.T) {
+	dir := t.TempDir()
+	filePath := writeTasksFile(t, dir, "# Tasks\n\n- [ ] 1 Task one\n  Wave: 1\n")
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := range n {
+		status := []string{"done", "in_progress"}[i%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := Mark(filePath, dir, MarkRequest{TaskID: "1", Status: status}); err != nil {
+				t.Errorf("Mark %s: %v", status, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	doc, err := ParseFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := doc.TaskByID("1").Status
+	rows, _ := s.ListTasks()
+	if len(rows) != 1 || rows[0].Status != want {
+		t.Errorf("spec.db rows = %+v, tasks.md status %s", rows, want)
+	}
+	events, _ := s.ListEvents(store.EventFilter{TaskID: "1"})
+	if len(events) != n {
+		t.Fatalf("events = %d, want %d", len(events), n)
+	}
+	if after, _ := events[len(events)-1].After.(map[string]any); after["status"] != want {
+		t.Errorf("last event after = %v, tasks.md status %s", after, want)
+	}
+}
@@ -0,0 +1,112 @@
+package tasks
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Field returns the value of a task field by name: one of the built-in
+// fields (id, title, status, wave, depends_on, files, tdd, reason,
+// requirements, deferred) or a Meta key. Names match case-insensitively,
+// with spaces, hyphens and underscores treated alike, so "Depends On" finds
+// depends_on and "owner" finds an "Owner:" line.
+func (t Task) Field(name string) (string, bool) {
+	key := fieldKey(name)
+	switch key {
+	case "id":
+		return t.ID, true
+	case "title":
+		return t.Title, true
+	case "status":
+		return t.Status, true
+	case "wave":
+		return strconv.Itoa(t.Wave), true
+	case "depends_on":
+		return strings.Join(t.DependsOn, ","), true
+	case "files":
+		return t.Files, t.Files != ""
+	case "tdd":
+		return t.TDD, t.TDD != ""
+	case "reason":
+		return t.Reason, t.Reason != ""
+	case "requirements", "_requirements":
+		return strings.Join(t.Requirements, ","), len(t.Requirements) > 0
+	case "deferred", "is_deferred":
+		return strconv.FormatBool(t.IsDeferred), true
+	}
+	for k, v := range t.Meta {
+		if fieldKey(k) == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Condition is one key=value filter for Filter.
+type Condition struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ParseConditions parses "key=value" filters.
+func ParseConditions(exprs []string) ([]Condition, error) {
+	conds := make([]Condition, 0, len(exprs))
+	for _, expr := range exprs {
+		key, value, ok := strings.Cut(expr, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid filter %q (want key=value)", expr)
+		}
+		conds = append(conds, Condition{Key: key, Value: strings.TrimSpace(value)})
+	}
+	return conds, nil
+}
+
+// Filter returns the tasks of doc that match every condition. A value
+// matches case-insensitively; a task without the field never matches. The
+// list fields (depends_on, requirements, files) match when the value is
+// one of their entries, or the whole list as Field joins it.
+func Filter(doc Document, conds []Condition) []Task {
+	out := []Task{}
+	for _, t := range doc.Tasks {
+		if t.matches(conds) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func (t Task) matches(conds []Condition) bool {
+	for _, c := range conds {
+		v, ok := t.Field(c.Key)
+		if !ok {
+			return false
+		}
+		if !strings.EqualFold(v, c.Value) && !slices.ContainsFunc(t.listField(c.Key), func(e string) bool {
+			return strings.EqualFold(e, c.Value)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// listField returns the entries of a list-valued built-in field, or nil
+// for any other field.
+func (t Task) listField(name string) []string {
+	switch fieldKey(name) {
+	case "depends_on":
+		return t.DependsOn
+	case "requirements", "_requirements":
+		return t.Requirements
+	case "files":
+		return t.FileList()
+	}
+	return nil
+}
+
+func fieldKey(name string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
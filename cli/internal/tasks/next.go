@@ -2,23 +2,31 @@ package tasks
 
 import (
 	"fmt"
-	"path/filepath"
 	"sort"
-
-	"github.com/lucas-stellet/oraculo/internal/specdir"
 )
 
+// CheckpointFunc reports the wave state machine's state for a wave
+// (pending, executing, awaiting-checkpoint, passed, blocked, reopened)
+// and any warnings about how it was resolved. The wave package supplies
+// it; tasks cannot import wave.
+type CheckpointFunc func(waveNum int) (state string, warnings []string)
+
 // ResolveNextWave implements the critical next-wave algorithm:
 //  1. Check for in-progress tasks [-] -> return "continue-wave"
-//  2. Find highest completed wave (all tasks [x])
-//  3. Check checkpoint status via _latest.json-first resolution
-//  4. If checkpoint blocked -> return "blocked"
+//  2. Find highest completed wave (all tasks [x] or [~])
+//  3. Ask checkpoint for that wave's state (nil skips the check)
+//  4. If the wave is blocked -> return "blocked"
 //  5. Find next wave's pending tasks with deps resolved
 //  6. Scan ALL deferred tasks whose deps are [x] -> include in DeferredReady
-//  7. If executable tasks found -> return "execute"
-//  8. If nothing left + rolling-wave -> return "plan-next-wave"
-//  9. If nothing left + all-at-once -> return "done"
-func ResolveNextWave(doc Document, specDir string) NextWaveResult {
+//  7. If a blocked task holds an earlier wave than the executable tasks,
+//     or nothing is executable -> return "blocked" with its reason
+//  8. If executable tasks found -> return "execute", with a file_conflict
+//     warning for each pair of them declaring overlapping Files
+//  9. If nothing left -> return "plan-next-wave" (rolling-wave) or "done"
+//
+// A skipped task counts as settled: it completes its wave and resolves
+// the dependencies on it. A blocked task does neither.
+func ResolveNextWave(doc Document, checkpoint CheckpointFunc) NextWaveResult {
 	if len(doc.Tasks) == 0 {
 		return NextWaveResult{
 			Action: "done",
@@ -53,12 +61,12 @@ func ResolveNextWave(doc Document, specDir string) NextWaveResult {
 	// Step 2: Find highest completed wave
 	highestCompleted := findHighestCompletedWave(waveMap)
 
-	// Step 3-4: Check checkpoint status for highest completed wave
-	if highestCompleted > 0 && specDir != "" {
-		cpStatus, cpWarnings := resolveCheckpointStatus(specDir, highestCompleted)
+	// Step 3-4: Check the wave state of the highest completed wave
+	if highestCompleted > 0 && checkpoint != nil {
+		state, cpWarnings := checkpoint(highestCompleted)
 		warnings = append(warnings, cpWarnings...)
 
-		if cpStatus == "blocked" {
+		if state == "blocked" {
 			return NextWaveResult{
 				Action:   "blocked",
 				Wave:     highestCompleted,
@@ -74,8 +82,31 @@ func ResolveNextWave(doc Document, specDir string) NextWaveResult {
 	// Step 6: Scan ALL deferred tasks whose deps are resolved
 	deferredReady := findDeferredReady(doc.Tasks, statusByID)
 
-	// Step 7: If executable tasks found -> return "execute"
+	// Step 7: A blocked task holds its wave: later waves cannot start
+	if b := firstBlocked(waveMap, highestCompleted); b != nil && (len(executableIDs) == 0 || nextWave > b.Wave) {
+		reason := fmt.Sprintf("task %s is blocked", b.ID)
+		if b.Reason != "" {
+			reason += ": " + b.Reason
+		}
+		return NextWaveResult{
+			Action:   "blocked",
+			Wave:     b.Wave,
+			Reason:   reason,
+			Warnings: warnings,
+		}
+	}
+
+	// Step 8: If executable tasks found -> return "execute", warning about
+	// tasks that would run in parallel on overlapping files
 	if len(executableIDs) > 0 || len(deferredReady) > 0 {
+		var batch []Task
+		for _, id := range append(append([]string(nil), executableIDs...), deferredReady...) {
+			batch = append(batch, *doc.TaskByID(id))
+		}
+		for _, c := range ConflictsAmong(batch) {
+			warnings = append(warnings, "file_conflict: "+c.String())
+		}
+
 		return NextWaveResult{
 			Action:        "execute",
 			Wave:          nextWave,
@@ -85,7 +116,7 @@ func ResolveNextWave(doc Document, specDir string) NextWaveResult {
 		}
 	}
 
-	// Step 8-9: Nothing executable left
+	// Step 9: Nothing executable left
 	if doc.Frontmatter.GenerationStrategy == "rolling-wave" {
 		return NextWaveResult{
 			Action:   "plan-next-wave",
@@ -113,7 +144,8 @@ func buildWaveMap(tasks []Task) map[int][]Task {
 }
 
 // findHighestCompletedWave returns the highest wave number where all
-// non-deferred tasks have status == "done". Returns 0 if no wave is complete.
+// non-deferred tasks are settled (done or skipped). Returns 0 if no wave is
+// complete.
 func findHighestCompletedWave(waveMap map[int][]Task) int {
 	// Get sorted wave numbers
 	waves := make([]int, 0, len(waveMap))
@@ -126,7 +158,7 @@ func findHighestCompletedWave(waveMap map[int][]Task) int {
 	for _, w := range waves {
 		allDone := true
 		for _, t := range waveMap[w] {
-			if t.Status != "done" {
+			if !settled(t.Status) {
 				allDone = false
 				break
 			}
@@ -140,69 +172,6 @@ func findHighestCompletedWave(waveMap map[int][]Task) int {
 	return highest
 }
 
-// resolveCheckpointStatus resolves checkpoint status using _latest.json-first resolution:
-//  1. Read _latest.json -> get latest checkpoint run-id
-//  2. Read checkpoint/{run-id}/release-gate-decider/status.json
-//  3. If differs from _wave-summary.json -> flag stale_summary
-//  4. If _latest.json missing -> scan checkpoint dirs, highest run wins
-//  5. If no checkpoint dirs -> return "missing"
-func resolveCheckpointStatus(specDir string, waveNum int) (string, []string) {
-	var warnings []string
-
-	cpDir := specdir.WaveCheckpointPath(specDir, waveNum)
-	latestPath := specdir.WaveLatestPath(specDir, waveNum)
-	summaryPath := specdir.WaveSummaryPath(specDir, waveNum)
-
-	var runDir string
-	var resolvedFromLatest bool
-
-	// Step 1: Try _latest.json first
-	latestDoc, err := specdir.ReadLatestJSON(latestPath)
-	if err == nil && latestDoc.RunID != "" {
-		// Build the run path from the run ID
-		candidate := filepath.Join(cpDir, latestDoc.RunID)
-		if specdir.DirExists(candidate) {
-			runDir = candidate
-			resolvedFromLatest = true
-		}
-	}
-
-	// Step 4: Fallback — scan checkpoint dir for highest run
-	if runDir == "" {
-		if specdir.DirExists(cpDir) {
-			found, _, scanErr := specdir.LatestRunDir(cpDir)
-			if scanErr == nil {
-				runDir = found
-			}
-		}
-	}
-
-	// Step 5: No checkpoint at all
-	if runDir == "" {
-		return "missing", warnings
-	}
-
-	// Step 2: Read release-gate-decider status.json
-	statusPath := filepath.Join(runDir, "release-gate-decider", specdir.StatusJSON)
-	statusDoc, err := specdir.ReadStatusJSON(statusPath)
-	if err != nil {
-		return "missing", append(warnings, fmt.Sprintf("cannot read checkpoint status: %v", err))
-	}
-
-	// Step 3: Compare with _wave-summary.json if resolved from _latest.json
-	if resolvedFromLatest {
-		summaryDoc, summaryErr := specdir.ReadStatusJSON(summaryPath)
-		if summaryErr == nil && summaryDoc.Status != statusDoc.Status {
-			warnings = append(warnings, fmt.Sprintf(
-				"stale_summary: wave %d _wave-summary.json says %q but latest checkpoint says %q",
-				waveNum, summaryDoc.Status, statusDoc.Status,
-			))
-		}
-	}
-
-	return statusDoc.Status, warnings
-}
-
 // findExecutableTasks finds the next wave after highestCompleted and returns
 // tasks within it whose dependencies are all resolved (status == "done").
 func findExecutableTasks(tasks []Task, statusByID map[string]string, waveMap map[int][]Task, highestCompleted int) (int, []string) {
@@ -237,6 +206,27 @@ func findExecutableTasks(tasks []Task, statusByID map[string]string, waveMap map
 	return 0, nil
 }
 
+// firstBlocked returns the first blocked task of the lowest wave after
+// highestCompleted that has one, or nil.
+func firstBlocked(waveMap map[int][]Task, highestCompleted int) *Task {
+	waves := make([]int, 0, len(waveMap))
+	for w := range waveMap {
+		waves = append(waves, w)
+	}
+	sort.Ints(waves)
+	for _, w := range waves {
+		if w <= highestCompleted {
+			continue
+		}
+		for i := range waveMap[w] {
+			if waveMap[w][i].Status == "blocked" {
+				return &waveMap[w][i]
+			}
+		}
+	}
+	return nil
+}
+
 // findDeferredReady returns IDs of deferred tasks whose deps are all resolved.
 func findDeferredReady(tasks []Task, statusByID map[string]string) []string {
 	var ready []string
@@ -248,12 +238,17 @@ func findDeferredReady(tasks []Task, statusByID map[string]string) []string {
 	return ready
 }
 
-// depsResolved returns true if all of a task's dependencies have status == "done".
+// depsResolved returns true if all of a task's dependencies are settled.
 func depsResolved(t Task, statusByID map[string]string) bool {
 	for _, dep := range t.DependsOn {
-		if statusByID[dep] != "done" {
+		if !settled(statusByID[dep]) {
 			return false
 		}
 	}
 	return true
 }
+
+// settled reports whether a status needs no further work: done or skipped.
+func settled(status string) bool {
+	return status == "done" || status == "skipped"
+}
@@ -1,53 +1,17 @@
 package tasks
 
-import (
-	"encoding/json"
-	"fmt"
-	"os"
-	"path/filepath"
-	"testing"
-)
+import "testing"
 
 // --- helpers ---
 
-// mkCheckpointDir creates a wave checkpoint directory with status.json.
-func mkCheckpointDir(t *testing.T, specDir string, waveNum int, runID string, status string) {
-	t.Helper()
-	cpDir := filepath.Join(specDir, fmt.Sprintf("execution/waves/wave-%02d/checkpoint/%s/release-gate-decider", waveNum, runID))
-	if err := os.MkdirAll(cpDir, 0755); err != nil {
-		t.Fatal(err)
-	}
-	writeJSON(t, filepath.Join(cpDir, "status.json"), map[string]string{"status": status})
-}
-
-// mkWaveSummary creates a _wave-summary.json for a wave.
-func mkWaveSummary(t *testing.T, specDir string, waveNum int, status string) {
-	t.Helper()
-	waveDir := filepath.Join(specDir, fmt.Sprintf("execution/waves/wave-%02d", waveNum))
-	if err := os.MkdirAll(waveDir, 0755); err != nil {
-		t.Fatal(err)
-	}
-	writeJSON(t, filepath.Join(waveDir, "_wave-summary.json"), map[string]string{"status": status})
-}
-
-// mkLatestJSON creates a _latest.json for a wave.
-func mkLatestJSON(t *testing.T, specDir string, waveNum int, runID string) {
-	t.Helper()
-	waveDir := filepath.Join(specDir, fmt.Sprintf("execution/waves/wave-%02d", waveNum))
-	if err := os.MkdirAll(waveDir, 0755); err != nil {
-		t.Fatal(err)
-	}
-	writeJSON(t, filepath.Join(waveDir, "_latest.json"), map[string]string{"run_id": runID})
-}
-
-func writeJSON(t *testing.T, path string, v any) {
-	t.Helper()
-	data, err := json.Marshal(v)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		t.Fatal(err)
+// waveStates returns a CheckpointFunc reporting the given wave states;
+// other waves are pending.
+func waveStates(states map[int]string) CheckpointFunc {
+	return func(waveNum int) (string, []string) {
+		if st, ok := states[waveNum]; ok {
+			return st, nil
+		}
+		return "pending", nil
 	}
 }
 
@@ -65,15 +29,9 @@ func strSliceContains(ss []string, s string) bool {
 // A1: All tasks done, no deferred -> action: "done" (all-at-once)
 func TestResolveNextWave_A1_AllDone(t *testing.T) {
 	doc := Parse(readFixture(t, "all-done.md"))
-	specDir := t.TempDir()
-
-	// Create checkpoint dirs for waves to simulate completed state
-	mkCheckpointDir(t, specDir, 1, "run-001", "pass")
-	mkWaveSummary(t, specDir, 1, "pass")
-	mkCheckpointDir(t, specDir, 2, "run-001", "pass")
-	mkWaveSummary(t, specDir, 2, "pass")
 
-	result := ResolveNextWave(doc, specDir)
+	// Waves 1 and 2 report passed
+	result := ResolveNextWave(doc, waveStates(map[int]string{1: "passed", 2: "passed"}))
 
 	if result.Action != "done" {
 		t.Errorf("action = %q, want done", result.Action)
@@ -86,15 +44,9 @@ func TestResolveNextWave_A1_AllDone(t *testing.T) {
 // A2: Task 5 deferred, deps resolved (THE BUG) -> action: "execute", DeferredReady: ["5"]
 func TestResolveNextWave_A2_DeferredReady(t *testing.T) {
 	doc := Parse(readFixture(t, "deferred-ready.md"))
-	specDir := t.TempDir()
-
-	// All regular tasks (1-4) are done; wave 1+2 checkpoints pass
-	mkCheckpointDir(t, specDir, 1, "run-001", "pass")
-	mkWaveSummary(t, specDir, 1, "pass")
-	mkCheckpointDir(t, specDir, 2, "run-001", "pass")
-	mkWaveSummary(t, specDir, 2, "pass")
 
-	result := ResolveNextWave(doc, specDir)
+	// All regular tasks (1-4) are done; waves 1 and 2 report passed
+	result := ResolveNextWave(doc, waveStates(map[int]string{1: "passed", 2: "passed"}))
 
 	if result.Action != "execute" {
 		t.Errorf("action = %q, want execute", result.Action)
@@ -107,13 +59,9 @@ func TestResolveNextWave_A2_DeferredReady(t *testing.T) {
 // A3: Task 5 deferred, deps NOT resolved -> action: "execute" (wave 2 tasks), no DeferredReady
 func TestResolveNextWave_A3_DeferredBlocked(t *testing.T) {
 	doc := Parse(readFixture(t, "deferred-blocked.md"))
-	specDir := t.TempDir()
 
-	// Wave 1 checkpoint passes, wave 2 not yet started
-	mkCheckpointDir(t, specDir, 1, "run-001", "pass")
-	mkWaveSummary(t, specDir, 1, "pass")
-
-	result := ResolveNextWave(doc, specDir)
+	// Wave 1 reports passed, wave 2 not yet started
+	result := ResolveNextWave(doc, waveStates(map[int]string{1: "passed"}))
 
 	if result.Action != "execute" {
 		t.Errorf("action = %q, want execute", result.Action)
@@ -131,9 +79,8 @@ func TestResolveNextWave_A3_DeferredBlocked(t *testing.T) {
 // A4: Task 3 in-progress [-] -> action: "continue-wave"
 func TestResolveNextWave_A4_InProgress(t *testing.T) {
 	doc := Parse(readFixture(t, "in-progress.md"))
-	specDir := t.TempDir()
 
-	result := ResolveNextWave(doc, specDir)
+	result := ResolveNextWave(doc, nil)
 
 	if result.Action != "continue-wave" {
 		t.Errorf("action = %q, want continue-wave", result.Action)
@@ -146,14 +93,8 @@ func TestResolveNextWave_A4_InProgress(t *testing.T) {
 // A5: task_ids mismatch validation -> warnings present, NextWave still works
 func TestResolveNextWave_A5_MismatchWarnings(t *testing.T) {
 	doc := Parse(readFixture(t, "deferred-ready.md"))
-	specDir := t.TempDir()
-
-	mkCheckpointDir(t, specDir, 1, "run-001", "pass")
-	mkWaveSummary(t, specDir, 1, "pass")
-	mkCheckpointDir(t, specDir, 2, "run-001", "pass")
-	mkWaveSummary(t, specDir, 2, "pass")
 
-	result := ResolveNextWave(doc, specDir)
+	result := ResolveNextWave(doc, waveStates(map[int]string{1: "passed", 2: "passed"}))
 
 	// Should still produce a valid action despite mismatch
 	if result.Action != "execute" {
@@ -173,17 +114,13 @@ func TestResolveNextWave_A5_MismatchWarnings(t *testing.T) {
 	}
 }
 
-// A6: Previous wave checkpoint "blocked" -> action: "blocked"
+// A6: Previous wave state "blocked" -> action: "blocked"
 func TestResolveNextWave_A6_CheckpointBlocked(t *testing.T) {
 	// Use basic.md: tasks 1,2 done (wave 1), tasks 3,4 pending (wave 2)
 	doc := Parse(readFixture(t, "basic.md"))
-	specDir := t.TempDir()
 
-	// Wave 1 checkpoint is blocked
-	mkCheckpointDir(t, specDir, 1, "run-001", "blocked")
-	mkWaveSummary(t, specDir, 1, "blocked")
-
-	result := ResolveNextWave(doc, specDir)
+	// Wave 1 reports blocked
+	result := ResolveNextWave(doc, waveStates(map[int]string{1: "blocked"}))
 
 	if result.Action != "blocked" {
 		t.Errorf("action = %q, want blocked", result.Action)
@@ -193,16 +130,12 @@ func TestResolveNextWave_A6_CheckpointBlocked(t *testing.T) {
 	}
 }
 
-// A7: Previous wave checkpoint "pass" -> action: "execute" (next wave tasks)
+// A7: Previous wave state "passed" -> action: "execute" (next wave tasks)
 func TestResolveNextWave_A7_CheckpointPass(t *testing.T) {
 	doc := Parse(readFixture(t, "basic.md"))
-	specDir := t.TempDir()
-
-	// Wave 1 checkpoint passes
-	mkCheckpointDir(t, specDir, 1, "run-001", "pass")
-	mkWaveSummary(t, specDir, 1, "pass")
 
-	result := ResolveNextWave(doc, specDir)
+	// Wave 1 reports passed
+	result := ResolveNextWave(doc, waveStates(map[int]string{1: "passed"}))
 
 	if result.Action != "execute" {
 		t.Errorf("action = %q, want execute", result.Action)
@@ -220,41 +153,10 @@ func TestResolveNextWave_A7_CheckpointPass(t *testing.T) {
 	}
 }
 
-// A7 variant: Stale summary detection — _latest.json overrides _wave-summary.json
-func TestResolveNextWave_A7_StaleSummary(t *testing.T) {
-	doc := Parse(readFixture(t, "basic.md"))
-	specDir := t.TempDir()
-
-	// Wave 1: _wave-summary says "blocked" (stale!)
-	// but _latest.json points to run-003 which says "pass"
-	mkWaveSummary(t, specDir, 1, "blocked")
-	mkLatestJSON(t, specDir, 1, "run-003")
-	mkCheckpointDir(t, specDir, 1, "run-003", "pass")
-
-	result := ResolveNextWave(doc, specDir)
-
-	// Should proceed because latest checkpoint says "pass"
-	if result.Action != "execute" {
-		t.Errorf("action = %q, want execute (latest run overrides stale summary)", result.Action)
-	}
-
-	// Should have stale_summary warning
-	hasStale := false
-	for _, w := range result.Warnings {
-		if containsStr(w, "stale_summary") {
-			hasStale = true
-			break
-		}
-	}
-	if !hasStale {
-		t.Errorf("warnings = %v, expected stale_summary warning", result.Warnings)
-	}
-}
-
 // A8: No tasks (empty doc) -> action: "done"
 func TestResolveNextWave_A8_EmptyDoc(t *testing.T) {
 	doc := Parse("")
-	result := ResolveNextWave(doc, "")
+	result := ResolveNextWave(doc, nil)
 
 	if result.Action != "done" {
 		t.Errorf("action = %q, want done", result.Action)
@@ -264,13 +166,9 @@ func TestResolveNextWave_A8_EmptyDoc(t *testing.T) {
 // A9: All done, rolling-wave, no deferred -> action: "plan-next-wave"
 func TestResolveNextWave_A9_RollingWavePlanNext(t *testing.T) {
 	doc := Parse(readFixture(t, "all-done-rolling.md"))
-	specDir := t.TempDir()
-
-	// Wave 1 checkpoint passes
-	mkCheckpointDir(t, specDir, 1, "run-001", "pass")
-	mkWaveSummary(t, specDir, 1, "pass")
 
-	result := ResolveNextWave(doc, specDir)
+	// Wave 1 reports passed
+	result := ResolveNextWave(doc, waveStates(map[int]string{1: "passed"}))
 
 	if result.Action != "plan-next-wave" {
 		t.Errorf("action = %q, want plan-next-wave", result.Action)
@@ -280,13 +178,9 @@ func TestResolveNextWave_A9_RollingWavePlanNext(t *testing.T) {
 // A10: Multiple tasks in wave, mixed deps -> only tasks with resolved deps included
 func TestResolveNextWave_A10_MixedDeps(t *testing.T) {
 	doc := Parse(readFixture(t, "mixed-deps.md"))
-	specDir := t.TempDir()
-
-	// Wave 1 checkpoint passes (tasks 1, 2 done)
-	mkCheckpointDir(t, specDir, 1, "run-001", "pass")
-	mkWaveSummary(t, specDir, 1, "pass")
 
-	result := ResolveNextWave(doc, specDir)
+	// Wave 1 reports passed (tasks 1, 2 done)
+	result := ResolveNextWave(doc, waveStates(map[int]string{1: "passed"}))
 
 	if result.Action != "execute" {
 		t.Errorf("action = %q, want execute", result.Action)
@@ -310,3 +204,52 @@ func TestResolveNextWave_A10_MixedDeps(t *testing.T) {
 		t.Errorf("TaskIDs = %v, should NOT include 5 (dep on pending task 3)", result.TaskIDs)
 	}
 }
+
+// A11: A blocked task holds its wave, even when a later wave has ready tasks
+func TestResolveNextWave_A11_BlockedHoldsWave(t *testing.T) {
+	doc := Parse(`## Tasks
+
+- [x] 1 Done
+  Wave: 1
+
+- [!] 2 Stuck
+  Reason: vendor API down
+  Wave: 1
+
+- [ ] 3 Independent
+  Wave: 2
+`)
+	result := ResolveNextWave(doc, nil)
+
+	if result.Action != "blocked" {
+		t.Fatalf("action = %q, want blocked", result.Action)
+	}
+	if result.Wave != 1 || result.Reason != "task 2 is blocked: vendor API down" {
+		t.Errorf("wave = %d, reason = %q", result.Wave, result.Reason)
+	}
+}
+
+// A12: A skipped task settles its wave and the dependencies on it
+func TestResolveNextWave_A12_SkippedSettles(t *testing.T) {
+	doc := Parse(`## Tasks
+
+- [x] 1 Done
+  Wave: 1
+
+- [~] 2 Not needed
+  Reason: covered by task 1
+  Wave: 1
+
+- [ ] 3 Next
+  Wave: 2
+  Depends On: Task 2
+`)
+	result := ResolveNextWave(doc, nil)
+
+	if result.Action != "execute" || result.Wave != 2 {
+		t.Fatalf("action = %q wave = %d, want execute wave 2", result.Action, result.Wave)
+	}
+	if len(result.TaskIDs) != 1 || result.TaskIDs[0] != "3" {
+		t.Errorf("TaskIDs = %v, want [3]", result.TaskIDs)
+	}
+}
@@ -8,15 +8,28 @@ import (
 	"strings"
 )
 
+// taskIDPattern matches a task ID: N, N.M or N.M.K.
+const taskIDPattern = `\d+(?:\.\d+){0,2}`
+
 var (
 	// Task line: - [ ] 1 Title here  OR  - [x] 2.1 Title here  OR  - [-] 3 Title
-	taskLineRe = regexp.MustCompile(`^- \[([ x\-])\] (\d+(?:\.\d+)?)\s+(.*)$`)
+	// OR  - [!] 4 Blocked  OR  - [~] 5 Skipped
+	taskLineRe = regexp.MustCompile(`^- \[([ x\-!~])\] (` + taskIDPattern + `)\s+(.*)$`)
+
+	// Metadata lines (indented under a task, optionally as "- Key: value"
+	// list items as in the tasks template)
+	waveMeta    = regexp.MustCompile(`(?i)^\s+(?:-\s+)?Wave:\s*(\d+)`)
+	dependsMeta = regexp.MustCompile(`(?i)^\s+(?:-\s+)?Depends\s+On:\s*(.+)`)
+	filesMeta   = regexp.MustCompile(`(?i)^\s+(?:-\s+)?Files:\s*(.+)`)
+	tddMeta     = regexp.MustCompile(`(?i)^\s+(?:-\s+)?TDD:\s*(.+)`)
+	// Reason captures its indent: the No-Test Justification list in the
+	// tasks template nests a "Reason:" item of its own.
+	reasonMeta = regexp.MustCompile(`(?i)^(\s+)(?:-\s+)?Reason:\s*(.+)`)
+	// Requirements: REQ-001, REQ-002.1  OR  _Requirements: REQ-001_ (template)
+	requirementsMeta = regexp.MustCompile(`(?i)^\s+(?:-\s+)?_?Requirements:\s*(.+)`)
 
-	// Metadata lines (indented under a task)
-	waveMeta    = regexp.MustCompile(`(?i)^\s+Wave:\s*(\d+)`)
-	dependsMeta = regexp.MustCompile(`(?i)^\s+Depends\s+On:\s*(.+)`)
-	filesMeta   = regexp.MustCompile(`(?i)^\s+Files:\s*(.+)`)
-	tddMeta     = regexp.MustCompile(`(?i)^\s+TDD:\s*(.+)`)
+	// Any other "Key: value" metadata line, kept in Task.Meta
+	anyMeta = regexp.MustCompile(`^(\s+)(?:-\s+)?([A-Za-z_][\w -]{0,39}?):\s+(\S.*)$`)
 
 	// Wave plan line: - Wave 1: Tasks 1, 2, 3
 	wavePlanRe = regexp.MustCompile(`(?i)^-\s+Wave\s+(\d+):\s*Tasks?\s+(.+)`)
@@ -28,7 +41,10 @@ var (
 	fmStrategy   = regexp.MustCompile(`(?i)^generation_strategy:\s*(.+)`)
 
 	// Task ID references: "Task 4", "4", etc.
-	taskIDRefRe = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	taskIDRefRe = regexp.MustCompile(taskIDPattern)
+
+	// Requirement references: "REQ-001", "REQ-001.2", "AC-3"
+	requirementRefRe = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*-\d+(?:\.\d+)*`)
 )
 
 // ParseFile reads and parses a tasks.md file.
@@ -60,6 +76,7 @@ func Parse(content string) Document {
 		inDeferred    bool
 		constraintBuf strings.Builder
 		currentTask   *Task
+		metaIndent    string
 	)
 
 	// Build a set from frontmatter task_ids for quick lookup
@@ -99,7 +116,11 @@ func Parse(content string) Document {
 			continue
 		}
 
-		// Wave plan section
+		// Wave plan section. A task line ends it: the template separates
+		// the plan from the tasks with a rule instead of a header.
+		if inWavePlan && taskLineRe.MatchString(line) {
+			inWavePlan = false
+		}
 		if inWavePlan {
 			if m := wavePlanRe.FindStringSubmatch(trimmed); m != nil {
 				waveNum, _ := strconv.Atoi(m[1])
@@ -124,11 +145,15 @@ func Parse(content string) Document {
 				doc.HasDeferred = true
 			}
 			currentTask = &task
+			metaIndent = ""
 			continue
 		}
 
 		// Metadata lines (indented, belonging to current task)
 		if currentTask != nil && (strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t")) {
+			if metaIndent == "" && anyMeta.MatchString(line) {
+				metaIndent = anyMeta.FindStringSubmatch(line)[1]
+			}
 			if m := waveMeta.FindStringSubmatch(line); m != nil {
 				currentTask.Wave, _ = strconv.Atoi(m[1])
 			} else if m := dependsMeta.FindStringSubmatch(line); m != nil {
@@ -137,6 +162,30 @@ func Parse(content string) Document {
 				currentTask.Files = strings.TrimSpace(m[1])
 			} else if m := tddMeta.FindStringSubmatch(line); m != nil {
 				currentTask.TDD = strings.TrimSpace(m[1])
+			} else if m := reasonMeta.FindStringSubmatch(line); m != nil {
+				if m[1] == metaIndent {
+					currentTask.Reason = strings.TrimSpace(m[2])
+				}
+			} else if m := requirementsMeta.FindStringSubmatch(line); m != nil {
+				currentTask.Requirements = parseRequirementRefs(m[1])
+				// The raw references are kept in Meta too, which spec.db
+				// stores, without the italics of the template's
+				// "_Requirements: ..._".
+				if a := anyMeta.FindStringSubmatch(line); a != nil && a[1] == metaIndent {
+					if currentTask.Meta == nil {
+						currentTask.Meta = make(map[string]string)
+					}
+					currentTask.Meta["Requirements"] = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(m[1]), "_"))
+				}
+			} else if m := anyMeta.FindStringSubmatch(line); m != nil {
+				// Only lines at the indent of the task's first metadata
+				// line count, so nested lists under a key are left alone.
+				if m[1] == metaIndent {
+					if currentTask.Meta == nil {
+						currentTask.Meta = make(map[string]string)
+					}
+					currentTask.Meta[strings.TrimSpace(m[2])] = strings.TrimSpace(m[3])
+				}
 			}
 		}
 	}
@@ -222,12 +271,26 @@ func parseIDList(s string) []string {
 	return ids
 }
 
+// parseRequirementRefs extracts requirement and acceptance criterion IDs,
+// upper-cased, in order of appearance.
+func parseRequirementRefs(s string) []string {
+	var ids []string
+	for _, m := range requirementRefRe.FindAllString(s, -1) {
+		ids = append(ids, strings.ToUpper(m))
+	}
+	return ids
+}
+
 func charToStatus(c string) string {
 	switch c {
 	case "x":
 		return "done"
 	case "-":
 		return "in_progress"
+	case "!":
+		return "blocked"
+	case "~":
+		return "skipped"
 	default:
 		return "pending"
 	}
@@ -259,6 +322,10 @@ func (d *Document) Count() CountResult {
 			r.Done++
 		case "in_progress":
 			r.InProgress++
+		case "blocked":
+			r.Blocked++
+		case "skipped":
+			r.Skipped++
 		default:
 			r.Pending++
 		}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -211,6 +212,132 @@ func TestParseMultipleDeps(t *testing.T) {
 	}
 }
 
+func TestParseMeta(t *testing.T) {
+	content := `## Tasks
+
+- [ ] 1 Plain style
+  Wave: 1
+  Owner: alice
+  Estimate: 2h
+  Files: a.go
+
+- [ ] 2 Template style
+  - Wave: 1
+  - Can Run In Parallel With: 1
+  - Implementation:
+    - Step: not metadata
+  - _Requirements: REQ-001, req-002.1_
+`
+	doc := Parse(content)
+
+	t1 := doc.TaskByID("1")
+	if len(t1.Meta) != 2 || t1.Meta["Owner"] != "alice" || t1.Meta["Estimate"] != "2h" {
+		t.Errorf("task 1 meta = %v", t1.Meta)
+	}
+	if t1.Files != "a.go" {
+		t.Errorf("task 1 files = %q", t1.Files)
+	}
+
+	t2 := doc.TaskByID("2")
+	want := map[string]string{"Can Run In Parallel With": "1", "Requirements": "REQ-001, req-002.1"}
+	if len(t2.Meta) != len(want) {
+		t.Fatalf("task 2 meta = %v, want %v", t2.Meta, want)
+	}
+	for k, v := range want {
+		if t2.Meta[k] != v {
+			t.Errorf("task 2 meta[%q] = %q, want %q", k, t2.Meta[k], v)
+		}
+	}
+	if got := strings.Join(t2.Requirements, ","); got != "REQ-001,REQ-002.1" {
+		t.Errorf("task 2 requirements = %q", got)
+	}
+}
+
+func TestFieldAndFilter(t *testing.T) {
+	doc := Parse(`## Tasks
+
+- [ ] 1 One
+  Wave: 1
+  Owner: Alice
+  Depends On: none
+
+- [x] 2 Two
+  Wave: 2
+  Owner: bob
+  Depends On: Task 1
+`)
+	t2 := doc.TaskByID("2")
+	for name, want := range map[string]string{"owner": "bob", "Depends On": "1", "depends_on": "1", "wave": "2", "status": "done"} {
+		if got, ok := t2.Field(name); !ok || got != want {
+			t.Errorf("Field(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+	if _, ok := t2.Field("estimate"); ok {
+		t.Error("Field(estimate) should be missing")
+	}
+
+	conds, err := ParseConditions([]string{"owner=alice", "wave=1"})
+	if err != nil {
+		t.Fatalf("ParseConditions: %v", err)
+	}
+	if got := Filter(doc, conds); len(got) != 1 || got[0].ID != "1" {
+		t.Errorf("Filter = %v, want task 1", got)
+	}
+	conds, _ = ParseConditions([]string{"owner=alice", "status=done"})
+	if got := Filter(doc, conds); len(got) != 0 {
+		t.Errorf("Filter = %v, want none", got)
+	}
+	if _, err := ParseConditions([]string{"owner"}); err == nil {
+		t.Error("expected error for filter without =")
+	}
+}
+
+func TestFilterListFields(t *testing.T) {
+	doc := Parse(`## Tasks
+
+- [ ] 1 One
+  Wave: 1
+  Files: ` + "`a.go`, `b.go`" + `
+  Requirements: REQ-001, REQ-002.1
+
+- [ ] 2 Two
+  Wave: 1
+  Depends On: Task 1
+
+- [ ] 3 Three
+  Wave: 2
+  Depends On: Task 1, Task 2
+  Requirements: REQ-002
+`)
+	tests := []struct {
+		where string
+		want  string
+	}{
+		{"requirements=REQ-001", "1"},
+		{"requirements=req-002.1", "1"},
+		{"requirements=REQ-002", "3"},
+		{"requirements=REQ-001,REQ-002.1", "1"},
+		{"depends_on=1", "2,3"},
+		{"depends_on=2", "3"},
+		{"depends_on=1,2", "3"},
+		{"files=b.go", "1"},
+		{"files=c.go", ""},
+	}
+	for _, tt := range tests {
+		conds, err := ParseConditions([]string{tt.where})
+		if err != nil {
+			t.Fatalf("ParseConditions(%q): %v", tt.where, err)
+		}
+		var ids []string
+		for _, task := range Filter(doc, conds) {
+			ids = append(ids, task.ID)
+		}
+		if got := strings.Join(ids, ","); got != tt.want {
+			t.Errorf("--where %s = [%s], want [%s]", tt.where, got, tt.want)
+		}
+	}
+}
+
 func TestCount(t *testing.T) {
 	doc := Parse(readFixture(t, "in-progress.md"))
 	counts := doc.Count()
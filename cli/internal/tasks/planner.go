@@ -0,0 +1,218 @@
+package tasks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WaveAssignment is a planned wave for one task. From is the task's
+// current Wave (0 when unset).
+type WaveAssignment struct {
+	TaskID string `json:"task_id"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+}
+
+// WavePlanResult is the layout computed by PlanWaves.
+type WavePlanResult struct {
+	MaxWaveSize  int              `json:"max_wave_size"`
+	Waves        []WavePlanEntry  `json:"waves"`
+	Changes      []WaveAssignment `json:"changes"`
+	CriticalPath []string         `json:"critical_path"`
+	// Pinned lists tasks that keep their wave because they are already
+	// in progress or done.
+	Pinned []string `json:"pinned,omitempty"`
+}
+
+// Assignments returns the planned wave of every planned task.
+func (r WavePlanResult) Assignments() map[string]int {
+	m := make(map[string]int)
+	for _, w := range r.Waves {
+		for _, id := range w.TaskIDs {
+			m[id] = w.Wave
+		}
+	}
+	return m
+}
+
+// PlanWaves packs the non-deferred tasks of doc into waves. A task goes in
+// a wave after all of its dependencies, never shares a wave with a task
+// whose Files overlap its own, and no wave holds more than maxWaveSize
+// tasks (0 means unlimited). Tasks on longer dependency chains are placed
+// first so the critical path is not delayed.
+//
+// Tasks already in progress or done keep their wave; pending tasks are
+// packed into the waves after the last wave holding such a task. A
+// dependency cycle is an error; references to missing or deferred tasks
+// are ignored.
+func PlanWaves(doc Document, maxWaveSize int) (WavePlanResult, error) {
+	res := WavePlanResult{MaxWaveSize: maxWaveSize, Waves: []WavePlanEntry{}, Changes: []WaveAssignment{}, CriticalPath: []string{}}
+
+	var planned []Task
+	for _, t := range doc.Tasks {
+		if !t.IsDeferred {
+			planned = append(planned, t)
+		}
+	}
+	sub := Document{Tasks: planned}
+	g := BuildGraph(sub)
+	if cycles := g.Cycles(); len(cycles) > 0 {
+		c := cycles[0]
+		return res, fmt.Errorf("dependency cycle: %s -> %s", strings.Join(c, " -> "), c[0])
+	}
+
+	byID := make(map[string]Task, len(planned))
+	order := make(map[string]int, len(planned))
+	for i, t := range planned {
+		byID[t.ID] = t
+		order[t.ID] = i
+	}
+	height := chainHeights(g)
+
+	wave := make(map[string]int, len(planned))
+	pinned := make(map[string]bool)
+	members := make(map[int][]string)
+	base := 0
+	for _, t := range planned {
+		if t.Status != "pending" {
+			w := max(t.Wave, 1)
+			pinned[t.ID] = true
+			wave[t.ID] = w
+			members[w] = append(members[w], t.ID)
+			base = max(base, w)
+			res.Pinned = append(res.Pinned, t.ID)
+		}
+	}
+
+	// Kahn's algorithm over the pending tasks, taking the ready task with
+	// the longest remaining chain first, then body order.
+	indegree := make(map[string]int)
+	var ready []string
+	for _, t := range planned {
+		if pinned[t.ID] {
+			continue
+		}
+		for _, dep := range g.Deps[t.ID] {
+			if !pinned[dep] {
+				indegree[t.ID]++
+			}
+		}
+		if indegree[t.ID] == 0 {
+			ready = append(ready, t.ID)
+		}
+	}
+	for len(ready) > 0 {
+		sort.SliceStable(ready, func(i, j int) bool {
+			if height[ready[i]] != height[ready[j]] {
+				return height[ready[i]] > height[ready[j]]
+			}
+			return order[ready[i]] < order[ready[j]]
+		})
+		id := ready[0]
+		ready = ready[1:]
+
+		w := base + 1
+		for _, dep := range g.Deps[id] {
+			w = max(w, wave[dep]+1)
+		}
+		files := byID[id].FileList()
+		for !fits(members[w], files, byID, maxWaveSize) {
+			w++
+		}
+		wave[id] = w
+		members[w] = append(members[w], id)
+
+		for _, next := range g.Dependents[id] {
+			if pinned[next] {
+				continue
+			}
+			if indegree[next]--; indegree[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	var waves []int
+	for w := range members {
+		waves = append(waves, w)
+	}
+	sort.Ints(waves)
+	for _, w := range waves {
+		ids := members[w]
+		sort.SliceStable(ids, func(i, j int) bool { return order[ids[i]] < order[ids[j]] })
+		res.Waves = append(res.Waves, WavePlanEntry{Wave: w, TaskIDs: ids})
+	}
+	for _, t := range planned {
+		if wave[t.ID] != t.Wave {
+			res.Changes = append(res.Changes, WaveAssignment{TaskID: t.ID, From: t.Wave, To: wave[t.ID]})
+		}
+	}
+	res.CriticalPath = criticalPath(g, height, order)
+	return res, nil
+}
+
+// fits reports whether a task with files can join a wave holding ids.
+func fits(ids []string, files []string, byID map[string]Task, maxWaveSize int) bool {
+	if maxWaveSize > 0 && len(ids) >= maxWaveSize {
+		return false
+	}
+	for _, id := range ids {
+		if filesOverlap(files, byID[id].FileList()) {
+			return false
+		}
+	}
+	return true
+}
+
+// chainHeights returns, for each task, the number of tasks on the longest
+// dependency chain starting at it and running through its dependents.
+// g must be acyclic.
+func chainHeights(g *Graph) map[string]int {
+	height := make(map[string]int, len(g.IDs))
+	var visit func(id string) int
+	visit = func(id string) int {
+		if h, ok := height[id]; ok {
+			return h
+		}
+		h := 1
+		for _, next := range g.Dependents[id] {
+			h = max(h, visit(next)+1)
+		}
+		height[id] = h
+		return h
+	}
+	for _, id := range g.IDs {
+		visit(id)
+	}
+	return height
+}
+
+// criticalPath follows the longest dependency chain, preferring tasks that
+// come first in the body on ties.
+func criticalPath(g *Graph, height map[string]int, order map[string]int) []string {
+	better := func(a, b string) bool {
+		if height[a] != height[b] {
+			return height[a] > height[b]
+		}
+		return order[a] < order[b]
+	}
+	path := []string{}
+	cur := ""
+	for _, id := range g.IDs {
+		if len(g.Deps[id]) == 0 && (cur == "" || better(id, cur)) {
+			cur = id
+		}
+	}
+	for cur != "" {
+		path = append(path, cur)
+		next := ""
+		for _, d := range g.Dependents[cur] {
+			if height[d] == height[cur]-1 && (next == "" || order[d] < order[next]) {
+				next = d
+			}
+		}
+		cur = next
+	}
+	return path
+}
@@ -0,0 +1,86 @@
+package tasks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func planTask(id, status string, wave int, files string, deps ...string) Task {
+	return Task{ID: id, Title: "Task " + id, Status: status, Wave: wave, Files: files, DependsOn: deps}
+}
+
+func TestPlanWaves(t *testing.T) {
+	doc := Document{Tasks: []Task{
+		planTask("1", "pending", 1, "`a.go`"),
+		planTask("2", "pending", 1, "`b.go`", "1"),
+		planTask("3", "pending", 1, "`c.go`", "2"),
+		planTask("4", "pending", 1, "`lib/`"),
+		planTask("5", "pending", 1, "`lib/x.go`"),
+		planTask("6", "pending", 1, "`d.go`"),
+	}}
+	res, err := PlanWaves(doc, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WavePlanEntry{
+		{Wave: 1, TaskIDs: []string{"1", "4"}},
+		{Wave: 2, TaskIDs: []string{"2", "5"}},
+		{Wave: 3, TaskIDs: []string{"3", "6"}},
+	}
+	if !reflect.DeepEqual(res.Waves, want) {
+		t.Errorf("waves = %+v, want %+v", res.Waves, want)
+	}
+	if got := strings.Join(res.CriticalPath, ","); got != "1,2,3" {
+		t.Errorf("critical path = %s", got)
+	}
+	if len(res.Changes) != 4 {
+		t.Errorf("changes = %+v", res.Changes)
+	}
+}
+
+func TestPlanWavesPinsStartedTasks(t *testing.T) {
+	doc := Document{Tasks: []Task{
+		planTask("1", "done", 1, ""),
+		planTask("2", "in_progress", 2, ""),
+		planTask("3", "pending", 1, ""),
+		planTask("4", "pending", 5, "", "3"),
+		{ID: "5", Status: "pending", IsDeferred: true, DependsOn: []string{"4"}},
+	}}
+	res, err := PlanWaves(doc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Assignments(); !reflect.DeepEqual(got, map[string]int{"1": 1, "2": 2, "3": 3, "4": 4}) {
+		t.Errorf("assignments = %v", got)
+	}
+	if got := strings.Join(res.Pinned, ","); got != "1,2" {
+		t.Errorf("pinned = %s", got)
+	}
+}
+
+func TestPlanWavesRejectsCycles(t *testing.T) {
+	doc := Document{Tasks: []Task{
+		planTask("1", "pending", 1, "", "2"),
+		planTask("2", "pending", 1, "", "1"),
+	}}
+	if _, err := PlanWaves(doc, 3); err == nil || !strings.Contains(err.Error(), "1 -> 2 -> 1") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestEditorSetWaves(t *testing.T) {
+	e := NewEditor(editorDoc)
+	if err := e.SetWaves(map[string]int{"2": 2, "3": 3}); err != nil {
+		t.Fatal(err)
+	}
+	got := e.Content()
+	for _, want := range []string{"- Wave 1: Tasks 1\n", "- Wave 2: Tasks 2\n", "- Wave 3: Tasks 3\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if ids := taskIDs(e.Document()); strings.Join(ids, ",") != "1,2,3" {
+		t.Errorf("task order changed: %v", ids)
+	}
+}
@@ -0,0 +1,23 @@
+package tasks
+
+import (
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+// Record converts a parsed task into the row shape stored in spec.db.
+func (t Task) Record() store.TaskRecord {
+	wave := t.Wave
+	return store.TaskRecord{
+		TaskID:     t.ID,
+		Title:      t.Title,
+		Status:     t.Status,
+		Wave:       &wave,
+		DependsOn:  strings.Join(t.DependsOn, ","),
+		Files:      t.Files,
+		TDD:        t.TDD != "",
+		IsDeferred: t.IsDeferred,
+		Meta:       t.Meta,
+	}
+}
@@ -24,12 +24,15 @@ type Frontmatter struct {
 type Task struct {
 	ID         string   `json:"id"`
 	Title      string   `json:"title"`
-	Status     string   `json:"status"` // "pending", "in_progress", "done"
+	Status     string   `json:"status"` // "pending", "in_progress", "done", "blocked", "skipped"
 	Wave       int      `json:"wave"`
 	DependsOn  []string `json:"depends_on,omitempty"`
 	Files      string   `json:"files,omitempty"`
 	TDD        string   `json:"tdd,omitempty"`
 	IsDeferred bool     `json:"is_deferred"`
+	Reason     string   `json:"reason,omitempty"` // why a task is blocked or skipped
+	Requirements []string `json:"requirements,omitempty"` // requirement and acceptance criterion IDs
+	Meta       map[string]string `json:"meta,omitempty"` // any other "Key: value" metadata lines
 	RawLine    int      `json:"raw_line"` // line number in original file (1-based)
 }
 
@@ -55,6 +58,8 @@ type CountResult struct {
 	Pending    int `json:"pending"`
 	InProgress int `json:"in_progress"`
 	Done       int `json:"done"`
+	Blocked    int `json:"blocked"`
+	Skipped    int `json:"skipped"`
 	Deferred   int `json:"deferred"`
 }
 
@@ -83,4 +88,5 @@ type ComplexityResult struct {
 	Score      int    `json:"score"`
 	ModelHint  string `json:"model_hint"` // "haiku", "sonnet", "opus"
 	Factors    []string `json:"factors,omitempty"`
+	Adjustment int      `json:"adjustment,omitempty"` // points added (or removed) by calibration
 }
@@ -1,22 +1,25 @@
 package tasks
 
 import (
+	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 var (
 	// checkboxLineRe matches any line with a checkbox marker
-	checkboxLineRe = regexp.MustCompile(`^- \[([ x\-])\] `)
+	checkboxLineRe = regexp.MustCompile(`^- \[([ x\-!~])\] `)
 
 	// taskCheckboxRe matches a valid task line: checkbox + numeric ID
-	taskCheckboxRe = regexp.MustCompile(`^- \[([ x\-])\] \d+(?:\.\d+)?\s`)
+	taskCheckboxRe = regexp.MustCompile(`^- \[([ x\-!~])\] ` + taskIDPattern + `\s`)
 
 	// starListRe matches lines using * as list marker (forbidden)
 	starListRe = regexp.MustCompile(`^\*\s`)
 
 	// nestedCheckboxRe matches indented checkbox lines (nested checkboxes in metadata)
-	nestedCheckboxRe = regexp.MustCompile(`^\s+- \[([ x\-])\] `)
+	nestedCheckboxRe = regexp.MustCompile(`^\s+- \[([ x\-!~])\] `)
 
 	// multiLineFilesRe detects if a Files entry spans multiple lines
 	// (we check if a line after "Files:" is indented and looks like a continuation)
@@ -28,6 +31,8 @@ var (
 //   - "-" as list marker (never "*")
 //   - No nested checkboxes in metadata
 //   - "Files" in single line
+//
+// It then checks the task graph (see validateGraph).
 func Validate(content string) ValidateResult {
 	lines := strings.Split(content, "\n")
 	var errors []string
@@ -61,12 +66,173 @@ func Validate(content string) ValidateResult {
 		}
 	}
 
+	errors = append(errors, validateGraph(lines, Parse(content))...)
+
 	return ValidateResult{
 		Valid:  len(errors) == 0,
 		Errors: errors,
 	}
 }
 
+// validateGraph checks the dependency graph and the wave bookkeeping of a
+// parsed document:
+//   - Depends On references to task IDs missing from the body
+//   - dependency cycles
+//   - tasks depending on a task in a later wave
+//   - frontmatter task_ids vs. body tasks (deferred-section tasks excepted)
+//   - Wave Plan entries vs. per-task Wave metadata
+//
+// Findings are sorted by line.
+func validateGraph(lines []string, doc Document) []string {
+	type finding struct {
+		line int
+		msg  string
+	}
+	var findings []finding
+	add := func(line int, format string, args ...any) {
+		findings = append(findings, finding{line, fmt.Sprintf(format, args...)})
+	}
+
+	byID := make(map[string]Task, len(doc.Tasks))
+	for _, t := range doc.Tasks {
+		byID[t.ID] = t
+	}
+
+	g := BuildGraph(doc)
+	for _, t := range doc.Tasks {
+		depLine := metaLineNum(lines, t, dependsMeta)
+		for _, dep := range g.Missing[t.ID] {
+			add(depLine, "task %s depends on %s, which does not exist", t.ID, dep)
+		}
+		for _, dep := range g.Deps[t.ID] {
+			if d := byID[dep]; t.Wave > 0 && d.Wave > t.Wave {
+				add(depLine, "task %s (wave %d) depends on task %s in later wave %d", t.ID, t.Wave, dep, d.Wave)
+			}
+		}
+	}
+	for _, cycle := range g.Cycles() {
+		first := byID[cycle[0]]
+		add(metaLineNum(lines, first, dependsMeta), "dependency cycle: %s -> %s",
+			strings.Join(cycle, " -> "), cycle[0])
+	}
+
+	if len(doc.Frontmatter.TaskIDs) > 0 {
+		fmLine := frontmatterTaskIDsLine(lines)
+		inFM := make(map[string]bool, len(doc.Frontmatter.TaskIDs))
+		for _, id := range doc.Frontmatter.TaskIDs {
+			inFM[id] = true
+			if _, ok := byID[id]; !ok {
+				add(fmLine, "frontmatter task_ids lists %s, which is not in the body", id)
+			}
+		}
+		for _, t := range doc.Tasks {
+			if !inFM[t.ID] && !inDeferredSection(lines, t.RawLine) {
+				add(t.RawLine, "task %s is missing from frontmatter task_ids", t.ID)
+			}
+		}
+	}
+
+	planned := make(map[string]bool)
+	for _, e := range wavePlanLines(lines) {
+		for _, id := range e.TaskIDs {
+			planned[id] = true
+			t, ok := byID[id]
+			switch {
+			case !ok:
+				add(e.line, "Wave Plan lists task %s in wave %d, but it is not in the body", id, e.Wave)
+			case t.Wave != e.Wave:
+				add(e.line, "Wave Plan lists task %s in wave %d, but its Wave is %d", id, e.Wave, t.Wave)
+			}
+		}
+	}
+	if len(planned) > 0 {
+		for _, t := range doc.Tasks {
+			if t.Wave > 0 && !planned[t.ID] && !t.IsDeferred {
+				add(metaLineNum(lines, t, waveMeta), "task %s (wave %d) is missing from the Wave Plan", t.ID, t.Wave)
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].line < findings[j].line })
+	out := make([]string, 0, len(findings))
+	for _, f := range findings {
+		out = append(out, formatError(f.line, f.msg))
+	}
+	return out
+}
+
+// metaLineNum returns the 1-based line of the metadata line of t matching
+// re, or the task line when t has none.
+func metaLineNum(lines []string, t Task, re *regexp.Regexp) int {
+	for i := t.RawLine; i < len(lines); i++ {
+		line := lines[i]
+		if taskLineRe.MatchString(line) || strings.HasPrefix(strings.TrimSpace(line), "## ") {
+			break
+		}
+		if re.MatchString(line) {
+			return i + 1
+		}
+	}
+	return t.RawLine
+}
+
+// frontmatterTaskIDsLine returns the 1-based line of the frontmatter
+// task_ids key, or 1 when it cannot be found.
+func frontmatterTaskIDsLine(lines []string) int {
+	start, end := findFrontmatter(lines)
+	for i := start + 1; start >= 0 && i < end; i++ {
+		if fmTaskIDs.MatchString(strings.TrimSpace(lines[i])) {
+			return i + 1
+		}
+	}
+	return 1
+}
+
+// inDeferredSection reports whether the 1-based line falls under a
+// "## ...Deferred..." header.
+func inDeferredSection(lines []string, line int) bool {
+	for i := line - 2; i >= 0; i-- {
+		if trimmed := strings.TrimSpace(lines[i]); strings.HasPrefix(trimmed, "## ") {
+			return strings.Contains(strings.ToLower(trimmed), "deferred")
+		}
+	}
+	return false
+}
+
+// wavePlanLine is a Wave Plan entry with its 1-based line number.
+type wavePlanLine struct {
+	WavePlanEntry
+	line int
+}
+
+// wavePlanLines scans the Wave Plan section the way Parse does, keeping
+// line numbers.
+func wavePlanLines(lines []string) []wavePlanLine {
+	var out []wavePlanLine
+	inPlan := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "## ") {
+			inPlan = strings.Contains(strings.ToLower(trimmed), "wave plan")
+			continue
+		}
+		if taskLineRe.MatchString(line) {
+			inPlan = false
+		}
+		if !inPlan {
+			continue
+		}
+		if m := wavePlanRe.FindStringSubmatch(trimmed); m != nil {
+			wave, _ := strconv.Atoi(m[1])
+			out = append(out, wavePlanLine{
+				WavePlanEntry: WavePlanEntry{Wave: wave, TaskIDs: parseIDList(m[2])},
+				line:          i + 1,
+			})
+		}
+	}
+	return out
+}
+
 func formatError(lineNum int, msg string) string {
 	return "line " + itoa(lineNum) + ": " + msg
 }
@@ -0,0 +1,85 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateFixturesClean(t *testing.T) {
+	for _, name := range []string{"basic.md", "deferred-ready.md", "deferred-blocked.md", "mixed-deps.md", "all-done-rolling.md"} {
+		if vr := Validate(readFixture(t, name)); !vr.Valid {
+			t.Errorf("%s: unexpected errors %v", name, vr.Errors)
+		}
+	}
+}
+
+func TestValidateDependencyGraph(t *testing.T) {
+	content := `---
+spec: graph
+task_ids: [1, 2, 3, 4, 9]
+---
+
+## Wave Plan
+
+- Wave 1: Tasks 1, 2
+- Wave 2: Tasks 3
+
+## Tasks
+
+- [ ] 1 First
+  Wave: 1
+  Depends On: Task 2
+
+- [ ] 2 Second
+  Wave: 1
+  Depends On: Task 1
+
+- [ ] 3 Third
+  Wave: 1
+  Depends On: Tasks 4, 7
+
+- [ ] 4 Fourth
+  Wave: 2
+  Depends On: none
+
+- [ ] 5 Unlisted
+  Wave: 2
+  Depends On: none
+
+## Deferred
+
+- [ ] 6 Later
+  Depends On: Task 4
+`
+	vr := Validate(content)
+	want := []string{
+		"line 3: frontmatter task_ids lists 9, which is not in the body",
+		"line 9: Wave Plan lists task 3 in wave 2, but its Wave is 1",
+		"line 15: dependency cycle: 1 -> 2 -> 1",
+		"line 23: task 3 depends on 7, which does not exist",
+		"line 23: task 3 (wave 1) depends on task 4 in later wave 2",
+		"line 26: task 4 (wave 2) is missing from the Wave Plan",
+		"line 29: task 5 is missing from frontmatter task_ids",
+	}
+	if vr.Valid {
+		t.Fatal("expected invalid")
+	}
+	if !reflect.DeepEqual(vr.Errors, want) {
+		t.Errorf("errors:\n%q\nwant:\n%q", vr.Errors, want)
+	}
+}
+
+func TestGraphCycles(t *testing.T) {
+	doc := Document{Tasks: []Task{
+		{ID: "1", DependsOn: []string{"3"}},
+		{ID: "2", DependsOn: []string{"1"}},
+		{ID: "3", DependsOn: []string{"2"}},
+		{ID: "4", DependsOn: []string{"4"}},
+		{ID: "5", DependsOn: []string{"1"}},
+	}}
+	got := BuildGraph(doc).Cycles()
+	want := [][]string{{"1", "3", "2"}, {"4"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cycles = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,202 @@
+// Package textdiff produces line-based unified diffs.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change.
+const DefaultContext = 3
+
+// opKind is the kind of one line in an edit script.
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	a, b int // line indexes in a and b (the one not involved is unused)
+}
+
+// Unified returns a unified diff turning a into b, with headers naming them
+// fromName and toName. It returns "" when the texts are equal.
+func Unified(fromName, toName, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	al, bl := splitLines(a), splitLines(b)
+	ops := diffLines(al, bl)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks(ops, context) {
+		writeHunk(&sb, h, al, bl)
+	}
+	return sb.String()
+}
+
+// splitLines splits s into lines, keeping a marker for a missing final
+// newline so that it shows up in the diff.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n\\ No newline at end of file\n"
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script with Myers' algorithm.
+func diffLines(a, b []string) []op {
+	n, m := len(a), len(b)
+	maxD := n + m
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		// Step d only reads diagonals -d-1..d+1, so that window is all
+		// backtrack needs: O(D²) memory instead of O((N+M)·D).
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the saved frontiers from the end to recover the edits.
+// trace[d] holds diagonals -d-1..d+1 of the frontier before step d.
+func backtrack(trace [][]int, a, b []string) []op {
+	x, y := len(a), len(b)
+	var ops []op
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{opEqual, x, y})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, op{opInsert, x, y})
+		} else {
+			x--
+			ops = append(ops, op{opDelete, x, y})
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// hunks groups the edit script into ranges of changes with context lines
+// around them, merging changes whose context overlaps.
+func hunks(ops []op, context int) [][]op {
+	var result [][]op
+	start, end := -1, -1
+	for i, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+		lo := max(i-context, 0)
+		hi := min(i+context+1, len(ops))
+		if start >= 0 && lo <= end {
+			end = hi
+			continue
+		}
+		if start >= 0 {
+			result = append(result, ops[start:end])
+		}
+		start, end = lo, hi
+	}
+	if start >= 0 {
+		result = append(result, ops[start:end])
+	}
+	return result
+}
+
+func writeHunk(sb *strings.Builder, h []op, a, b []string) {
+	aStart, bStart := -1, -1
+	var aLen, bLen int
+	for _, o := range h {
+		if o.kind != opInsert {
+			if aStart < 0 {
+				aStart = o.a
+			}
+			aLen++
+		}
+		if o.kind != opDelete {
+			if bStart < 0 {
+				bStart = o.b
+			}
+			bLen++
+		}
+	}
+	// An empty range is reported at the line before it, as diff(1) does.
+	if aStart < 0 {
+		aStart = h[0].a - 1
+	}
+	if bStart < 0 {
+		bStart = h[0].b - 1
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+	for _, o := range h {
+		line := ""
+		switch o.kind {
+		case opInsert:
+			line = b[o.b]
+		default:
+			line = a[o.a]
+		}
+		sb.WriteByte(byte(o.kind))
+		sb.WriteString(line)
+	}
+}
+
+func hunkRange(start, length int) string {
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
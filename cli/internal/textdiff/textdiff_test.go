@@ -0,0 +1,84 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	cases := []struct {
+		name, a, b, want string
+		context          int
+	}{
+		{
+			name: "equal",
+			a:    "x\n", b: "x\n",
+			want: "",
+		},
+		{
+			name:    "change with context",
+			a:       "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			b:       "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			context: 2,
+			want:    "--- a\n+++ b\n@@ -3,5 +3,5 @@\n 3\n 4\n-5\n+five\n 6\n 7\n",
+		},
+		{
+			name:    "separate hunks",
+			a:       "a\nb\nc\nd\ne\nf\ng\n",
+			b:       "A\nb\nc\nd\ne\nf\nG\n",
+			context: 1,
+			want:    "--- a\n+++ b\n@@ -1,2 +1,2 @@\n-a\n+A\n b\n@@ -6,2 +6,2 @@\n f\n-g\n+G\n",
+		},
+		{
+			name: "from empty",
+			a:    "", b: "new\n",
+			context: 3,
+			want:    "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n",
+		},
+		{
+			name: "pure insertion",
+			a:    "1\n2\n3\n", b: "1\n2\nx\n3\n",
+			context: 0,
+			want:    "--- a\n+++ b\n@@ -2,0 +3 @@\n+x\n",
+		},
+		{
+			name: "missing final newline",
+			a:    "a\nb", b: "a\nb\n",
+			context: 3,
+			want:    "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Unified("a", "b", tc.a, tc.b, tc.context); got != tc.want {
+				t.Errorf("Unified:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesReplays(t *testing.T) {
+	// Applying the edit script to a must give b, whatever the shape of the
+	// changes.
+	pairs := [][2]string{
+		{"", "a\nb\n"},
+		{"a\nb\n", ""},
+		{"a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n"},
+		{"1\n2\n3\n4\n5\n6\n", "0\n2\n3\nx\n5\n6\n7\n"},
+	}
+	for _, p := range pairs {
+		a, b := splitLines(p[0]), splitLines(p[1])
+		var got []string
+		for _, o := range diffLines(a, b) {
+			switch o.kind {
+			case opEqual:
+				got = append(got, a[o.a])
+			case opInsert:
+				got = append(got, b[o.b])
+			}
+		}
+		if strings.Join(got, "") != strings.Join(b, "") {
+			t.Errorf("diff of %q -> %q replays to %q", p[0], p[1], strings.Join(got, ""))
+		}
+	}
+}
@@ -6,6 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/lucas-stellet/oraculo/internal/fsutil"
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
 )
 
 // iterationState is the persistent state for audit iteration tracking.
@@ -83,7 +87,7 @@ func auditIterationStartCore(cwd, runDir, auditType string, max int) (*auditIter
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal state: %w", err)
 	}
-	if err := os.WriteFile(statePath, data, 0644); err != nil {
+	if err := fsutil.WriteAtomic(statePath, data, 0644); err != nil {
 		return nil, fmt.Errorf("failed to write state file: %w", err)
 	}
 
@@ -204,9 +208,20 @@ func auditIterationAdvanceCore(cwd, runDir, auditType, result string) (*auditIte
 	}
 
 	statePath := iterationStatePath(runDir, auditType)
+	if _, err := os.Stat(statePath); err != nil {
+		return nil, fmt.Errorf("no iteration state found, run audit-iteration start first")
+	}
+
+	// Hold the lock across the read-modify-write so two advances cannot
+	// both record the same iteration.
+	lock, err := fsutil.Lock(filepath.Dir(statePath))
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
 	data, err := os.ReadFile(statePath)
 	if err != nil {
-		return nil, fmt.Errorf("no iteration state found, run audit-iteration start first")
+		return nil, fmt.Errorf("failed to read state file: %w", err)
 	}
 
 	var state iterationState
@@ -236,10 +251,25 @@ func auditIterationAdvanceCore(cwd, runDir, auditType, result string) (*auditIte
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal state: %w", err)
 	}
-	if err := os.WriteFile(statePath, newData, 0644); err != nil {
+	if err := fsutil.WriteAtomic(statePath, newData, 0644); err != nil {
 		return nil, fmt.Errorf("failed to write state file: %w", err)
 	}
 
+	if specDir := specdir.SpecDirFromPath(runDir); specDir != "" {
+		var wavePtr *int
+		if wn := specdir.WaveNumFromPath(runDir); wn > 0 {
+			wavePtr = &wn
+		}
+		runRel, _ := filepath.Rel(specDir, runDir)
+		store.TryRecordEvent(specDir, store.Event{
+			Command: "audit-iteration advance",
+			Wave:    wavePtr,
+			Args:    map[string]any{"run_dir": runRel, "type": auditType, "result": result},
+			Before:  map[string]any{"iteration": state.CurrentIteration - 1},
+			After:   map[string]any{"iteration": state.CurrentIteration, "result": result},
+		})
+	}
+
 	remaining := state.MaxIterations - state.CurrentIteration
 	if remaining < 0 {
 		remaining = 0
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/lucas-stellet/oraculo/internal/fsutil"
+	"github.com/lucas-stellet/oraculo/internal/reconcile"
 	"github.com/lucas-stellet/oraculo/internal/registry"
 	"github.com/lucas-stellet/oraculo/internal/store"
 )
@@ -82,7 +84,7 @@ func DispatchHandoff(cwd, runDir, command string, raw bool) {
 	sb.WriteString(fmt.Sprintf("\n**All pass:** %v\n", allPass))
 
 	handoffPath := filepath.Join(runDir, "_handoff.md")
-	if err := os.WriteFile(handoffPath, []byte(sb.String()), 0644); err != nil {
+	if err := fsutil.WriteAtomic(handoffPath, []byte(sb.String()), 0644); err != nil {
 		Fail("failed to write _handoff.md: "+err.Error(), raw)
 	}
 
@@ -99,6 +101,9 @@ func DispatchHandoff(cwd, runDir, command string, raw bool) {
 				cmdName = extractCommandFromRunDir(runDir)
 			}
 			s.HarvestRunDir(runDir, cmdName, waveNum)
+			// The run may have rewritten phase artifacts (design.md, reports);
+			// record their new versions while they are still on disk.
+			reconcile.HarvestArtifacts(s, specDir)
 		}
 	}
 
@@ -11,7 +11,9 @@ import (
 	"github.com/lucas-stellet/oraculo/internal/config"
 	"github.com/lucas-stellet/oraculo/internal/embedded"
 	"github.com/lucas-stellet/oraculo/internal/registry"
+	"github.com/lucas-stellet/oraculo/internal/specdir"
 	"github.com/lucas-stellet/oraculo/internal/store"
+	wavepkg "github.com/lucas-stellet/oraculo/internal/wave"
 )
 
 var runNumRe = regexp.MustCompile(`^run-(\d+)$`)
@@ -68,30 +70,16 @@ func DispatchInit(cwd, command, specName, wave string, raw bool) {
 
 	commsDir := filepath.Join(specDir, commsPath)
 
-	// Scan for next run number
-	nextRun := 1
-	entries, err := os.ReadDir(commsDir)
-	if err == nil {
-		for _, e := range entries {
-			if !e.IsDir() {
-				continue
-			}
-			m := runNumRe.FindStringSubmatch(e.Name())
-			if m == nil {
-				continue
-			}
-			n, _ := strconv.Atoi(m[1])
-			if n >= nextRun {
-				nextRun = n + 1
-			}
-		}
+	if state, ok := dispatchWaveStates[command]; ok {
+		n, _ := strconv.Atoi(wave)
+		advanceWave(specDir, n, state, "dispatch-init")
 	}
 
-	runID := fmt.Sprintf("run-%03d", nextRun)
-	runDir := filepath.Join(commsDir, runID)
-	if err := os.MkdirAll(runDir, 0755); err != nil {
+	runDir, nextRun, err := claimRunDir(commsDir)
+	if err != nil {
 		Fail("failed to create run dir: "+err.Error(), raw)
 	}
+	runID := filepath.Base(runDir)
 
 	// Dual-write: register run in spec.db
 	if s := store.TryOpen(specDir); s != nil {
@@ -103,6 +91,12 @@ func DispatchInit(cwd, command, specName, wave string, raw bool) {
 		}
 		runDirRelToSpec, _ := filepath.Rel(specDir, runDir)
 		s.CreateRun(command, nextRun, meta.Phase, waveNumPtr, runDirRelToSpec)
+		s.RecordEvent(store.Event{
+			Command: "dispatch-init",
+			Wave:    waveNumPtr,
+			Args:    map[string]any{"command": command, "wave": wave},
+			After:   map[string]any{"run_id": runID, "run_dir": runDirRelToSpec},
+		})
 	}
 
 	// Create artifact directories declared in dispatch_pattern.
@@ -148,3 +142,61 @@ func DispatchInit(cwd, command, specName, wave string, raw bool) {
 	}
 	Output(result, runDirRel, raw)
 }
+
+// dispatchWaveStates is the wave state a dispatch of each command moves its
+// wave to.
+var dispatchWaveStates = map[string]string{
+	"exec":       wavepkg.StateExecuting,
+	"checkpoint": wavepkg.StateAwaitingCheckpoint,
+}
+
+// advanceWave moves a wave forward to state. Like the other dual writes it
+// fails open: a wave that cannot advance (a passed wave run again without
+// being reopened) keeps its state.
+func advanceWave(specDir string, waveNum int, state, command string) {
+	_ = wavepkg.Advance(specDir, waveNum, state, command)
+}
+
+// claimRunDir creates the next free run-NNN directory under commsDir and
+// returns its path and number. The directory is created with an exclusive
+// Mkdir, so two dispatches racing for the same number cannot share a run:
+// the loser moves on to the next number.
+func claimRunDir(commsDir string) (string, int, error) {
+	if err := os.MkdirAll(commsDir, 0755); err != nil {
+		return "", 0, err
+	}
+
+	// Scan for next run number. Runs archived by a wave rollback keep their
+	// numbers, so new runs are numbered after them too.
+	nextRun := 1
+	entries, err := os.ReadDir(commsDir)
+	if err != nil {
+		return "", 0, err
+	}
+	archived, _ := os.ReadDir(filepath.Join(commsDir, specdir.ArchivedDir))
+	for _, e := range append(entries, archived...) {
+		if !e.IsDir() {
+			continue
+		}
+		m := runNumRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		if n >= nextRun {
+			nextRun = n + 1
+		}
+	}
+
+	for {
+		runDir := filepath.Join(commsDir, fmt.Sprintf("run-%03d", nextRun))
+		err := os.Mkdir(runDir, 0755)
+		if err == nil {
+			return runDir, nextRun, nil
+		}
+		if !os.IsExist(err) {
+			return "", 0, err
+		}
+		nextRun++
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 
 	"github.com/lucas-stellet/oraculo/internal/config"
+	"github.com/lucas-stellet/oraculo/internal/fsutil"
 	"github.com/lucas-stellet/oraculo/internal/store"
 )
 
@@ -88,7 +89,7 @@ status.json format:
 		reportPath, statusPath)
 
 	briefFullPath := filepath.Join(subagentDir, "brief.md")
-	if err := os.WriteFile(briefFullPath, []byte(brief), 0644); err != nil {
+	if err := fsutil.WriteAtomic(briefFullPath, []byte(brief), 0644); err != nil {
 		Fail("failed to write brief.md: "+err.Error(), raw)
 	}
 
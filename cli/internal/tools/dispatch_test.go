@@ -7,7 +7,11 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	wavepkg "github.com/lucas-stellet/oraculo/internal/wave"
 )
 
 func TestCommandRegistry(t *testing.T) {
@@ -349,3 +353,80 @@ func writeJSON(t *testing.T, path string, v any) {
 		t.Fatal(err)
 	}
 }
+
+func TestClaimRunDir(t *testing.T) {
+	commsDir := filepath.Join(t.TempDir(), "execution", "waves", "wave-01", "execution")
+	runDir, n, err := claimRunDir(commsDir)
+	if err != nil {
+		t.Fatalf("claimRunDir: %v", err)
+	}
+	if n != 1 || filepath.Base(runDir) != "run-001" {
+		t.Errorf("first claim = %s (%d), want run-001", runDir, n)
+	}
+
+	// Concurrent dispatches each get their own run.
+	const workers = 8
+	var wg sync.WaitGroup
+	claimed := make(chan int, workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, n, err := claimRunDir(commsDir)
+			if err != nil {
+				t.Errorf("claimRunDir: %v", err)
+				return
+			}
+			claimed <- n
+		}()
+	}
+	wg.Wait()
+	close(claimed)
+	seen := make(map[int]bool)
+	for n := range claimed {
+		if seen[n] {
+			t.Errorf("run %d claimed twice", n)
+		}
+		seen[n] = true
+	}
+	if len(seen) != workers {
+		t.Errorf("claimed %d runs, want %d", len(seen), workers)
+	}
+
+	// Archived runs keep their numbers reserved.
+	archived := filepath.Join(commsDir, specdir.ArchivedDir)
+	if err := os.MkdirAll(archived, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(commsDir, "run-009"), filepath.Join(archived, "run-009")); err != nil {
+		t.Fatal(err)
+	}
+	if _, n, err := claimRunDir(commsDir); err != nil || n != 10 {
+		t.Errorf("claim after archiving run-009 = %d, %v; want 10", n, err)
+	}
+}
+
+func TestDispatchInitCheckpointSettles(t *testing.T) {
+	cwd := t.TempDir()
+	specDir := filepath.Join(cwd, ".spec-workflow", "specs", "demo")
+
+	DispatchInit(cwd, "checkpoint", "demo", "1", true)
+	if st, src := wavepkg.CurrentState(specDir, 1); st != wavepkg.StateAwaitingCheckpoint || src != wavepkg.SourceExplicit {
+		t.Fatalf("after dispatch-init: state = %s (%s), want explicit awaiting-checkpoint", st, src)
+	}
+
+	// The release-gate-decider reports; no one advances the wave by hand.
+	gate := filepath.Join(specDir, "execution", "waves", "wave-01", "checkpoint", "run-001", "release-gate-decider")
+	if err := os.MkdirAll(gate, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, filepath.Join(gate, "status.json"), map[string]string{"status": "pass"})
+
+	waves, err := wavepkg.ScanWaves(specDir)
+	if err != nil {
+		t.Fatalf("ScanWaves: %v", err)
+	}
+	if len(waves) != 1 || waves[0].Status != wavepkg.StatePassed {
+		t.Errorf("waves = %+v, want wave 1 passed", waves)
+	}
+}
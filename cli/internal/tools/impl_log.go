@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lucas-stellet/oraculo/internal/fsutil"
 	"github.com/lucas-stellet/oraculo/internal/specdir"
 )
 
@@ -41,7 +42,7 @@ func implLogRegisterResult(cwd, specName, taskID, wave, title, files, changes, t
 		sb.WriteString("\n")
 	}
 
-	if err := os.WriteFile(logPath, []byte(sb.String()), 0644); err != nil {
+	if err := fsutil.WriteAtomic(logPath, []byte(sb.String()), 0644); err != nil {
 		return nil, "", fmt.Errorf("failed to write impl log: %w", err)
 	}
 
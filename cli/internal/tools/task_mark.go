@@ -6,63 +6,84 @@ import (
 	"path/filepath"
 	"regexp"
 
+	"github.com/lucas-stellet/oraculo/internal/fsutil"
 	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
 )
 
 // taskMarkResult performs the task marking logic and returns the result.
 // Extracted for testability — the public TaskMark function wraps this with Output/Fail.
 func taskMarkResult(cwd, specName, taskID, status string) (map[string]any, error) {
-	tasksPath := specdir.TasksPath(specdir.SpecDirAbs(cwd, specName))
+	specDir := specdir.SpecDirAbs(cwd, specName)
+	tasksPath := specdir.TasksPath(specDir)
 
-	data, err := os.ReadFile(tasksPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read tasks.md: %w", err)
-	}
-
-	content := string(data)
-
-	// Match task line: `- [ ] **ID.** ...` where checkbox marker is space, x, -, or !
-	pattern := fmt.Sprintf(`(?m)^(\s*- \[)([ x\-!])(\] \*\*%s\.\*\*)`, regexp.QuoteMeta(taskID))
-	re := regexp.MustCompile(pattern)
-
-	loc := re.FindStringSubmatchIndex(content)
-	if loc == nil {
-		return nil, fmt.Errorf("task ID %s not found in tasks.md", taskID)
-	}
-
-	// Extract previous marker (group 2)
-	prevMarker := content[loc[4]:loc[5]]
+	// Hold the spec directory lock across the read-modify-write so a
+	// concurrent mark cannot overwrite this one.
 	var prevStatus string
-	switch prevMarker {
-	case " ":
-		prevStatus = "pending"
-	case "-":
-		prevStatus = "in-progress"
-	case "x":
-		prevStatus = "done"
-	case "!":
-		prevStatus = "blocked"
-	default:
-		prevStatus = "unknown"
-	}
-
-	// Determine new marker
-	var newMarker string
-	switch status {
-	case "in-progress":
-		newMarker = "-"
-	case "done":
-		newMarker = "x"
-	case "blocked":
-		newMarker = "!"
+	err := fsutil.WithLock(tasksPath, func() error {
+		data, err := os.ReadFile(tasksPath)
+		if err != nil {
+			return fmt.Errorf("failed to read tasks.md: %w", err)
+		}
+
+		content := string(data)
+
+		// Match task line: `- [ ] **ID.** ...` where checkbox marker is space, x, -, ! or ~
+		pattern := fmt.Sprintf(`(?m)^(\s*- \[)([ x\-!~])(\] \*\*%s\.\*\*)`, regexp.QuoteMeta(taskID))
+		re := regexp.MustCompile(pattern)
+
+		loc := re.FindStringSubmatchIndex(content)
+		if loc == nil {
+			return fmt.Errorf("task ID %s not found in tasks.md", taskID)
+		}
+
+		// Extract previous marker (group 2)
+		prevMarker := content[loc[4]:loc[5]]
+		switch prevMarker {
+		case " ":
+			prevStatus = "pending"
+		case "-":
+			prevStatus = "in-progress"
+		case "x":
+			prevStatus = "done"
+		case "!":
+			prevStatus = "blocked"
+		case "~":
+			prevStatus = "skipped"
+		default:
+			prevStatus = "unknown"
+		}
+
+		// Determine new marker
+		var newMarker string
+		switch status {
+		case "in-progress":
+			newMarker = "-"
+		case "done":
+			newMarker = "x"
+		case "blocked":
+			newMarker = "!"
+		}
+
+		// Replace the marker in content
+		updated := content[:loc[4]] + newMarker + content[loc[5]:]
+
+		if err := fsutil.WriteAtomic(tasksPath, []byte(updated), 0644); err != nil {
+			return fmt.Errorf("failed to write tasks.md: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Replace the marker in content
-	updated := content[:loc[4]] + newMarker + content[loc[5]:]
-
-	if err := os.WriteFile(tasksPath, []byte(updated), 0644); err != nil {
-		return nil, fmt.Errorf("failed to write tasks.md: %w", err)
-	}
+	store.TryRecordEvent(specDir, store.Event{
+		Command: "task-mark",
+		TaskID:  taskID,
+		Args:    map[string]any{"task_id": taskID, "status": status},
+		Before:  map[string]any{"status": prevStatus},
+		After:   map[string]any{"status": status},
+	})
 
 	tasksRel, _ := filepath.Rel(cwd, tasksPath)
 
@@ -109,6 +109,21 @@ func TestTaskMarkToBlocked(t *testing.T) {
 	}
 }
 
+func TestTaskMarkFromSkipped(t *testing.T) {
+	cwd := setupTasksDir(t, strings.Replace(testTasksMD, "- [ ] **1.**", "- [~] **1.**", 1))
+
+	result, err := taskMarkResult(cwd, "test-spec", "1", "in-progress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["previous_status"] != "skipped" {
+		t.Errorf("previous_status = %v, want skipped", result["previous_status"])
+	}
+	if content := readTasksMD(t, cwd); !strings.Contains(content, "- [-] **1.** Setup project structure") {
+		t.Error("tasks.md should have [-] marker for task 1")
+	}
+}
+
 func TestTaskMarkNotFound(t *testing.T) {
 	cwd := setupTasksDir(t, testTasksMD)
 
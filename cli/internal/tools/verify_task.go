@@ -4,6 +4,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/lucas-stellet/oraculo/internal/git"
 	"github.com/lucas-stellet/oraculo/internal/specdir"
 )
 
@@ -26,7 +27,7 @@ func verifyTaskResult(cwd, specName, taskID string, checkCommit bool) (map[strin
 
 	if checkCommit {
 		commitInfo := map[string]any{"exists": false}
-		out, err := exec.Command("git", "-C", cwd, "log", "--oneline", "-E", "--grep=task "+taskID+"([^0-9]|$)").Output()
+		out, err := exec.Command("git", "-C", cwd, "log", "--oneline", "-E", "--grep="+git.TaskPattern(taskID)).Output()
 		if err == nil {
 			lines := strings.TrimSpace(string(out))
 			if lines != "" {
@@ -9,10 +9,12 @@ import (
 	"strings"
 
 	"github.com/lucas-stellet/oraculo/internal/specdir"
+	wavepkg "github.com/lucas-stellet/oraculo/internal/wave"
 )
 
 type waveInfo struct {
 	Wave       string `json:"wave"`
+	State      string `json:"state"`
 	Status     string `json:"status"`
 	Tasks      []int  `json:"tasks"`
 	Checkpoint string `json:"checkpoint"`
@@ -31,7 +33,7 @@ type waveSummaryDoc struct {
 	UpdatedAt string `json:"updated_at,omitempty"`
 }
 
-var taskLineRe = regexp.MustCompile(`(?m)^-\s+\[([ x\-!])\]\s+\*\*(\d+)\.\*\*`)
+var taskLineRe = regexp.MustCompile(`(?m)^-\s+\[([ x\-!~])\]\s+\*\*(\d+)\.\*\*`)
 
 // waveStatusResult performs the wave status logic and returns the result.
 // Extracted for testability — the public WaveStatus function wraps this with Output/Fail.
@@ -49,11 +51,13 @@ func waveStatusResult(cwd, specName string) (map[string]any, error) {
 	}
 
 	// Build wave info for each existing wave directory
+	resolve := wavepkg.CheckpointResolver(specDirAbs)
 	var waves []waveInfo
 	for _, wd := range waveDirs {
 		wi := waveInfo{
 			Wave: fmt.Sprintf("%02d", wd.Num),
 		}
+		wi.State, _ = wavepkg.CurrentState(specDirAbs, wd.Num)
 
 		// Read wave summary
 		summaryPath := specdir.WaveSummaryPath(specDirAbs, wd.Num)
@@ -74,13 +78,12 @@ func waveStatusResult(cwd, specName string) (map[string]any, error) {
 			wi.Tasks = []int{}
 		}
 
-		// Read latest JSON for checkpoint status
-		latestPath := specdir.WaveLatestPath(specDirAbs, wd.Num)
-		if specdir.FileExists(latestPath) {
-			doc, err := specdir.ReadLatestJSON(latestPath)
-			if err == nil {
-				wi.Checkpoint = doc.Status
-			}
+		// Checkpoint status: the checkpoint run judged by the gate, else
+		// what _latest.json records (an inline checkpoint has no runs)
+		if cp := resolve(wd.Num); cp.Status == "pass" || cp.Status == "blocked" {
+			wi.Checkpoint = cp.Status
+		} else if doc, err := specdir.ReadLatestJSON(specdir.WaveLatestPath(specDirAbs, wd.Num)); err == nil {
+			wi.Checkpoint = doc.Status
 		}
 		if wi.Checkpoint == "" {
 			wi.Checkpoint = "missing"
@@ -128,7 +131,8 @@ func waveStatusResult(cwd, specName string) (map[string]any, error) {
 		checkpointStatus = latest.Checkpoint
 
 		// Determine wave status and resume action based on latest wave state
-		if latest.Checkpoint == "pass" {
+		switch latest.State {
+		case wavepkg.StatePassed:
 			// Wave completed — check if there are still pending tasks
 			waveStatus = "completed"
 
@@ -160,12 +164,12 @@ func waveStatusResult(cwd, specName string) (map[string]any, error) {
 					nextTasks = allPendingTasks(tasks)
 				}
 			}
-		} else if latest.Checkpoint == "blocked" {
+		case wavepkg.StateBlocked:
 			waveStatus = "blocked"
 			resumeAction = "wait-checkpoint"
 			nextTasks = latest.Tasks
-		} else {
-			// No checkpoint or checkpoint missing — wave is in progress
+		default:
+			// Pending, executing, awaiting its checkpoint or reopened
 			waveStatus = "in-progress"
 			if len(inProgressTasks) > 0 {
 				resumeAction = "resume-in-progress"
@@ -229,6 +233,8 @@ func parseTasks(specDirAbs string) []taskInfo {
 			status = "in-progress"
 		case "!":
 			status = "blocked"
+		case "~":
+			status = "skipped"
 		default:
 			status = "pending"
 		}
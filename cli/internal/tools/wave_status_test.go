@@ -423,3 +423,27 @@ func TestWaveStatusNoTasksMD(t *testing.T) {
 		t.Errorf("resume_action = %v, want start-wave", result["resume_action"])
 	}
 }
+
+func TestWaveStatusConfiguredGate(t *testing.T) {
+	cwd, specDir := setupSpecDir(t, "test-spec")
+	writeTasksMD(t, specDir, "# Tasks\n\n- [x] 1 First task\n  Wave: 1\n")
+	runDir := filepath.Join(createWaveDir(t, specDir, 1), "checkpoint", "run-001")
+	for name, status := range map[string]string{"release-gate-decider": "pass", "traceability-judge": "blocked"} {
+		if err := os.MkdirAll(filepath.Join(runDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeJSON(t, filepath.Join(runDir, name, "status.json"), map[string]string{"status": status})
+	}
+	if err := os.WriteFile(filepath.Join(cwd, ".spec-workflow", "oraculo.toml"), []byte("[checkpoint]\npolicy = \"all-pass\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := waveStatusResult(cwd, "test-spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waves := result["waves"].([]waveInfo)
+	if len(waves) != 1 || waves[0].State != "blocked" || waves[0].Checkpoint != "blocked" {
+		t.Errorf("waves = %+v, want wave 01 blocked under the all-pass gate", waves)
+	}
+}
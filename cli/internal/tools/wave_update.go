@@ -9,7 +9,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lucas-stellet/oraculo/internal/fsutil"
 	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	wavepkg "github.com/lucas-stellet/oraculo/internal/wave"
 )
 
 // waveUpdateResult performs the wave update logic and returns the result.
@@ -43,6 +46,20 @@ func waveUpdateResult(cwd, specName, wave, status, tasks, checkpointRun, executi
 		return nil, fmt.Errorf("failed to create wave directory: %w", err)
 	}
 
+	// Capture the previous state for the event journal before overwriting.
+	var before any
+	if prev, err := specdir.ReadLatestJSON(specdir.WaveLatestPath(specDirAbs, waveNum)); err == nil {
+		before = map[string]any{"status": prev.Status}
+	}
+
+	// Advance the wave state before the files that inference reads change.
+	switch status {
+	case "pass":
+		advanceWave(specDirAbs, waveNum, wavepkg.StatePassed, "wave-update")
+	case "blocked":
+		advanceWave(specDirAbs, waveNum, wavepkg.StateBlocked, "wave-update")
+	}
+
 	// Build and write summary JSON
 	summaryPath := specdir.WaveSummaryPath(specDirAbs, waveNum)
 	summary := map[string]any{
@@ -70,6 +87,17 @@ func waveUpdateResult(cwd, specName, wave, status, tasks, checkpointRun, executi
 		return nil, fmt.Errorf("failed to write wave latest: %w", err)
 	}
 
+	store.TryRecordEvent(specDirAbs, store.Event{
+		Command: "wave-update",
+		Wave:    &waveNum,
+		Args: map[string]any{
+			"wave": wave, "status": status, "tasks": tasks,
+			"checkpoint_run": checkpointRun, "execution_run": executionRun,
+		},
+		Before: before,
+		After:  latest,
+	})
+
 	summaryRel, _ := filepath.Rel(cwd, summaryPath)
 	latestRel, _ := filepath.Rel(cwd, latestPath)
 
@@ -87,7 +115,7 @@ func writeJSONFile(path string, v any) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	return fsutil.WriteAtomic(path, data, 0644)
 }
 
 // WaveUpdate writes wave summary and latest JSON files for a spec wave.
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/lucas-stellet/oraculo/internal/store"
 )
 
 func setupWaveDir(t *testing.T) string {
@@ -177,3 +179,40 @@ func TestWaveUpdateDirCreation(t *testing.T) {
 		t.Error("wave path should be a directory")
 	}
 }
+
+func TestWaveUpdateRecordsEvent(t *testing.T) {
+	cwd := setupWaveDir(t)
+
+	if _, err := waveUpdateResult(cwd, "test-spec", "01", "blocked", "1,2", "run-001", "run-001"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := waveUpdateResult(cwd, "test-spec", "01", "pass", "1,2", "run-002", "run-002"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := store.Open(filepath.Join(cwd, ".spec-workflow", "specs", "test-spec"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	events, err := s.ListEvents(store.EventFilter{Command: "wave-update"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 wave-update events, got %d", len(events))
+	}
+	last := events[1]
+	if last.Wave == nil || *last.Wave != 1 {
+		t.Errorf("event wave = %v, want 1", last.Wave)
+	}
+	before, _ := last.Before.(map[string]any)
+	after, _ := last.After.(map[string]any)
+	if before["status"] != "blocked" || after["status"] != "pass" {
+		t.Errorf("event before/after = %v / %v, want blocked -> pass", last.Before, last.After)
+	}
+	if events[0].Before != nil {
+		t.Errorf("first update should have no before state, got %v", events[0].Before)
+	}
+}
@@ -0,0 +1,87 @@
+package trace
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Requirement and criterion references in free text: "REQ-001", "REQ-001.2".
+var requirementRefRe = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*-\d+(?:\.\d+)*`)
+
+// Scenario is one row of a QA test plan table that names requirements.
+type Scenario struct {
+	ID           string   `json:"id"`
+	Requirements []string `json:"requirements"`
+	Line         int      `json:"line"`
+}
+
+// ParseScenariosFile reads and parses QA-TEST-PLAN.md.
+func ParseScenariosFile(path string) ([]Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read QA test plan: %w", err)
+	}
+	return ParseScenarios(string(data)), nil
+}
+
+// ParseScenarios extracts scenarios from the markdown tables of a QA test
+// plan. Only tables with a "Requirement" column count: the first column is
+// the scenario ID and the requirement column lists what it covers.
+func ParseScenarios(content string) []Scenario {
+	var out []Scenario
+	reqCol := -1 // requirement column of the current table, -1 if none
+	inTable := false
+
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "|") {
+			inTable = false
+			continue
+		}
+		cells := splitRow(trimmed)
+		if !inTable {
+			inTable = true
+			reqCol = -1
+			for j, c := range cells {
+				if strings.HasPrefix(strings.ToLower(c), "requirement") {
+					reqCol = j
+					break
+				}
+			}
+			continue
+		}
+		if reqCol < 1 || reqCol >= len(cells) || isSeparatorRow(cells) {
+			continue
+		}
+		refs := requirementRefRe.FindAllString(cells[reqCol], -1)
+		if cells[0] == "" || len(refs) == 0 {
+			continue
+		}
+		for j := range refs {
+			refs[j] = strings.ToUpper(refs[j])
+		}
+		out = append(out, Scenario{ID: cells[0], Requirements: refs, Line: i + 1})
+	}
+	return out
+}
+
+// splitRow returns the trimmed cells of a "| a | b |" table row.
+func splitRow(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	cells := strings.Split(row, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+func isSeparatorRow(cells []string) bool {
+	for _, c := range cells {
+		if strings.Trim(c, ":- ") != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+// Package trace links requirements in requirements.md to the tasks,
+// implementation logs and QA scenarios that cover them.
+package trace
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// Requirement heading: ### REQ-001 - Title
+	requirementRe = regexp.MustCompile(`^#{2,4}\s+([A-Za-z][A-Za-z0-9]*-\d+)\b\s*(?:[-–—:]\s*)?(.*)$`)
+
+	// Start of the acceptance criteria list: - Acceptance criteria (EARS):
+	criteriaRe = regexp.MustCompile(`(?i)^(\s*)-\s+Acceptance\s+criteria\b`)
+
+	// Criterion bullet, optionally led by its own ID: - AC-1: WHEN ...
+	criterionRe   = regexp.MustCompile(`^(\s*)-\s+(.*)$`)
+	criterionIDRe = regexp.MustCompile(`^\[?([A-Za-z][A-Za-z0-9]*-\d+(?:\.\d+)*)\]?\s*[:.)-]?\s+(.*)$`)
+)
+
+// Requirement is one "### REQ-001 - Title" section of requirements.md.
+type Requirement struct {
+	ID       string      `json:"id"`
+	Title    string      `json:"title"`
+	Line     int         `json:"line"`
+	Criteria []Criterion `json:"criteria,omitempty"`
+}
+
+// Criterion is one acceptance criterion of a requirement. A bullet that
+// starts with an ID keeps it; the others are numbered after the
+// requirement (REQ-001.1, REQ-001.2, ...).
+type Criterion struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+	Line int    `json:"line"`
+}
+
+// ParseRequirementsFile reads and parses requirements.md.
+func ParseRequirementsFile(path string) ([]Requirement, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read requirements.md: %w", err)
+	}
+	return ParseRequirements(string(data)), nil
+}
+
+// ParseRequirements extracts the requirements and their acceptance
+// criteria from requirements.md content. IDs are upper-cased so they match
+// task references regardless of case.
+func ParseRequirements(content string) []Requirement {
+	var reqs []Requirement
+	var cur *Requirement
+	criteriaIndent := -1 // indent of the "Acceptance criteria" bullet, -1 outside the list
+
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			criteriaIndent = -1
+			if m := requirementRe.FindStringSubmatch(trimmed); m != nil {
+				reqs = append(reqs, Requirement{
+					ID:    strings.ToUpper(m[1]),
+					Title: strings.TrimSpace(m[2]),
+					Line:  i + 1,
+				})
+				cur = &reqs[len(reqs)-1]
+			} else {
+				cur = nil
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if m := criteriaRe.FindStringSubmatch(line); m != nil {
+			criteriaIndent = len(m[1])
+			continue
+		}
+		if criteriaIndent < 0 || trimmed == "" {
+			continue
+		}
+		m := criterionRe.FindStringSubmatch(line)
+		if m == nil || len(m[1]) <= criteriaIndent {
+			criteriaIndent = -1
+			continue
+		}
+		text := strings.TrimSpace(m[2])
+		if text == "" {
+			continue
+		}
+		id := cur.ID + "." + strconv.Itoa(len(cur.Criteria)+1)
+		if im := criterionIDRe.FindStringSubmatch(text); im != nil {
+			id, text = strings.ToUpper(im[1]), im[2]
+		}
+		cur.Criteria = append(cur.Criteria, Criterion{ID: id, Text: text, Line: i + 1})
+	}
+	return reqs
+}
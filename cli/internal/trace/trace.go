@@ -0,0 +1,192 @@
+package trace
+
+import (
+	"github.com/lucas-stellet/oraculo/internal/tasks"
+)
+
+// Gap names used in Coverage.Gaps.
+const (
+	GapTasks    = "tasks"     // no task references the item
+	GapImplLogs = "impl_logs" // no referencing task has an implementation log
+	GapQA       = "qa"        // no QA scenario references the item
+)
+
+// Coverage lists what covers a requirement or criterion.
+type Coverage struct {
+	Tasks     []string `json:"tasks"`
+	ImplLogs  []string `json:"impl_logs"` // tasks from Tasks with an implementation log
+	Scenarios []string `json:"scenarios"`
+	Gaps      []string `json:"gaps,omitempty"`
+}
+
+// Uncovered reports whether no task covers the item.
+func (c Coverage) Uncovered() bool {
+	return len(c.Tasks) == 0
+}
+
+// RequirementRow is one requirement of the matrix.
+type RequirementRow struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Coverage
+	Criteria []CriterionRow `json:"criteria,omitempty"`
+}
+
+// CriterionRow is one acceptance criterion of the matrix.
+type CriterionRow struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+	Coverage
+}
+
+// UnknownRef is a reference to an ID that requirements.md does not define.
+type UnknownRef struct {
+	Source string `json:"source"` // "task" or "scenario"
+	ID     string `json:"id"`     // task or scenario ID
+	Ref    string `json:"ref"`
+}
+
+// Summary counts covered items.
+type Summary struct {
+	Requirements    int      `json:"requirements"`
+	Covered         int      `json:"covered"`
+	WithImplLogs    int      `json:"with_impl_logs"`
+	WithQA          int      `json:"with_qa"`
+	Criteria        int      `json:"criteria"`
+	CriteriaCovered int      `json:"criteria_covered"`
+	Uncovered       []string `json:"uncovered"`
+}
+
+// Matrix is the requirement → tasks → impl logs → QA scenarios coverage of
+// a spec.
+type Matrix struct {
+	Requirements []RequirementRow `json:"requirements"`
+	Unknown      []UnknownRef     `json:"unknown,omitempty"`
+	Summary      Summary          `json:"summary"`
+}
+
+// Build links requirements to the tasks of doc and to scenarios. A task or
+// scenario naming a requirement covers it and all of its criteria; one
+// naming a criterion covers that criterion and, through it, the
+// requirement. Skipped tasks cover nothing. hasImplLog reports whether a
+// task has an implementation log.
+func Build(reqs []Requirement, doc tasks.Document, scenarios []Scenario, hasImplLog func(taskID string) bool) Matrix {
+	// Resolve each ID to the requirement index and criterion index (-1 for
+	// the requirement itself).
+	type target struct{ req, crit int }
+	targets := make(map[string]target)
+	for i, r := range reqs {
+		targets[r.ID] = target{i, -1}
+		for j, c := range r.Criteria {
+			targets[c.ID] = target{i, j}
+		}
+	}
+
+	reqTasks := make([]refSet, len(reqs))
+	reqScenarios := make([]refSet, len(reqs))
+	critTasks := make([][]refSet, len(reqs))
+	critScenarios := make([][]refSet, len(reqs))
+	for i, r := range reqs {
+		critTasks[i] = make([]refSet, len(r.Criteria))
+		critScenarios[i] = make([]refSet, len(r.Criteria))
+	}
+
+	var m Matrix
+	link := func(source, id string, refs []string, req []refSet, crit [][]refSet) {
+		for _, ref := range refs {
+			t, ok := targets[ref]
+			if !ok {
+				m.Unknown = append(m.Unknown, UnknownRef{Source: source, ID: id, Ref: ref})
+				continue
+			}
+			req[t.req].add(id)
+			if t.crit >= 0 {
+				crit[t.req][t.crit].add(id)
+				continue
+			}
+			for j := range crit[t.req] {
+				crit[t.req][j].add(id)
+			}
+		}
+	}
+	for _, t := range doc.Tasks {
+		if t.Status != "skipped" {
+			link("task", t.ID, t.Requirements, reqTasks, critTasks)
+		}
+	}
+	for _, s := range scenarios {
+		link("scenario", s.ID, s.Requirements, reqScenarios, critScenarios)
+	}
+
+	m.Requirements = make([]RequirementRow, 0, len(reqs))
+	m.Summary.Uncovered = []string{}
+	for i, r := range reqs {
+		row := RequirementRow{ID: r.ID, Title: r.Title, Coverage: coverage(reqTasks[i], reqScenarios[i], hasImplLog)}
+		m.Summary.Requirements++
+		if !row.Uncovered() {
+			m.Summary.Covered++
+		} else {
+			m.Summary.Uncovered = append(m.Summary.Uncovered, r.ID)
+		}
+		if len(row.ImplLogs) > 0 {
+			m.Summary.WithImplLogs++
+		}
+		if len(row.Scenarios) > 0 {
+			m.Summary.WithQA++
+		}
+		for j, c := range r.Criteria {
+			cr := CriterionRow{ID: c.ID, Text: c.Text, Coverage: coverage(critTasks[i][j], critScenarios[i][j], hasImplLog)}
+			m.Summary.Criteria++
+			if !cr.Uncovered() {
+				m.Summary.CriteriaCovered++
+			} else if !row.Uncovered() {
+				m.Summary.Uncovered = append(m.Summary.Uncovered, c.ID)
+			}
+			row.Criteria = append(row.Criteria, cr)
+		}
+		m.Requirements = append(m.Requirements, row)
+	}
+	return m
+}
+
+func coverage(taskIDs, scenarioIDs refSet, hasImplLog func(string) bool) Coverage {
+	c := Coverage{Tasks: taskIDs.list(), ImplLogs: []string{}, Scenarios: scenarioIDs.list()}
+	for _, id := range c.Tasks {
+		if hasImplLog(id) {
+			c.ImplLogs = append(c.ImplLogs, id)
+		}
+	}
+	if len(c.Tasks) == 0 {
+		c.Gaps = append(c.Gaps, GapTasks)
+	}
+	if len(c.ImplLogs) == 0 {
+		c.Gaps = append(c.Gaps, GapImplLogs)
+	}
+	if len(c.Scenarios) == 0 {
+		c.Gaps = append(c.Gaps, GapQA)
+	}
+	return c
+}
+
+// refSet collects IDs once each, in insertion order.
+type refSet struct {
+	ids  []string
+	seen map[string]bool
+}
+
+func (s *refSet) add(id string) {
+	if s.seen == nil {
+		s.seen = make(map[string]bool)
+	}
+	if !s.seen[id] {
+		s.seen[id] = true
+		s.ids = append(s.ids, id)
+	}
+}
+
+func (s refSet) list() []string {
+	if s.ids == nil {
+		return []string{}
+	}
+	return s.ids
+}
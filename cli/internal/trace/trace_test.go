@@ -0,0 +1,134 @@
+package trace
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lucas-stellet/oraculo/internal/tasks"
+)
+
+const requirementsDoc = `# Requirements Document
+
+## Functional Requirements
+
+### REQ-001 - Login
+- User story: as a user I sign in
+- Acceptance criteria (EARS):
+  - WHEN credentials are valid THEN the system must sign in
+  - IF credentials are wrong THEN the system must show an error
+- Priority: Must
+
+### req-002: Logout
+- Acceptance criteria (EARS):
+  - AC-9: WHEN logout is clicked THEN the session ends
+  -
+
+### REQ-003 - Audit
+- Acceptance criteria (EARS):
+
+## Non-Functional Requirements
+- Performance:
+`
+
+const testPlanDoc = `## Scenarios
+
+| Test ID | Requirement | Level |
+|---|---|---|
+| T-001 | REQ-001.1 | Smoke |
+| T-002 | REQ-002, REQ-007 | Regression |
+| | REQ-003 | Smoke |
+`
+
+const tasksDoc = `## Tasks
+
+- [x] 1 Login form
+  Wave: 1
+  Requirements: REQ-001
+
+- [ ] 2 Logout button
+  Wave: 1
+  - _Requirements: AC-9, REQ-042_
+
+- [~] 3 Audit trail
+  Wave: 2
+  Requirements: REQ-003
+  Reason: descoped
+`
+
+func TestParseRequirements(t *testing.T) {
+	reqs := ParseRequirements(requirementsDoc)
+	if len(reqs) != 3 {
+		t.Fatalf("got %d requirements, want 3: %+v", len(reqs), reqs)
+	}
+	if reqs[0].ID != "REQ-001" || reqs[0].Title != "Login" || reqs[0].Line != 5 {
+		t.Errorf("req 0 = %+v", reqs[0])
+	}
+	var ids []string
+	for _, c := range reqs[0].Criteria {
+		ids = append(ids, c.ID)
+	}
+	if want := []string{"REQ-001.1", "REQ-001.2"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("REQ-001 criteria = %v, want %v", ids, want)
+	}
+	if reqs[1].ID != "REQ-002" || reqs[1].Title != "Logout" {
+		t.Errorf("req 1 = %+v", reqs[1])
+	}
+	if len(reqs[1].Criteria) != 1 || reqs[1].Criteria[0].ID != "AC-9" ||
+		reqs[1].Criteria[0].Text != "WHEN logout is clicked THEN the session ends" {
+		t.Errorf("REQ-002 criteria = %+v", reqs[1].Criteria)
+	}
+	if len(reqs[2].Criteria) != 0 {
+		t.Errorf("REQ-003 criteria = %+v, want none", reqs[2].Criteria)
+	}
+}
+
+func TestParseScenarios(t *testing.T) {
+	got := ParseScenarios(testPlanDoc)
+	want := []Scenario{
+		{ID: "T-001", Requirements: []string{"REQ-001.1"}, Line: 5},
+		{ID: "T-002", Requirements: []string{"REQ-002", "REQ-007"}, Line: 6},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseScenarios = %+v, want %+v", got, want)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	m := Build(ParseRequirements(requirementsDoc), tasks.Parse(tasksDoc), ParseScenarios(testPlanDoc),
+		func(id string) bool { return id == "1" })
+
+	req1 := m.Requirements[0]
+	if !reflect.DeepEqual(req1.Tasks, []string{"1"}) || !reflect.DeepEqual(req1.ImplLogs, []string{"1"}) ||
+		!reflect.DeepEqual(req1.Scenarios, []string{"T-001"}) || len(req1.Gaps) != 0 {
+		t.Errorf("REQ-001 = %+v", req1.Coverage)
+	}
+	// Naming the requirement covers every criterion; the scenario only
+	// names the first.
+	if c := req1.Criteria[1]; !reflect.DeepEqual(c.Tasks, []string{"1"}) || !reflect.DeepEqual(c.Gaps, []string{GapQA}) {
+		t.Errorf("REQ-001.2 = %+v", c.Coverage)
+	}
+
+	// Naming a criterion covers its requirement.
+	req2 := m.Requirements[1]
+	if !reflect.DeepEqual(req2.Tasks, []string{"2"}) || !reflect.DeepEqual(req2.Gaps, []string{GapImplLogs}) {
+		t.Errorf("REQ-002 = %+v", req2.Coverage)
+	}
+
+	// A skipped task covers nothing.
+	req3 := m.Requirements[2]
+	if !req3.Uncovered() || !reflect.DeepEqual(req3.Gaps, []string{GapTasks, GapImplLogs, GapQA}) {
+		t.Errorf("REQ-003 = %+v", req3.Coverage)
+	}
+
+	wantUnknown := []UnknownRef{
+		{Source: "task", ID: "2", Ref: "REQ-042"},
+		{Source: "scenario", ID: "T-002", Ref: "REQ-007"},
+	}
+	if !reflect.DeepEqual(m.Unknown, wantUnknown) {
+		t.Errorf("Unknown = %+v, want %+v", m.Unknown, wantUnknown)
+	}
+	wantSummary := Summary{Requirements: 3, Covered: 2, WithImplLogs: 1, WithQA: 2, Criteria: 3, CriteriaCovered: 3, Uncovered: []string{"REQ-003"}}
+	if !reflect.DeepEqual(m.Summary, wantSummary) {
+		t.Errorf("Summary = %+v, want %+v", m.Summary, wantSummary)
+	}
+}
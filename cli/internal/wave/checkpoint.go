@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"regexp"
 
+	"github.com/lucas-stellet/oraculo/internal/config"
 	"github.com/lucas-stellet/oraculo/internal/specdir"
 )
 
@@ -13,14 +14,61 @@ var runNumRe = regexp.MustCompile(`^run-(\d+)$`)
 // releaseGateDecider is the canonical subagent name for checkpoint runs.
 const releaseGateDecider = "release-gate-decider"
 
-// ResolveCheckpoint determines the checkpoint status for a wave.
+// sourceConfig is the CheckpointResult source of a checkpoint that could
+// not be judged because the workspace's gate is invalid.
+const sourceConfig = "config"
+
+// ResolveCheckpoint determines the checkpoint status for a wave under the
+// gate configured for its workspace (see CheckpointResolver).
+func ResolveCheckpoint(specDir string, waveNum int) CheckpointResult {
+	return CheckpointResolver(specDir)(waveNum)
+}
+
+// Gate returns the [checkpoint] gate of the oraculo.toml of the workspace
+// specDir belongs to. A spec outside .spec-workflow/specs gets the default
+// gate, where the release-gate-decider decides; a config that cannot be
+// read or holds an invalid gate is an error.
+func Gate(specDir string) (config.CheckpointConfig, error) {
+	specs := filepath.Dir(filepath.Clean(specDir))
+	if filepath.Base(specs) != "specs" || filepath.Base(filepath.Dir(specs)) != ".spec-workflow" {
+		return config.Defaults().Checkpoint, nil
+	}
+	cfg, err := config.Load(filepath.Dir(filepath.Dir(specs)))
+	if err != nil {
+		return config.CheckpointConfig{}, err
+	}
+	return cfg.Checkpoint, nil
+}
+
+// CheckpointResolver loads the workspace's gate once and returns a function
+// resolving a wave's checkpoint under it. Every reader of checkpoint
+// results goes through it, so wave checkpoint, wave-status, tasks next and
+// resume agree. The gate fails closed: when it cannot be loaded, every
+// checkpoint resolves as blocked, with the error in Details.
+func CheckpointResolver(specDir string) func(waveNum int) CheckpointResult {
+	gate, err := Gate(specDir)
+	return func(waveNum int) CheckpointResult {
+		if err != nil {
+			return CheckpointResult{
+				WaveNum: waveNum,
+				Status:  "blocked",
+				Source:  sourceConfig,
+				Details: err.Error(),
+			}
+		}
+		return ResolveCheckpointGate(specDir, waveNum, gate)
+	}
+}
+
+// ResolveCheckpointGate determines the checkpoint status for a wave,
+// applying gate to the subagent status.json files of the run.
 // Resolution order:
 //  1. Read _latest.json -> get latest checkpoint run-id
-//  2. Read checkpoint/{run-id}/release-gate-decider/status.json
+//  2. Evaluate the gate over checkpoint/{run-id}/*/status.json
 //  3. If _latest.json differs from _wave-summary.json -> flag stale_summary
 //  4. If _latest.json missing -> scan checkpoint dir, highest run wins
 //  5. If no checkpoint dirs -> status: "missing"
-func ResolveCheckpoint(specDir string, waveNum int) CheckpointResult {
+func ResolveCheckpointGate(specDir string, waveNum int, gate config.CheckpointConfig) CheckpointResult {
 	checkDir := specdir.WaveCheckpointPath(specDir, waveNum)
 
 	// If checkpoint dir doesn't exist at all
@@ -41,10 +89,12 @@ func ResolveCheckpoint(specDir string, waveNum int) CheckpointResult {
 				WaveNum: waveNum,
 				RunID:   latest.RunID,
 				Source:  "latest_json",
+				Policy:  gate.Policy,
 			}
 
-			// Step 2: Read the actual run's status.json for ground truth
-			runStatus := readCheckpointRunStatus(checkDir, latest.RunID)
+			// Step 2: Read the actual run's status.json files for ground truth
+			runStatus, rules := evaluateGate(filepath.Join(checkDir, latest.RunID), gate)
+			result.Rules = rules
 			if runStatus != "" {
 				result.Status = runStatus
 			} else {
@@ -77,13 +127,15 @@ func ResolveCheckpoint(specDir string, waveNum int) CheckpointResult {
 	}
 
 	runID := filepath.Base(runDir)
-	status := readRunSubagentStatus(runDir)
+	status, rules := evaluateGate(runDir, gate)
 
 	result := CheckpointResult{
 		WaveNum: waveNum,
 		Status:  status,
 		RunID:   runID,
 		Source:  "dir_scan",
+		Policy:  gate.Policy,
+		Rules:   rules,
 	}
 
 	if status == "" {
@@ -94,50 +146,6 @@ func ResolveCheckpoint(specDir string, waveNum int) CheckpointResult {
 	return result
 }
 
-// readCheckpointRunStatus reads the release-gate-decider's status.json for a specific run.
-// Falls back to scanning all subagent dirs if release-gate-decider doesn't exist.
-func readCheckpointRunStatus(checkDir, runID string) string {
-	runPath := filepath.Join(checkDir, runID)
-	if !specdir.DirExists(runPath) {
-		return ""
-	}
-
-	// Try canonical subagent first
-	statusPath := filepath.Join(runPath, releaseGateDecider, specdir.StatusJSON)
-	if specdir.FileExists(statusPath) {
-		doc, err := specdir.ReadStatusJSON(statusPath)
-		if err == nil {
-			return doc.Status
-		}
-	}
-
-	// Fall back to scanning all subagent dirs
-	return readRunSubagentStatus(runPath)
-}
-
-// readRunSubagentStatus scans all subagent directories in a run for any status.json.
-func readRunSubagentStatus(runDir string) string {
-	entries, err := os.ReadDir(runDir)
-	if err != nil {
-		return ""
-	}
-
-	for _, e := range entries {
-		if !e.IsDir() {
-			continue
-		}
-		statusPath := filepath.Join(runDir, e.Name(), specdir.StatusJSON)
-		if specdir.FileExists(statusPath) {
-			doc, err := specdir.ReadStatusJSON(statusPath)
-			if err == nil {
-				return doc.Status
-			}
-		}
-	}
-
-	return ""
-}
-
 // readDir is a helper to read directory entries, used by scanner and summary.
 func readDir(dir string) ([]os.DirEntry, error) {
 	return os.ReadDir(dir)
@@ -0,0 +1,209 @@
+package wave
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/config"
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+)
+
+// Checkpoint gate policies (config key checkpoint.policy).
+const (
+	PolicyDecider = "decider"  // the release-gate-decider's status decides
+	PolicyAllPass = "all-pass" // every subagent must pass
+	PolicyQuorum  = "quorum"   // checkpoint.quorum subagents must pass
+)
+
+// severityOrder lists the usual finding severities, most severe first.
+// Other severities are reported after them in name order.
+var severityOrder = []string{"critical", "high", "medium", "low"}
+
+// GateRule is one rule of a checkpoint gate and how a run fared against it.
+type GateRule struct {
+	Rule   string `json:"rule"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// reviewer is a subagent of a checkpoint run that wrote a status.json.
+type reviewer struct {
+	name string
+	doc  specdir.StatusDoc
+}
+
+// readReviewers returns the subagents of a run with a readable status.json,
+// in name order.
+func readReviewers(runDir string) []reviewer {
+	entries, err := os.ReadDir(runDir)
+	if err != nil {
+		return nil
+	}
+	var revs []reviewer
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		doc, err := specdir.ReadStatusJSON(filepath.Join(runDir, e.Name(), specdir.StatusJSON))
+		if err == nil {
+			revs = append(revs, reviewer{name: e.Name(), doc: doc})
+		}
+	}
+	return revs
+}
+
+// evaluateGate applies gate to a checkpoint run and returns the resulting
+// status with every rule checked. Under the decider policy the status is
+// the decider's own (the first subagent's without one); other policies give
+// "pass" or "blocked". Any failed rule blocks the run. A run without any
+// status.json yields "" and no rules.
+func evaluateGate(runDir string, gate config.CheckpointConfig) (string, []GateRule) {
+	revs := readReviewers(runDir)
+	if len(revs) == 0 {
+		return "", nil
+	}
+
+	var passed []string
+	for _, r := range revs {
+		if r.doc.Status == "pass" {
+			passed = append(passed, r.name)
+		}
+	}
+
+	status := "pass"
+	var rules []GateRule
+	switch gate.Policy {
+	case PolicyAllPass:
+		rule := GateRule{Rule: PolicyAllPass, Passed: len(passed) == len(revs)}
+		rule.Detail = fmt.Sprintf("%d of %d subagents passed", len(passed), len(revs))
+		if !rule.Passed {
+			rule.Detail += "; not passed: " + strings.Join(notPassed(revs), ", ")
+		}
+		rules = append(rules, rule)
+	case PolicyQuorum:
+		need := gate.Quorum
+		rules = append(rules, GateRule{
+			Rule:   PolicyQuorum,
+			Passed: len(passed) >= need,
+			Detail: fmt.Sprintf("%d of %d subagents passed, quorum %d", len(passed), len(revs), need),
+		})
+	default:
+		decider := revs[0]
+		for _, r := range revs {
+			if r.name == releaseGateDecider {
+				decider = r
+				break
+			}
+		}
+		status = decider.doc.Status
+		rules = append(rules, GateRule{
+			Rule:   PolicyDecider,
+			Passed: status == "pass",
+			Detail: fmt.Sprintf("%s: %s", decider.name, status),
+		})
+	}
+
+	if len(gate.RequiredReviewers) > 0 {
+		rules = append(rules, requiredRule(revs, gate.RequiredReviewers))
+	}
+	rules = append(rules, findingRules(revs, gate.MaxOpenFindings)...)
+
+	for _, r := range rules {
+		if !r.Passed && r.Rule != PolicyDecider {
+			status = "blocked"
+		}
+	}
+	return status, rules
+}
+
+// notPassed returns the names of the reviewers that did not pass, with
+// their status.
+func notPassed(revs []reviewer) []string {
+	var names []string
+	for _, r := range revs {
+		if r.doc.Status != "pass" {
+			names = append(names, fmt.Sprintf("%s (%s)", r.name, r.doc.Status))
+		}
+	}
+	return names
+}
+
+// requiredRule checks that every required reviewer reported pass.
+func requiredRule(revs []reviewer, required []string) GateRule {
+	var missing, failed []string
+	for _, name := range required {
+		i := slices.IndexFunc(revs, func(r reviewer) bool { return r.name == name })
+		switch {
+		case i < 0:
+			missing = append(missing, name)
+		case revs[i].doc.Status != "pass":
+			failed = append(failed, fmt.Sprintf("%s (%s)", name, revs[i].doc.Status))
+		}
+	}
+	rule := GateRule{Rule: "required-reviewers", Passed: len(missing) == 0 && len(failed) == 0}
+	var parts []string
+	if len(missing) > 0 {
+		parts = append(parts, "no status.json: "+strings.Join(missing, ", "))
+	}
+	if len(failed) > 0 {
+		parts = append(parts, "not passed: "+strings.Join(failed, ", "))
+	}
+	if rule.Passed {
+		rule.Detail = "passed: " + strings.Join(required, ", ")
+	} else {
+		rule.Detail = strings.Join(parts, "; ")
+	}
+	return rule
+}
+
+// findingRules checks the open findings of every severity with a limit.
+func findingRules(revs []reviewer, limits map[string]int) []GateRule {
+	if len(limits) == 0 {
+		return nil
+	}
+	open := make(map[string]int)
+	for _, r := range revs {
+		for _, f := range r.doc.Findings {
+			if !strings.EqualFold(f.Status, "resolved") {
+				open[strings.ToLower(f.Severity)]++
+			}
+		}
+	}
+
+	severities := make([]string, 0, len(limits))
+	for sev := range limits {
+		severities = append(severities, strings.ToLower(sev))
+	}
+	sort.Slice(severities, func(i, j int) bool {
+		a, b := slices.Index(severityOrder, severities[i]), slices.Index(severityOrder, severities[j])
+		if a < 0 && b < 0 {
+			return severities[i] < severities[j]
+		}
+		return b < 0 || (a >= 0 && a < b)
+	})
+
+	var rules []GateRule
+	for _, sev := range severities {
+		limit := limitFor(limits, sev)
+		rules = append(rules, GateRule{
+			Rule:   "max-open-findings." + sev,
+			Passed: open[sev] <= limit,
+			Detail: fmt.Sprintf("%s: %d open, max %d", sev, open[sev], limit),
+		})
+	}
+	return rules
+}
+
+// limitFor looks a severity up in the configured limits regardless of case.
+func limitFor(limits map[string]int, sev string) int {
+	for k, v := range limits {
+		if strings.EqualFold(k, sev) {
+			return v
+		}
+	}
+	return 0
+}
@@ -1,10 +1,17 @@
 package wave
 
+import (
+	"fmt"
+
+	"github.com/lucas-stellet/oraculo/internal/tasks"
+)
+
 // ComputeResume determines what action to take when resuming a spec.
 // It scans all waves and picks the appropriate resume action based on state:
 //   - If any wave is blocked, return "blocked" with that wave number.
-//   - If any wave is in_progress, return "continue-wave" with that wave number.
-//   - If all waves are complete, return "done".
+//   - If any wave is executing, awaiting its checkpoint or reopened, return
+//     "continue-wave" with that wave number.
+//   - If all waves have passed, return "done".
 //   - If the last wave is complete, return "next-wave" with next wave number.
 //   - If no waves exist, return "next-wave" with wave 1.
 func ComputeResume(specDir string) ResumeState {
@@ -19,7 +26,7 @@ func ComputeResume(specDir string) ResumeState {
 
 	// Check for blocked waves first (highest priority)
 	for _, w := range waves {
-		if w.Status == "blocked" {
+		if w.Status == StateBlocked {
 			return ResumeState{
 				Action:  "blocked",
 				WaveNum: w.WaveNum,
@@ -28,20 +35,29 @@ func ComputeResume(specDir string) ResumeState {
 		}
 	}
 
-	// Check for in-progress waves
+	// Check for waves in progress
 	for _, w := range waves {
-		if w.Status == "in_progress" {
-			return ResumeState{
-				Action:  "continue-wave",
-				WaveNum: w.WaveNum,
-				Reason:  "wave has execution runs but no passing checkpoint",
-			}
+		var reason string
+		switch w.Status {
+		case StateExecuting:
+			reason = "wave has execution runs but no checkpoint"
+		case StateAwaitingCheckpoint:
+			reason = "wave is awaiting its checkpoint result"
+		case StateReopened:
+			reason = "wave was reopened"
+		default:
+			continue
+		}
+		return ResumeState{
+			Action:  "continue-wave",
+			WaveNum: w.WaveNum,
+			Reason:  reason,
 		}
 	}
 
 	// Check for pending waves
 	for _, w := range waves {
-		if w.Status == "pending" {
+		if w.Status == StatePending {
 			return ResumeState{
 				Action:  "continue-wave",
 				WaveNum: w.WaveNum,
@@ -50,11 +66,27 @@ func ComputeResume(specDir string) ResumeState {
 		}
 	}
 
-	// All waves are complete
+	// All waves have passed
 	lastWave := waves[len(waves)-1]
 	return ResumeState{
 		Action:  "done",
 		WaveNum: lastWave.WaveNum,
-		Reason:  "all waves complete",
+		Reason:  "all waves passed",
 	}
 }
+
+// NextWave resolves the next wave of doc, reading each completed wave's
+// checkpoint through the wave state machine, so a stored state and a
+// later checkpoint result are judged the same way as in ScanWaves. A
+// stale _wave-summary.json is reported as a stale_summary warning.
+func NextWave(doc tasks.Document, specDir string) tasks.NextWaveResult {
+	resolve := CheckpointResolver(specDir)
+	return tasks.ResolveNextWave(doc, func(waveNum int) (string, []string) {
+		state, _ := CurrentState(specDir, waveNum)
+		var warnings []string
+		if cp := resolve(waveNum); cp.StaleFlag {
+			warnings = append(warnings, fmt.Sprintf("stale_summary: wave %d %s", waveNum, cp.Details))
+		}
+		return state, warnings
+	})
+}
@@ -0,0 +1,128 @@
+package wave
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	"github.com/lucas-stellet/oraculo/internal/tasks"
+)
+
+// RollbackResult reports what Rollback changed. Paths are relative to the
+// spec directory.
+type RollbackResult struct {
+	From     string             `json:"from"`
+	Tasks    []tasks.Transition `json:"tasks"`
+	Archived []string           `json:"archived"`
+	Cleared  []string           `json:"cleared"`
+}
+
+// runMove is one run directory to archive.
+type runMove struct {
+	from, to string
+}
+
+// CheckRollback returns the state of a wave that may be rolled back, and an
+// ErrIllegalTransition error for one that may not: only a passed or blocked
+// wave can be reopened.
+func CheckRollback(specDir string, waveNum int) (string, error) {
+	from, _ := CurrentState(specDir, waveNum)
+	if !CanTransition(from, StateReopened) {
+		return from, fmt.Errorf("%w: wave %d is %s; only a passed or blocked wave can be rolled back",
+			ErrIllegalTransition, waveNum, from)
+	}
+	return from, nil
+}
+
+// Rollback undoes a wave so it can run again: its tasks other than skipped
+// ones go back to pending, its execution and checkpoint run dirs move to
+// _archived/ next to them (spec.db follows them there), _latest.json and
+// _wave-summary.json are removed and the wave is reopened. The state moves
+// last, so a rollback that stops on an error can simply be run again.
+func Rollback(specDir string, waveNum int, command string) (RollbackResult, error) {
+	var res RollbackResult
+	from, err := CheckRollback(specDir, waveNum)
+	if err != nil {
+		return res, err
+	}
+	res.From = from
+
+	moves, err := archiveMoves(specDir, waveNum)
+	if err != nil {
+		return res, err
+	}
+
+	batch, err := tasks.MarkMany(specdir.TasksPath(specDir), specDir, tasks.BatchRequest{
+		Wave:   waveNum,
+		Status: "pending",
+	})
+	if err != nil {
+		return res, err
+	}
+	res.Tasks = batch.Transitions
+
+	s := store.TryOpen(specDir)
+	if s != nil {
+		defer s.Close()
+	}
+	res.Archived = []string{}
+	for _, m := range moves {
+		if err := os.MkdirAll(filepath.Dir(m.to), 0755); err != nil {
+			return res, err
+		}
+		if err := os.Rename(m.from, m.to); err != nil {
+			return res, fmt.Errorf("archive %s: %w", m.from, err)
+		}
+		relFrom, _ := filepath.Rel(specDir, m.from)
+		relTo, _ := filepath.Rel(specDir, m.to)
+		if s != nil {
+			_, _ = s.UpdateRunCommsPath(relFrom, relTo)
+		}
+		res.Archived = append(res.Archived, relTo)
+	}
+
+	res.Cleared = []string{}
+	for _, path := range []string{specdir.WaveLatestPath(specDir, waveNum), specdir.WaveSummaryPath(specDir, waveNum)} {
+		err := os.Remove(path)
+		if err == nil {
+			rel, _ := filepath.Rel(specDir, path)
+			res.Cleared = append(res.Cleared, rel)
+		} else if !os.IsNotExist(err) {
+			return res, err
+		}
+	}
+
+	if _, err := Transition(specDir, waveNum, StateReopened, command); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// archiveMoves lists the run dirs of a wave with the _archived/ paths they
+// move to. An archived run with the same name stops the rollback before
+// anything changes.
+func archiveMoves(specDir string, waveNum int) ([]runMove, error) {
+	var moves []runMove
+	for _, dir := range []string{specdir.WaveExecPath(specDir, waveNum), specdir.WaveCheckpointPath(specDir, waveNum)} {
+		entries, err := readDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() || !runNumRe.MatchString(e.Name()) {
+				continue
+			}
+			m := runMove{
+				from: filepath.Join(dir, e.Name()),
+				to:   filepath.Join(dir, specdir.ArchivedDir, e.Name()),
+			}
+			if _, err := os.Stat(m.to); err == nil {
+				return nil, fmt.Errorf("cannot archive %s: %s already exists", m.from, m.to)
+			}
+			moves = append(moves, m)
+		}
+	}
+	return moves, nil
+}
@@ -1,24 +1,35 @@
 package wave
 
 import (
+	"slices"
+
 	"github.com/lucas-stellet/oraculo/internal/specdir"
 )
 
 // ScanWaves reads all wave directories and returns their states.
 // Uses specdir.ListWaveDirs() to enumerate, then inspects each wave's
-// execution/ and checkpoint/ subdirs to determine run counts and status.
+// execution/ and checkpoint/ subdirs to count runs. A wave's state is the
+// one stored in spec.db when it was set explicitly (an awaiting-checkpoint
+// wave whose checkpoint has reported takes the result), otherwise it is
+// inferred from those runs and the checkpoint result, judged by the
+// workspace's gate. Waves that only
+// exist in spec.db are included.
 func ScanWaves(specDir string) ([]WaveState, error) {
 	waveDirs, err := specdir.ListWaveDirs(specDir)
 	if err != nil {
 		return nil, err
 	}
+	explicit := explicitStates(specDir)
+	resolve := CheckpointResolver(specDir)
 
-	if len(waveDirs) == 0 {
+	if len(waveDirs) == 0 && len(explicit) == 0 {
 		return nil, nil
 	}
 
 	var states []WaveState
+	seen := make(map[int]bool)
 	for _, wd := range waveDirs {
+		seen[wd.Num] = true
 		ws := WaveState{
 			WaveNum: wd.Num,
 		}
@@ -31,36 +42,18 @@ func ScanWaves(specDir string) ([]WaveState, error) {
 		checkPath := specdir.WaveCheckpointPath(specDir, wd.Num)
 		ws.CheckRuns = countRunDirs(checkPath)
 
-		// Determine status based on runs and checkpoint result
-		ws.Status = classifyWaveStatus(specDir, wd.Num, ws.ExecRuns, ws.CheckRuns)
+		ws.Status, ws.StateSource = resolveState(specDir, wd.Num, resolve, explicit[wd.Num], ws.ExecRuns, ws.CheckRuns)
 
 		states = append(states, ws)
 	}
-
-	return states, nil
-}
-
-// classifyWaveStatus determines wave status from run counts and checkpoint result.
-func classifyWaveStatus(specDir string, waveNum, execRuns, checkRuns int) string {
-	if execRuns == 0 && checkRuns == 0 {
-		return "pending"
-	}
-
-	if checkRuns > 0 {
-		cp := ResolveCheckpoint(specDir, waveNum)
-		switch cp.Status {
-		case "pass":
-			return "complete"
-		case "blocked":
-			return "blocked"
+	for num, st := range explicit {
+		if !seen[num] {
+			states = append(states, WaveState{WaveNum: num, Status: st, StateSource: SourceExplicit})
 		}
 	}
+	slices.SortFunc(states, func(a, b WaveState) int { return a.WaveNum - b.WaveNum })
 
-	if execRuns > 0 {
-		return "in_progress"
-	}
-
-	return "pending"
+	return states, nil
 }
 
 // countRunDirs counts run-NNN directories in a given path.
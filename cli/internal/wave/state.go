@@ -0,0 +1,273 @@
+package wave
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/lucas-stellet/oraculo/internal/fsutil"
+	"github.com/lucas-stellet/oraculo/internal/specdir"
+	"github.com/lucas-stellet/oraculo/internal/store"
+)
+
+// Wave states. A wave moves pending → executing → awaiting-checkpoint →
+// passed or blocked. A passed or blocked wave can be reopened, after which
+// it executes again.
+const (
+	StatePending            = "pending"
+	StateExecuting          = "executing"
+	StateAwaitingCheckpoint = "awaiting-checkpoint"
+	StatePassed             = "passed"
+	StateBlocked            = "blocked"
+	StateReopened           = "reopened"
+)
+
+// States lists the wave states in lifecycle order.
+var States = []string{
+	StatePending, StateExecuting, StateAwaitingCheckpoint,
+	StatePassed, StateBlocked, StateReopened,
+}
+
+// transitions maps each state to the states it may move to.
+var transitions = map[string][]string{
+	StatePending:            {StateExecuting},
+	StateExecuting:          {StateAwaitingCheckpoint},
+	StateAwaitingCheckpoint: {StatePassed, StateBlocked},
+	StatePassed:             {StateReopened},
+	StateBlocked:            {StateReopened},
+	StateReopened:           {StateExecuting},
+}
+
+// ErrIllegalTransition is returned for a move the state machine does not
+// allow.
+var ErrIllegalTransition = errors.New("illegal wave transition")
+
+// State source values reported in WaveState.StateSource.
+const (
+	SourceExplicit = "explicit" // set by a transition and stored in spec.db
+	SourceInferred = "inferred" // derived from the wave directory
+)
+
+// ValidState reports whether s is a wave state.
+func ValidState(s string) bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// NextStates returns the states a wave in state from may move to.
+func NextStates(from string) []string {
+	return transitions[from]
+}
+
+// CanTransition reports whether a wave may move from one state to another.
+func CanTransition(from, to string) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// inferState derives the state of a wave that has never been transitioned
+// explicitly from its run directories and checkpoint result, as resolve
+// gives it.
+// Without checkpoint runs, the result recorded in _latest.json (by an
+// inline checkpoint) is used.
+func inferState(specDir string, waveNum int, resolve func(int) CheckpointResult, execRuns, checkRuns int) string {
+	var result string
+	if checkRuns > 0 {
+		result = resolve(waveNum).Status
+	} else if latest, err := specdir.ReadLatestJSON(specdir.WaveLatestPath(specDir, waveNum)); err == nil {
+		result = latest.Status
+	}
+	switch {
+	case result == "pass":
+		return StatePassed
+	case result == "blocked":
+		return StateBlocked
+	case checkRuns > 0:
+		return StateAwaitingCheckpoint
+	case execRuns > 0:
+		return StateExecuting
+	}
+	return StatePending
+}
+
+// checkpointOutcome returns passed or blocked when the newest checkpoint run
+// of a wave has a result, as resolve gives it, and "" while it has none. A result for an older
+// run, which _latest.json may still name after a new checkpoint was
+// dispatched, does not count.
+func checkpointOutcome(specDir string, waveNum int, resolve func(int) CheckpointResult) string {
+	newest, _, err := specdir.LatestRunDir(specdir.WaveCheckpointPath(specDir, waveNum))
+	if err != nil {
+		return ""
+	}
+	cp := resolve(waveNum)
+	if cp.RunID != filepath.Base(newest) && cp.Source != sourceConfig {
+		return ""
+	}
+	switch cp.Status {
+	case "pass":
+		return StatePassed
+	case "blocked":
+		return StateBlocked
+	}
+	return ""
+}
+
+// resolveState returns the state of a wave and its source from the state
+// stored in spec.db, if any, and the wave directory. A stored
+// awaiting-checkpoint only lasts until the checkpoint reports: the result
+// written to disk then decides, as no command records it in spec.db.
+func resolveState(specDir string, waveNum int, resolve func(int) CheckpointResult, stored string, execRuns, checkRuns int) (string, string) {
+	switch stored {
+	case "":
+		return inferState(specDir, waveNum, resolve, execRuns, checkRuns), SourceInferred
+	case StateAwaitingCheckpoint:
+		if st := checkpointOutcome(specDir, waveNum, resolve); st != "" {
+			return st, SourceInferred
+		}
+	}
+	return stored, SourceExplicit
+}
+
+// explicitStates returns the explicitly set wave states stored in spec.db,
+// keyed by wave number. A spec without spec.db has none; reading never
+// creates the database.
+func explicitStates(specDir string) map[int]string {
+	states := make(map[int]string)
+	if !specdir.FileExists(filepath.Join(specDir, specdir.SpecDB)) {
+		return states
+	}
+	s := store.TryOpen(specDir)
+	if s == nil {
+		return states
+	}
+	defer s.Close()
+	recs, err := s.ListWaves()
+	if err != nil {
+		return states
+	}
+	for _, r := range recs {
+		if r.StateSetAt != "" && ValidState(r.Status) {
+			states[r.WaveNumber] = r.Status
+		}
+	}
+	return states
+}
+
+// CurrentState returns the state of a wave and where it came from: the
+// state stored in spec.db when one was set, otherwise the state inferred
+// from the wave directory. A stored awaiting-checkpoint gives way to the
+// checkpoint's result once it is on disk. Checkpoint runs are judged by the
+// workspace's gate.
+func CurrentState(specDir string, waveNum int) (state, source string) {
+	return resolveState(specDir, waveNum, CheckpointResolver(specDir), explicitStates(specDir)[waveNum],
+		countRunDirs(specdir.WaveExecPath(specDir, waveNum)),
+		countRunDirs(specdir.WaveCheckpointPath(specDir, waveNum)))
+}
+
+// Transition moves a wave to state to, rejecting moves the state machine
+// does not allow, and stores the new state in spec.db with a journal event
+// naming command. It returns the previous state.
+func Transition(specDir string, waveNum int, to, command string) (string, error) {
+	if !ValidState(to) {
+		return "", fmt.Errorf("unknown wave state %q (valid: %s)", to, strings.Join(States, ", "))
+	}
+	var from string
+	err := fsutil.WithLock(filepath.Join(specDir, specdir.SpecDB), func() error {
+		from, _ = CurrentState(specDir, waveNum)
+		if !CanTransition(from, to) {
+			allowed := "none"
+			if next := NextStates(from); len(next) > 0 {
+				allowed = strings.Join(next, ", ")
+			}
+			return fmt.Errorf("%w: wave %d is %s and cannot move to %s (allowed: %s)",
+				ErrIllegalTransition, waveNum, from, to, allowed)
+		}
+		return setState(specDir, waveNum, []string{from, to}, command)
+	})
+	return from, err
+}
+
+// Advance moves a wave forward to state to along the shortest legal path,
+// as dispatches and checkpoint results do: a wave still pending when its
+// checkpoint passes steps through executing and awaiting-checkpoint. A
+// blocked wave that is run again is reopened on the way; a passed wave is
+// never reopened implicitly, that takes an explicit Transition. A wave
+// already in state to keeps it; an inferred state is stored as is, and
+// journaled when it replaces a stored awaiting-checkpoint.
+func Advance(specDir string, waveNum int, to, command string) error {
+	return fsutil.WithLock(filepath.Join(specDir, specdir.SpecDB), func() error {
+		from, source := CurrentState(specDir, waveNum)
+		if from == to {
+			if source == SourceInferred {
+				if stored, ok := explicitStates(specDir)[waveNum]; ok {
+					return setState(specDir, waveNum, []string{stored, to}, command)
+				}
+				return setState(specDir, waveNum, []string{to}, "")
+			}
+			return nil
+		}
+		path := forwardPath(from, to)
+		if path == nil {
+			return fmt.Errorf("%w: wave %d is %s and cannot advance to %s",
+				ErrIllegalTransition, waveNum, from, to)
+		}
+		return setState(specDir, waveNum, path, command)
+	})
+}
+
+// forwardPath returns the shortest chain of states from one state to
+// another, both included. Only a blocked wave may pass through reopened.
+// It returns nil when there is no such chain.
+func forwardPath(from, to string) []string {
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == to {
+			path := []string{to}
+			for p := prev[to]; p != ""; p = prev[p] {
+				path = append([]string{p}, path...)
+			}
+			return path
+		}
+		for _, next := range transitions[cur] {
+			if _, seen := prev[next]; seen || (next == StateReopened && from != StateBlocked) {
+				continue
+			}
+			prev[next] = cur
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
+
+// setState stores the last state of path in spec.db and journals the move
+// from its first state as a "wave transition" event made by command. A
+// single-state path is stored without an event.
+func setState(specDir string, waveNum int, path []string, command string) error {
+	s, err := store.Open(specDir)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	from, to := path[0], path[len(path)-1]
+	if err := s.SetWaveState(waveNum, to, ""); err != nil {
+		return err
+	}
+	if len(path) == 1 {
+		return nil
+	}
+	after := map[string]any{"state": to}
+	if len(path) > 2 {
+		after["via"] = path[1 : len(path)-1]
+	}
+	return s.RecordEvent(store.Event{
+		Command: "wave transition",
+		Wave:    &waveNum,
+		Args:    map[string]any{"wave": waveNum, "state": to, "by": command},
+		Before:  map[string]any{"state": from},
+		After:   after,
+	})
+}
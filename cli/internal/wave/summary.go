@@ -7,7 +7,8 @@ import (
 )
 
 // GenerateSummary creates a WaveSummary for a specific wave by reading
-// subagent status.json files from the latest execution run.
+// subagent status.json files from the latest checkpoint run, judged by the
+// workspace's gate, or else the latest execution run.
 func GenerateSummary(specDir string, waveNum int) WaveSummary {
 	wavePath := specdir.WavePath(specDir, waveNum)
 	if !specdir.DirExists(wavePath) {
@@ -63,10 +64,10 @@ func GenerateSummary(specDir string, waveNum int) WaveSummary {
 	if specdir.DirExists(checkDir) {
 		runDir, _, err := specdir.LatestRunDir(checkDir)
 		if err == nil {
-			status, summary := scanSubagentStatus(runDir)
-			if status != "" {
+			if cp := ResolveCheckpoint(specDir, waveNum); cp.Status == "pass" || cp.Status == "blocked" {
+				_, summary := scanSubagentStatus(runDir)
 				return WaveSummary{
-					Status:  status,
+					Status:  cp.Status,
 					Summary: summary,
 					Source:  "checkpoint_scan",
 				}
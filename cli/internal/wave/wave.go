@@ -5,11 +5,12 @@ package wave
 
 // WaveState represents the current state of a single wave directory.
 type WaveState struct {
-	WaveNum   int      `json:"wave_num"`
-	Status    string   `json:"status"`     // "pending", "in_progress", "complete", "blocked"
-	TaskIDs   []string `json:"task_ids"`
-	ExecRuns  int      `json:"exec_runs"`
-	CheckRuns int      `json:"check_runs"`
+	WaveNum     int      `json:"wave_num"`
+	Status      string   `json:"status"`       // one of States
+	StateSource string   `json:"state_source"` // "explicit", "inferred"
+	TaskIDs     []string `json:"task_ids"`
+	ExecRuns    int      `json:"exec_runs"`
+	CheckRuns   int      `json:"check_runs"`
 }
 
 // WaveSummary captures the resolved summary for a wave, indicating the source
@@ -24,12 +25,14 @@ type WaveSummary struct {
 // CheckpointResult captures the resolved checkpoint status for a wave.
 // Resolution is _latest.json-first to avoid stale _wave-summary.json bugs.
 type CheckpointResult struct {
-	WaveNum   int    `json:"wave_num"`
-	Status    string `json:"status"`    // "pass", "blocked", "missing", "no_runs"
-	RunID     string `json:"run_id"`
-	Source    string `json:"source"`    // "latest_json", "dir_scan"
-	StaleFlag bool   `json:"stale_flag"`
-	Details   string `json:"details,omitempty"`
+	WaveNum   int        `json:"wave_num"`
+	Status    string     `json:"status"` // "pass", "blocked", "missing", "no_runs"
+	RunID     string     `json:"run_id"`
+	Source    string     `json:"source"` // "latest_json", "dir_scan", "config"
+	StaleFlag bool       `json:"stale_flag"`
+	Details   string     `json:"details,omitempty"`
+	Policy    string     `json:"policy,omitempty"` // gate policy applied to the run
+	Rules     []GateRule `json:"rules,omitempty"`  // each gate rule and whether it passed
 }
 
 // ResumeState indicates what action to take when resuming a spec's execution phase.
@@ -2,9 +2,15 @@ package wave
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/lucas-stellet/oraculo/internal/config"
+	"github.com/lucas-stellet/oraculo/internal/store"
+	"github.com/lucas-stellet/oraculo/internal/tasks"
 )
 
 // --- helpers ---
@@ -175,10 +181,66 @@ func TestC4_MultipleRunsLatestMatters(t *testing.T) {
 	}
 }
 
+func TestC5_GatePolicies(t *testing.T) {
+	// C5: Configured gate policies judge every subagent of the run
+	specDir := t.TempDir()
+	runDir := filepath.Join(specDir, "execution", "waves", "wave-01", "checkpoint", "run-001")
+	writeJSON(t, filepath.Join(runDir, "release-gate-decider", "status.json"), map[string]any{
+		"status": "pass",
+		"findings": []map[string]string{
+			{"severity": "high", "summary": "missing test"},
+			{"severity": "high", "summary": "typo", "status": "resolved"},
+		},
+	})
+	writeJSON(t, filepath.Join(runDir, "traceability-judge", "status.json"), map[string]string{"status": "blocked"})
+	writeJSON(t, filepath.Join(runDir, "evidence-collector", "status.json"), map[string]string{"status": "pass"})
+
+	gate := config.Defaults().Checkpoint
+	tests := []struct {
+		name   string
+		modify func(*config.CheckpointConfig)
+		status string
+		failed []string
+	}{
+		{"decider", func(*config.CheckpointConfig) {}, "pass", nil},
+		{"all-pass", func(g *config.CheckpointConfig) { g.Policy = PolicyAllPass }, "blocked", []string{"all-pass"}},
+		{"quorum", func(g *config.CheckpointConfig) { g.Policy = PolicyQuorum }, "pass", nil},
+		{"quorum of 3", func(g *config.CheckpointConfig) { g.Policy, g.Quorum = PolicyQuorum, 3 }, "blocked", []string{"quorum"}},
+		{"required reviewers", func(g *config.CheckpointConfig) {
+			g.RequiredReviewers = []string{"evidence-collector", "traceability-judge"}
+		}, "blocked", []string{"required-reviewers"}},
+		{"open findings", func(g *config.CheckpointConfig) {
+			g.MaxOpenFindings = map[string]int{"high": 0, "critical": 0}
+		}, "blocked", []string{"max-open-findings.high"}},
+		{"open findings within limit", func(g *config.CheckpointConfig) {
+			g.MaxOpenFindings = map[string]int{"high": 1}
+		}, "pass", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := gate
+			tt.modify(&g)
+			result := ResolveCheckpointGate(specDir, 1, g)
+			if result.Status != tt.status {
+				t.Errorf("status = %q, want %q (rules %+v)", result.Status, tt.status, result.Rules)
+			}
+			var failed []string
+			for _, r := range result.Rules {
+				if !r.Passed {
+					failed = append(failed, r.Rule)
+				}
+			}
+			if strings.Join(failed, ",") != strings.Join(tt.failed, ",") {
+				t.Errorf("failed rules = %v, want %v", failed, tt.failed)
+			}
+		})
+	}
+}
+
 // --- Category D: Wave summary/scanner tests ---
 
 func TestD1_SingleWaveInProgress(t *testing.T) {
-	// D1: Single wave with exec runs -> WaveState{Status: "in_progress"}
+	// D1: Single wave with exec runs -> WaveState{Status: "executing"}
 	specDir := t.TempDir()
 
 	// Create wave-01 with one execution run but no checkpoint
@@ -197,8 +259,8 @@ func TestD1_SingleWaveInProgress(t *testing.T) {
 	if len(waves) != 1 {
 		t.Fatalf("expected 1 wave, got %d", len(waves))
 	}
-	if waves[0].Status != "in_progress" {
-		t.Errorf("expected status 'in_progress', got %q", waves[0].Status)
+	if waves[0].Status != StateExecuting {
+		t.Errorf("expected status 'executing', got %q", waves[0].Status)
 	}
 	if waves[0].ExecRuns != 1 {
 		t.Errorf("expected 1 exec run, got %d", waves[0].ExecRuns)
@@ -209,7 +271,7 @@ func TestD1_SingleWaveInProgress(t *testing.T) {
 }
 
 func TestD2_WaveWithCheckpointPass(t *testing.T) {
-	// D2: Wave with checkpoint pass -> WaveState{Status: "complete"}
+	// D2: Wave with checkpoint pass -> WaveState{Status: "passed"}
 	specDir := t.TempDir()
 
 	waveDir := filepath.Join(specDir, "execution", "waves", "wave-01")
@@ -238,8 +300,8 @@ func TestD2_WaveWithCheckpointPass(t *testing.T) {
 	if len(waves) != 1 {
 		t.Fatalf("expected 1 wave, got %d", len(waves))
 	}
-	if waves[0].Status != "complete" {
-		t.Errorf("expected status 'complete', got %q", waves[0].Status)
+	if waves[0].Status != StatePassed {
+		t.Errorf("expected status 'passed', got %q", waves[0].Status)
 	}
 	if waves[0].ExecRuns != 1 {
 		t.Errorf("expected 1 exec run, got %d", waves[0].ExecRuns)
@@ -265,3 +327,296 @@ func TestD3_EmptyWavesDir(t *testing.T) {
 		t.Errorf("expected nil, got %v", waves)
 	}
 }
+
+// --- Category E: State machine ---
+
+func TestTransition(t *testing.T) {
+	specDir := t.TempDir()
+
+	if _, err := Transition(specDir, 1, StatePassed, "test"); !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("pending -> passed: err = %v, want ErrIllegalTransition", err)
+	}
+	if _, err := Transition(specDir, 1, "done", "test"); err == nil || errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("unknown state: err = %v", err)
+	}
+	for _, to := range []string{StateExecuting, StateAwaitingCheckpoint, StateBlocked, StateReopened, StateExecuting} {
+		if _, err := Transition(specDir, 1, to, "test"); err != nil {
+			t.Fatalf("transition to %s: %v", to, err)
+		}
+	}
+
+	st, source := CurrentState(specDir, 1)
+	if st != StateExecuting || source != SourceExplicit {
+		t.Errorf("CurrentState = %s (%s), want executing (explicit)", st, source)
+	}
+	waves, err := ScanWaves(specDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(waves) != 1 || waves[0].Status != StateExecuting || waves[0].StateSource != SourceExplicit {
+		t.Errorf("ScanWaves = %+v, want wave 1 executing (explicit)", waves)
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	specDir := t.TempDir()
+
+	// A pending wave whose checkpoint passes steps through the states.
+	if err := Advance(specDir, 1, StatePassed, "test"); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+	if st, _ := CurrentState(specDir, 1); st != StatePassed {
+		t.Fatalf("state = %s, want passed", st)
+	}
+	// A passed wave is not reopened implicitly.
+	if err := Advance(specDir, 1, StateExecuting, "test"); !errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("passed -> executing: err = %v, want ErrIllegalTransition", err)
+	}
+
+	// A blocked wave run again is.
+	if err := Advance(specDir, 2, StateBlocked, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Advance(specDir, 2, StateExecuting, "test"); err != nil {
+		t.Errorf("blocked -> executing: %v", err)
+	}
+
+	if got := forwardPath(StatePending, StatePassed); len(got) != 4 {
+		t.Errorf("forwardPath = %v, want pending, executing, awaiting-checkpoint, passed", got)
+	}
+}
+
+func TestAwaitingCheckpointTakesResult(t *testing.T) {
+	specDir := t.TempDir()
+	if err := Advance(specDir, 1, StateAwaitingCheckpoint, "test"); err != nil {
+		t.Fatal(err)
+	}
+	checkDir := filepath.Join(specDir, "execution", "waves", "wave-01", "checkpoint")
+	mkdirAll(t, filepath.Join(checkDir, "run-001", "release-gate-decider"))
+	if st, src := CurrentState(specDir, 1); st != StateAwaitingCheckpoint || src != SourceExplicit {
+		t.Fatalf("before the result: %s (%s), want awaiting-checkpoint (explicit)", st, src)
+	}
+
+	writeJSON(t, filepath.Join(checkDir, "run-001", "release-gate-decider", "status.json"), map[string]string{"status": "blocked"})
+	if st, _ := CurrentState(specDir, 1); st != StateBlocked {
+		t.Fatalf("after a blocked result: %s, want blocked", st)
+	}
+	if waves, _ := ScanWaves(specDir); len(waves) != 1 || waves[0].Status != StateBlocked {
+		t.Fatalf("ScanWaves = %+v, want wave 1 blocked", waves)
+	}
+	if r := ComputeResume(specDir); r.Action != "blocked" {
+		t.Errorf("resume = %+v, want blocked", r)
+	}
+
+	// Run again: the old run's result must not decide the new checkpoint.
+	for _, st := range []string{StateExecuting, StateAwaitingCheckpoint} {
+		if err := Advance(specDir, 1, st, "test"); err != nil {
+			t.Fatalf("advance to %s: %v", st, err)
+		}
+	}
+	writeJSON(t, filepath.Join(specDir, "execution", "waves", "wave-01", "_latest.json"), map[string]string{"run_id": "run-001"})
+	mkdirAll(t, filepath.Join(checkDir, "run-002", "release-gate-decider"))
+	if st, _ := CurrentState(specDir, 1); st != StateAwaitingCheckpoint {
+		t.Fatalf("new checkpoint pending: %s, want awaiting-checkpoint", st)
+	}
+
+	writeJSON(t, filepath.Join(specDir, "execution", "waves", "wave-01", "_latest.json"), map[string]string{"run_id": "run-002"})
+	writeJSON(t, filepath.Join(checkDir, "run-002", "release-gate-decider", "status.json"), map[string]string{"status": "pass"})
+	if st, _ := CurrentState(specDir, 1); st != StatePassed {
+		t.Fatalf("after a pass: %s, want passed", st)
+	}
+
+	// Storing the result journals the move out of awaiting-checkpoint.
+	if err := Advance(specDir, 1, StatePassed, "wave checkpoint"); err != nil {
+		t.Fatal(err)
+	}
+	s, err := store.Open(specDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	events, _ := s.ListEvents(store.EventFilter{Command: "wave transition"})
+	last := events[len(events)-1]
+	if before, _ := last.Before.(map[string]any); before["state"] != StateAwaitingCheckpoint {
+		t.Errorf("last transition = %+v, want from awaiting-checkpoint", last)
+	}
+	if st, src := CurrentState(specDir, 1); st != StatePassed || src != SourceExplicit {
+		t.Errorf("stored: %s (%s), want passed (explicit)", st, src)
+	}
+}
+
+const nextWaveTasks = `# Tasks: next-wave-test
+
+## Tasks
+
+- [x] 1 First task
+  Wave: 1
+
+- [ ] 2 Second task
+  Wave: 2
+  Depends On: Task 1
+`
+
+func TestNextWave(t *testing.T) {
+	doc := tasks.Parse(nextWaveTasks)
+	specDir := t.TempDir()
+	waveDir := filepath.Join(specDir, "execution", "waves", "wave-01")
+	mkdirAll(t, filepath.Join(waveDir, "execution", "run-001"))
+	gate := filepath.Join(waveDir, "checkpoint", "run-001", "release-gate-decider", "status.json")
+	writeJSON(t, gate, map[string]string{"status": "blocked"})
+
+	if next := NextWave(doc, specDir); next.Action != "blocked" || next.Wave != 1 {
+		t.Fatalf("blocked checkpoint: next = %+v, want blocked wave 1", next)
+	}
+
+	// A stored awaiting-checkpoint yields to the passing result, and a
+	// _wave-summary.json disagreeing with _latest.json is reported.
+	if err := Advance(specDir, 1, StateAwaitingCheckpoint, "test"); err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, gate, map[string]string{"status": "pass"})
+	writeJSON(t, filepath.Join(waveDir, "_latest.json"), map[string]any{"run_id": "run-001", "status": "pass"})
+	writeJSON(t, filepath.Join(waveDir, "_wave-summary.json"), map[string]string{"status": "blocked"})
+
+	next := NextWave(doc, specDir)
+	if next.Action != "execute" || next.Wave != 2 || len(next.TaskIDs) != 1 || next.TaskIDs[0] != "2" {
+		t.Fatalf("passed checkpoint: next = %+v, want execute task 2 of wave 2", next)
+	}
+	stale := false
+	for _, w := range next.Warnings {
+		stale = stale || strings.HasPrefix(w, "stale_summary: wave 1 ")
+	}
+	if !stale {
+		t.Errorf("warnings = %v, want a stale_summary warning for wave 1", next.Warnings)
+	}
+}
+
+func TestConfiguredGate(t *testing.T) {
+	// The decider passes but a reviewer blocked: only all-pass blocks.
+	root := t.TempDir()
+	specDir := filepath.Join(root, ".spec-workflow", "specs", "demo")
+	mkdirAll(t, specDir)
+	if err := os.WriteFile(filepath.Join(specDir, "tasks.md"), []byte(nextWaveTasks), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runDir := filepath.Join(specDir, "execution", "waves", "wave-01", "checkpoint", "run-001")
+	writeJSON(t, filepath.Join(runDir, "release-gate-decider", "status.json"), map[string]string{"status": "pass"})
+	writeJSON(t, filepath.Join(runDir, "traceability-judge", "status.json"), map[string]string{"status": "blocked"})
+
+	if st, _ := CurrentState(specDir, 1); st != StatePassed {
+		t.Fatalf("default gate: state = %s, want passed", st)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, ".spec-workflow", "oraculo.toml"), []byte("[checkpoint]\npolicy = \"all-pass\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if g, err := Gate(specDir); err != nil || g.Policy != PolicyAllPass {
+		t.Fatalf("Gate = %+v, %v; want all-pass", g, err)
+	}
+	if st, _ := CurrentState(specDir, 1); st != StateBlocked {
+		t.Errorf("CurrentState = %s, want blocked", st)
+	}
+	if waves, err := ScanWaves(specDir); err != nil || len(waves) != 1 || waves[0].Status != StateBlocked {
+		t.Errorf("ScanWaves = %+v, %v; want wave 1 blocked", waves, err)
+	}
+	if rs := ComputeResume(specDir); rs.Action != "blocked" || rs.WaveNum != 1 {
+		t.Errorf("ComputeResume = %+v, want blocked wave 1", rs)
+	}
+	if sum := GenerateSummary(specDir, 1); sum.Status != "blocked" {
+		t.Errorf("GenerateSummary status = %q, want blocked", sum.Status)
+	}
+	if next := NextWave(tasks.Parse(nextWaveTasks), specDir); next.Action != "blocked" || next.Wave != 1 {
+		t.Errorf("NextWave = %+v, want blocked wave 1", next)
+	}
+
+	// A stored awaiting-checkpoint takes the result under the same gate.
+	if err := os.RemoveAll(runDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := Advance(specDir, 1, StateAwaitingCheckpoint, "test"); err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, filepath.Join(runDir, "release-gate-decider", "status.json"), map[string]string{"status": "pass"})
+	writeJSON(t, filepath.Join(runDir, "traceability-judge", "status.json"), map[string]string{"status": "blocked"})
+	if st, _ := CurrentState(specDir, 1); st != StateBlocked {
+		t.Errorf("awaiting wave: state = %s, want blocked", st)
+	}
+
+	// A typo in the policy fails closed instead of falling back to the
+	// decider, which passes this run.
+	if err := os.WriteFile(filepath.Join(root, ".spec-workflow", "oraculo.toml"), []byte("[checkpoint]\npolicy = \"allpass\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, filepath.Join(runDir, "traceability-judge", "status.json"), map[string]string{"status": "pass"})
+	if _, err := Gate(specDir); err == nil {
+		t.Error("Gate: expected error for an unknown policy")
+	}
+	if cp := ResolveCheckpoint(specDir, 1); cp.Status != "blocked" || cp.Source != "config" {
+		t.Errorf("ResolveCheckpoint = %+v, want blocked by config", cp)
+	}
+	if st, _ := CurrentState(specDir, 1); st != StateBlocked {
+		t.Errorf("invalid gate: state = %s, want blocked", st)
+	}
+}
+
+const rollbackTasks = `# Tasks: rollback-test
+
+## Tasks
+
+- [x] 1 First task
+  Wave: 1
+
+- [~] 2 Second task
+  Reason: covered by task 1
+  Wave: 1
+
+- [x] 3 Third task
+  Wave: 2
+`
+
+func TestRollback(t *testing.T) {
+	specDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(specDir, "tasks.md"), []byte(rollbackTasks), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waveDir := filepath.Join(specDir, "execution", "waves", "wave-01")
+	mkdirAll(t, filepath.Join(waveDir, "execution", "run-001"))
+	mkdirAll(t, filepath.Join(waveDir, "checkpoint", "run-001"))
+	writeJSON(t, filepath.Join(waveDir, "_latest.json"), map[string]any{"status": "pass"})
+
+	// A wave awaiting its checkpoint cannot be rolled back.
+	if err := Advance(specDir, 1, StateAwaitingCheckpoint, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Rollback(specDir, 1, "test"); !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("rollback of unchecked wave: err = %v, want ErrIllegalTransition", err)
+	}
+
+	if err := Advance(specDir, 1, StatePassed, "test"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := Rollback(specDir, 1, "test")
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if res.From != StatePassed || len(res.Tasks) != 1 || res.Tasks[0].TaskID != "1" {
+		t.Errorf("result = %+v, want task 1 reset from a passed wave", res)
+	}
+	if len(res.Archived) != 2 || len(res.Cleared) != 1 {
+		t.Errorf("archived %v, cleared %v; want 2 runs and _latest.json", res.Archived, res.Cleared)
+	}
+	for _, sub := range []string{"execution", "checkpoint"} {
+		if _, err := os.Stat(filepath.Join(waveDir, sub, "_archived", "run-001")); err != nil {
+			t.Errorf("%s run not archived: %v", sub, err)
+		}
+	}
+	if st, _ := CurrentState(specDir, 1); st != StateReopened {
+		t.Errorf("state = %s, want reopened", st)
+	}
+	data, _ := os.ReadFile(filepath.Join(specDir, "tasks.md"))
+	for _, line := range []string{"- [ ] 1 First task", "- [~] 2 Second task", "- [x] 3 Third task"} {
+		if !strings.Contains(string(data), line) {
+			t.Errorf("tasks.md lacks %q:\n%s", line, data)
+		}
+	}
+}